@@ -40,12 +40,16 @@ type RootCommand struct {
 	TFEOrg     string
 	TFEToken   string
 	TFEAddress string
+	ConfigFile string
 
 	// Global instances.
 	Stdin  io.Reader
 	Stdout io.Writer
 	Stderr io.Writer
 	Logger log.Logger
+	// Reload returns the running command parsed again from its configuration sources, used
+	// to reload the configuration file without restarting.
+	Reload func() (Command, error)
 }
 
 // NewRootCommand initializes the main root configuration.
@@ -60,6 +64,7 @@ func NewRootCommand(app *kingpin.Application) *RootCommand {
 	app.Flag("tfe-organization", "The Terraform cloud or enterprise organization.").Required().StringVar(&c.TFEOrg)
 	app.Flag("tfe-token", "The Terraform cloud or enterprise API token.").Required().StringVar(&c.TFEToken)
 	app.Flag("tfe-address", "The address of the Terraform Enterprise API.").Default(tfe.DefaultAddress).StringVar(&c.TFEAddress)
+	app.Flag(configFlagName, "YAML or JSON configuration file with the flag values, the global flags at the root and the command flags under the command name, command line flags and env vars take precedence over it.").StringVar(&c.ConfigFile)
 
 	return c
 }
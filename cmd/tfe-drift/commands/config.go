@@ -0,0 +1,182 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/alecthomas/kingpin/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const configFlagName = "config"
+
+// ApplyConfigFile returns the command line arguments extended with the flag values of the configuration
+// file (YAML or JSON) set with `--config`.
+//
+// The configuration file keys are the flag names, the global flags are set at the root and the command
+// flags under the command name key (e.g: `controller`). The flags set on the command line or with env vars
+// take precedence over the configuration file.
+func ApplyConfigFile(app *kingpin.Application, args []string) ([]string, error) {
+	pctx, err := app.ParseContext(args)
+	if err != nil {
+		// Let the regular parsing report the command line errors.
+		return args, nil
+	}
+
+	path := app.GetFlag(configFlagName).GetEnvarValue()
+	setFlags := map[string]bool{}
+	for _, e := range pctx.Elements {
+		f, ok := e.Clause.(*kingpin.FlagClause)
+		if !ok {
+			continue
+		}
+		name := f.Model().Name
+		setFlags[name] = true
+		if name == configFlagName && e.Value != nil {
+			path = *e.Value
+		}
+	}
+	if path == "" {
+		return args, nil
+	}
+
+	config, err := loadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	selectedCmd := ""
+	if pctx.SelectedCommand != nil {
+		selectedCmd = pctx.SelectedCommand.FullCommand()
+	}
+
+	var configArgs []string
+	for _, key := range sortedConfigKeys(config) {
+		// Command flags.
+		if cmd := app.GetCommand(key); cmd != nil {
+			section, ok := config[key].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid config %q: must be a map of %q command flags", key, key)
+			}
+
+			for _, flagKey := range sortedConfigKeys(section) {
+				f := cmd.GetFlag(flagKey)
+				if f == nil {
+					return nil, fmt.Errorf("invalid config %q: unknown %q command flag", key+"."+flagKey, key)
+				}
+
+				fargs, err := configFlagArgs(f, section[flagKey])
+				if err != nil {
+					return nil, fmt.Errorf("invalid config %q: %w", key+"."+flagKey, err)
+				}
+				if key == selectedCmd && !setFlags[flagKey] && !f.HasEnvarValue() {
+					configArgs = append(configArgs, fargs...)
+				}
+			}
+			continue
+		}
+
+		// Global flags.
+		f := app.GetFlag(key)
+		if f == nil || key == configFlagName {
+			return nil, fmt.Errorf("invalid config %q: unknown flag or command", key)
+		}
+
+		fargs, err := configFlagArgs(f, config[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid config %q: %w", key, err)
+		}
+		if !setFlags[key] && !f.HasEnvarValue() {
+			configArgs = append(configArgs, fargs...)
+		}
+	}
+
+	// Config flags go before the positional args terminator (if any).
+	i := 0
+	for i < len(args) && args[i] != "--" {
+		i++
+	}
+	res := make([]string, 0, len(args)+len(configArgs))
+	res = append(res, args[:i]...)
+	res = append(res, configArgs...)
+	res = append(res, args[i:]...)
+
+	return res, nil
+}
+
+func loadConfigFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	// JSON is valid YAML, so we can use the same decoder for both.
+	config := map[string]any{}
+	err = yaml.Unmarshal(data, &config)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode config file %q: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// configFlagArgs converts a flag config value into command line arguments, lists are used for repeatable
+// flags and maps for repeatable flags with `<key>=<value>` format (non scalar values are encoded in JSON).
+func configFlagArgs(f *kingpin.FlagClause, value any) ([]string, error) {
+	m := f.Model()
+	if m.IsBoolFlag() {
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		if !b {
+			return []string{"--no-" + m.Name}, nil
+		}
+		return []string{"--" + m.Name}, nil
+	}
+
+	values := []any{value}
+	switch v := value.(type) {
+	case []any:
+		values = v
+	case map[string]any:
+		values = []any{}
+		for _, k := range sortedConfigKeys(v) {
+			kv := v[k]
+			switch kv.(type) {
+			case string, int, float64, bool:
+			default:
+				data, err := json.Marshal(kv)
+				if err != nil {
+					return nil, fmt.Errorf("could not encode %q: %w", k, err)
+				}
+				kv = string(data)
+			}
+			values = append(values, fmt.Sprintf("%s=%v", k, kv))
+		}
+	}
+
+	args := make([]string, 0, len(values))
+	for _, v := range values {
+		switch v.(type) {
+		case string, int, float64, bool:
+			args = append(args, fmt.Sprintf("--%s=%v", m.Name, v))
+		default:
+			return nil, fmt.Errorf("must be a scalar or a list of scalars")
+		}
+	}
+
+	return args, nil
+}
+
+func sortedConfigKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
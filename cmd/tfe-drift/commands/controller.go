@@ -2,9 +2,16 @@ package commands
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/pprof"
+	"os"
+	"os/signal"
+	"reflect"
+	"slices"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
@@ -14,9 +21,13 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
 	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/http/api"
+	"github.com/slok/tfe-drift/internal/http/ui"
+	"github.com/slok/tfe-drift/internal/internalerrors"
 	"github.com/slok/tfe-drift/internal/log"
 	internalprometheus "github.com/slok/tfe-drift/internal/metrics/prometheus"
 	fakestorage "github.com/slok/tfe-drift/internal/storage/fake"
+	filestorage "github.com/slok/tfe-drift/internal/storage/file"
 	tfestorage "github.com/slok/tfe-drift/internal/storage/tfe"
 	"github.com/slok/tfe-drift/internal/workspace/process"
 	wksprocess "github.com/slok/tfe-drift/internal/workspace/process"
@@ -36,16 +47,46 @@ type ControllerCommand struct {
 	waitTimeout          time.Duration
 	dryRun               bool
 	detectInterval       time.Duration
+	detectCron           string
+	detectCronTimezone   string
 	disableDriftDetector bool
+	maxInflightCycles    int
+	blackoutWindows      []string
 	metricsTimeout       time.Duration
 	listenAddress        string
 	metricsPath          string
 	healthCheckPath      string
+	livenessPath         string
+	readinessPath        string
+	readinessMaxDetAge   time.Duration
 	pprofPath            string
 	fetchWorkers         int
 	fakeTFE              bool
+	emailNotifier        emailNotifierFlags
+	githubNotifier       githubNotifierFlags
+	pagerdutyNotifier    pagerdutyNotifierFlags
+	alertmanagerNotifier alertmanagerNotifierFlags
+	transitions          transitionsFlags
+	sorting              sortFlags
+	emailDigestInterval  time.Duration
+	leaderElection       string
+	leaderElectionID     string
+	leaderElectionTTL    time.Duration
+	leaderElectionFile   string
+	leaderElectionTFEWk  string
+	apiToken             string
+	uiPath               string
+	disableUI            bool
+	configWatchInterval  time.Duration
+	profiles             []string
 }
 
+const (
+	leaderElectionNone = "none"
+	leaderElectionFile = "file"
+	leaderElectionTFE  = "tfe"
+)
+
 // NewControllerCommand returns the Controller command.
 func NewControllerCommand(rootConfig *RootCommand, app *kingpin.Application) *ControllerCommand {
 	cmd := app.Command("controller", "Runs drift detector in controller mode.")
@@ -64,14 +105,38 @@ func NewControllerCommand(rootConfig *RootCommand, app *kingpin.Application) *Co
 	cmd.Flag("wait-timeout", "Max time duration to wait for drift detection plans to finish.").Default("1h").DurationVar(&c.waitTimeout)
 	cmd.Flag("dry-run", "Will execute all the process without creating any drift detection plans, will use latest ones available.").BoolVar(&c.dryRun)
 	cmd.Flag("detect-interval", "The interval that the app will run a drift detection.").Default("5m").DurationVar(&c.detectInterval)
+	cmd.Flag("detect-cron", "Cron expression (e.g: `0 * * * 1-5`, `@hourly`) that schedules the drift detections, if set, it will be used instead of the detect interval.").StringVar(&c.detectCron)
+	cmd.Flag("detect-cron-timezone", "The timezone used to evaluate the detect cron expression (e.g: `Europe/Madrid`).").Default("UTC").StringVar(&c.detectCronTimezone)
+	cmd.Flag("blackout-window", "Window where drift detection plans will not be created with `[tag:<tag>|name:<regex>=]<days> <HH:MM>-<HH:MM> [<timezone>]` (recurring) or `[tag:<tag>|name:<regex>=]<RFC3339>/<RFC3339>` (one-off) format, without selector it will apply to all workspaces (can be repeated).").StringsVar(&c.blackoutWindows)
+	cmd.Flag("max-inflight-cycles", "The maximum drift detection cycles tracking their plans in the background, when reached, the next drift detections will be skipped (0 is unlimited).").Default("10").IntVar(&c.maxInflightCycles)
 	cmd.Flag("disable-drift-detector", "Will disable the drift detector, this can be useful when you want ot run only the metrics exporter.").BoolVar(&c.disableDriftDetector)
 	cmd.Flag("metrics-exporter-timeout", "Duration timeout used for the prometheus exporter metrics collector.").Default("45s").DurationVar(&c.metricsTimeout)
 	cmd.Flag("listen-address", "The address where the will be listening.").Default(":8080").StringVar(&c.listenAddress)
 	cmd.Flag("metrics-path", "The path where Prometheus metrics will be served.").Default("/metrics").StringVar(&c.metricsPath)
 	cmd.Flag("health-check-path", "The path where the health check will be served.").Default("/status").StringVar(&c.healthCheckPath)
+	cmd.Flag("liveness-path", "The path where the liveness probe will be served.").Default("/livez").StringVar(&c.livenessPath)
+	cmd.Flag("readiness-path", "The path where the readiness probe will be served.").Default("/readyz").StringVar(&c.readinessPath)
+	cmd.Flag("readiness-max-detection-age", "The max duration without a successful drift detection before being not ready (by default twice the schedule period plus the wait timeout).").DurationVar(&c.readinessMaxDetAge)
 	cmd.Flag("pprof-path", "The path where the pprof handlers will be served.").Default("/debug/pprof").StringVar(&c.pprofPath)
 	cmd.Flag("fetch-workers", "The number of workers running concurrently to fetch workspaces information.").Default("20").IntVar(&c.fetchWorkers)
 	cmd.Flag("fake-tfe", "Will fake the TFE repository, mainly used for development.").BoolVar(&c.fakeTFE)
+	cmd.Flag("email-digest-interval", "The interval that the drift digest email will be sent.").Default("24h").DurationVar(&c.emailDigestInterval)
+	cmd.Flag("leader-election", "The leader election used when running multiple controller replicas, only the leader will run the drift detections.").Default(leaderElectionNone).EnumVar(&c.leaderElection, leaderElectionNone, leaderElectionFile, leaderElectionTFE)
+	cmd.Flag("leader-election-id", "The identity of the controller replica used on the leader election (by default the hostname).").StringVar(&c.leaderElectionID)
+	cmd.Flag("leader-election-ttl", "The duration of the leadership without renewals.").Default("30s").DurationVar(&c.leaderElectionTTL)
+	cmd.Flag("leader-election-file", "The lease file path used by the file leader election, must be shared between the controller replicas.").StringVar(&c.leaderElectionFile)
+	cmd.Flag("leader-election-tfe-workspace", "The dedicated workspace name used by the TFE leader election to store the lease.").StringVar(&c.leaderElectionTFEWk)
+	cmd.Flag("api-token", "The bearer token required by the HTTP API (e.g: on-demand drift detections, workspaces state), if not set the API will be disabled.").StringVar(&c.apiToken)
+	cmd.Flag("ui-path", "The path where the read-only web UI will be served.").Default("/ui").StringVar(&c.uiPath)
+	cmd.Flag("disable-ui", "Will disable the read-only web UI.").BoolVar(&c.disableUI)
+	cmd.Flag("profile", "Named drift detection profile with `<name>=<json>` format, each profile runs its own drift detections, the JSON object keys are the flags that can be customized (include-name, exclude-name, include-tag, exclude-tag, detect-interval, detect-cron, detect-cron-timezone, not-before, limit-max-plans and plan-message), the unset ones use the flag values (can be repeated).").StringsVar(&c.profiles)
+	cmd.Flag("config-watch-interval", "The interval used to check the configuration file changes to reload it (0 disables the watching, SIGHUP will still reload it).").Default("10s").DurationVar(&c.configWatchInterval)
+	c.emailNotifier.register(cmd)
+	c.githubNotifier.register(cmd)
+	c.pagerdutyNotifier.register(cmd)
+	c.alertmanagerNotifier.register(cmd)
+	c.transitions.register(cmd)
+	c.sorting.register(cmd)
 
 	return c
 }
@@ -81,29 +146,32 @@ func (c ControllerCommand) Run(ctx context.Context) error {
 	logger := c.rootConfig.Logger
 	notVerboseLogger := infoAsDebugLogger{Logger: logger}
 
-	if len(c.excludeNameRegexes) > 0 && len(c.includeNameRegexes) > 0 {
-		return fmt.Errorf("include and exclude name options can't be used at the same time")
-	}
-
 	if len(c.includeTags) > 0 && len(c.excludeTags) > 0 {
 		return fmt.Errorf("include and exclude tag options can't be used at the same time")
 	}
 
-	// Sanitize names and tags by splitting using commas.
+	// Sanitize tags by splitting using commas.
 	const repeatedArgSplitChar = ","
-	excludeNameRegexes := splitRepeatedArg(c.excludeNameRegexes, repeatedArgSplitChar)
-	includeNameRegexes := splitRepeatedArg(c.includeNameRegexes, repeatedArgSplitChar)
 	includeTags := splitRepeatedArg(c.includeTags, repeatedArgSplitChar)
 	excludeTags := splitRepeatedArg(c.excludeTags, repeatedArgSplitChar)
 
+	metricsRecorder, err := internalprometheus.NewControllerRecorder(prometheus.DefaultRegisterer)
+	if err != nil {
+		return fmt.Errorf("could not create controller metrics recorder: %w", err)
+	}
+
 	var repo tfestorage.Repository
+	var client *tfe.Client
+	var pinger controller.Pinger
 	if !c.fakeTFE {
 		config := &tfe.Config{
 			Token:   c.rootConfig.TFEToken,
 			Address: c.rootConfig.TFEAddress,
+			// Measure the TFE API requests.
+			HTTPClient: &http.Client{Transport: tfestorage.NewMetricsRoundTripper(nil, metricsRecorder)},
 		}
 
-		client, err := tfe.NewClient(config)
+		client, err = tfe.NewClient(config)
 		if err != nil {
 			return err
 		}
@@ -114,6 +182,7 @@ func (c ControllerCommand) Run(ctx context.Context) error {
 		if err != nil {
 			return fmt.Errorf("could not create tfe storage repository: %w", err)
 		}
+		pinger = tfestorage.NewPinger(repoTFEClient, c.rootConfig.TFEOrg)
 
 		if c.dryRun {
 			repo = tfestorage.NewDryRunRepository(notVerboseLogger, repo)
@@ -122,52 +191,400 @@ func (c ControllerCommand) Run(ctx context.Context) error {
 		repo = fakestorage.NewRepository()
 	}
 
-	var includeProcessor process.Processor = process.NoopProcessor
-	if len(includeNameRegexes) > 0 {
-		p, err := wksprocess.NewIncludeNameProcessor(notVerboseLogger, includeNameRegexes)
+	// Drift detection processors, replaced on configuration reloads.
+	procs, err := c.newDetectionProcessors(ctx, notVerboseLogger, metricsRecorder, repo)
+	if err != nil {
+		return err
+	}
+	reloadableProcs := newReloadableDetectionProcessors(procs)
+	includeProcessor := reloadableProcs.include
+	excludeProcessor := reloadableProcs.exclude
+
+	blackouts, err := controller.ParseBlackouts(c.blackoutWindows)
+	if err != nil {
+		return err
+	}
+
+	var g run.Group
+
+	// Leader election.
+	var leaderElector *controller.LeaderElector
+	if c.leaderElection != leaderElectionNone {
+		lock, err := c.newLeaderLock(client)
 		if err != nil {
-			return fmt.Errorf("invalid include processor: %w", err)
+			return err
 		}
-		includeProcessor = p
+
+		id := c.leaderElectionID
+		if id == "" {
+			id, err = os.Hostname()
+			if err != nil {
+				return fmt.Errorf("could not get hostname for the leader election ID: %w", err)
+			}
+		}
+
+		leaderElector, err = controller.NewLeaderElector(controller.LeaderElectorConfig{
+			Logger:          logger,
+			Lock:            lock,
+			ID:              id,
+			TTL:             c.leaderElectionTTL,
+			MetricsRecorder: metricsRecorder,
+		})
+		if err != nil {
+			return fmt.Errorf("controller leader elector could not be created: %w", err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		g.Add(
+			func() error {
+				err := leaderElector.Run(ctx)
+				if err != nil && ctx.Err() == nil {
+					return fmt.Errorf("controller leader elector had an error: %w", err)
+				}
+
+				return nil
+			},
+			func(_ error) {
+				cancel()
+			},
+		)
 	}
 
-	var excludeProcessor process.Processor = process.NoopProcessor
-	if len(excludeNameRegexes) > 0 {
-		p, err := wksprocess.NewExcludeNameProcessor(notVerboseLogger, excludeNameRegexes)
+	var leader controller.Leader
+	if leaderElector != nil {
+		leader = leaderElector
+	}
+
+	// Drift detection processor chain, shared by the scheduled and on-demand drift detections.
+	var githubNotifyProcessor process.Processor = process.NoopProcessor
+	if c.githubNotifier.enabled() {
+		p, err := c.githubNotifier.newProcessor(logger, c.transitions.enabled())
 		if err != nil {
-			return fmt.Errorf("invalid exclude processor: %w", err)
+			return err
 		}
-		excludeProcessor = p
+		githubNotifyProcessor = p
 	}
 
-	var g run.Group
+	var pagerdutyNotifyProcessor process.Processor = process.NoopProcessor
+	if c.pagerdutyNotifier.enabled() {
+		p, err := c.pagerdutyNotifier.newProcessor(logger, c.transitions.enabled())
+		if err != nil {
+			return err
+		}
+		pagerdutyNotifyProcessor = p
+	}
+
+	notifyProcessor, err := c.transitions.newProcessor(logger, repo, []wksprocess.Processor{
+		githubNotifyProcessor,
+		pagerdutyNotifyProcessor,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid notify transitions processor: %w", err)
+	}
+
+	// Latest known workspaces state, served by the API and the UI, and used by the Alertmanager alerts.
+	wkStates := controller.NewWorkspaceStates()
+
+	// Drift detections are split in the plans creation and the created plans tracking, so the scheduled drift
+	// detections can track the plans in the background.
+	newTrackerChain := func(procs *reloadableDetectionProcessors) wksprocess.Processor {
+		return wksprocess.NewProcessorChain([]wksprocess.Processor{
+			procs.wait,
+			notifyProcessor,
+			wkStates.UpdateProcessor(),
+		})
+	}
 
-	// Controller.
+	// Scheduled and on-demand drift detections don't run on the same workspaces concurrently.
+	wksLocks := controller.NewWorkspaceLocks()
+
+	// Controller, every drift detection profile runs its own drift detector sharing the rest of the components.
+	profiles := map[string]ControllerCommand{}
+	driftDetectors := map[string]*controller.DriftDetector{}
+	profileProcs := map[string]*reloadableDetectionProcessors{}
+	readinessChecks := map[string]controller.ReadinessCheck{}
 	if c.disableDriftDetector {
 		logger.Infof("Drift detector controller disabled")
 	} else {
+		detectionProfiles, err := c.detectionProfiles()
+		if err != nil {
+			return fmt.Errorf("invalid drift detection profiles: %w", err)
+		}
+
+		for _, p := range detectionProfiles {
+			pc := p.config
+			profiles[p.name] = pc
+			if len(pc.includeTags) > 0 && len(pc.excludeTags) > 0 {
+				return fmt.Errorf("profile %q include and exclude tag options can't be used at the same time", p.name)
+			}
+
+			plogger := logger.WithValues(log.Kv{"profile": p.name})
+			precorder := metricsRecorder.WithProfile(p.name)
+
+			var schedule controller.Schedule = controller.IntervalSchedule(pc.detectInterval)
+			if pc.detectCron != "" {
+				tz, err := time.LoadLocation(pc.detectCronTimezone)
+				if err != nil {
+					return fmt.Errorf("profile %q invalid detect cron timezone: %w", p.name, err)
+				}
+
+				schedule, err = controller.NewCronSchedule(pc.detectCron, tz)
+				if err != nil {
+					return fmt.Errorf("profile %q invalid detect cron: %w", p.name, err)
+				}
+			}
+
+			readinessMaxDetAge := pc.readinessMaxDetAge
+			if readinessMaxDetAge == 0 {
+				next := schedule.Next(time.Now())
+				readinessMaxDetAge = 2*schedule.Next(next).Sub(next) + pc.waitTimeout
+			}
+
+			procs, err := pc.newDetectionProcessors(ctx, infoAsDebugLogger{Logger: plogger}, precorder, repo)
+			if err != nil {
+				return fmt.Errorf("profile %q: %w", p.name, err)
+			}
+			profileProcs[p.name] = newReloadableDetectionProcessors(procs)
+
+			driftDetector, err := controller.NewDriftDetector(controller.DriftDetectorConfig{
+				Logger:             plogger,
+				Leader:             leader,
+				Schedule:           schedule,
+				MetricsRecorder:    precorder,
+				Blackouts:          blackouts,
+				Locks:              wksLocks,
+				WorkspaceLister:    repo,
+				WorkspaceProcessor: profileProcs[p.name].plan,
+				TrackerProcessor:   newTrackerChain(profileProcs[p.name]),
+				MaxInflightCycles:  pc.maxInflightCycles,
+				IncludeTags:        splitRepeatedArg(pc.includeTags, repeatedArgSplitChar),
+				ExcludeTags:        splitRepeatedArg(pc.excludeTags, repeatedArgSplitChar),
+			})
+			if err != nil {
+				return fmt.Errorf("controller drift detector could not be created: %w", err)
+			}
+			driftDetectors[p.name] = driftDetector
+
+			checkName := "drift_detector"
+			if p.name != defaultProfileName {
+				checkName += "_" + p.name
+			}
+			readinessChecks[checkName] = controller.NewDriftDetectorReadinessCheck(driftDetector, readinessMaxDetAge)
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			g.Add(
+				func() error {
+					err := driftDetector.Run(ctx)
+					if err != nil {
+						return fmt.Errorf("controller drift detector had an error: %w", err)
+					}
+
+					return nil
+				},
+				func(_ error) {
+					cancel()
+				},
+			)
+		}
+	}
+
+	// On-demand drift detections.
+	var onDemandDetector *controller.OnDemandDetector
+	if c.apiToken != "" {
+		onDemandDetector, err = controller.NewOnDemandDetector(controller.OnDemandDetectorConfig{
+			Logger:                            logger,
+			Leader:                            leader,
+			Locks:                             wksLocks,
+			WorkspaceLister:                   repo,
+			WorkspaceProcessor:                reloadableProcs.onDemandPlan,
+			BypassNotBeforeWorkspaceProcessor: reloadableProcs.onDemandBypassNotBeforePlan,
+			TrackerProcessor:                  newTrackerChain(reloadableProcs),
+			IncludeTags:                       includeTags,
+			ExcludeTags:                       excludeTags,
+		})
+		if err != nil {
+			return fmt.Errorf("controller on-demand drift detector could not be created: %w", err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		g.Add(
+			func() error {
+				err := onDemandDetector.Run(ctx)
+				if err != nil && ctx.Err() == nil {
+					return fmt.Errorf("controller on-demand drift detector had an error: %w", err)
+				}
+
+				return nil
+			},
+			func(_ error) {
+				cancel()
+			},
+		)
+	}
+
+	// Configuration reloads.
+	if c.rootConfig.ConfigFile != "" && c.rootConfig.Reload != nil {
+		sigC := make(chan os.Signal, 1)
+		signal.Notify(sigC, syscall.SIGHUP)
+		defer signal.Stop(sigC)
+
+		watchFile := ""
+		if c.configWatchInterval > 0 {
+			watchFile = c.rootConfig.ConfigFile
+		}
+
+		reloader, err := controller.NewReloader(controller.ReloaderConfig{
+			Logger:          logger,
+			MetricsRecorder: metricsRecorder,
+			ConfigLoader: controller.ConfigLoaderFunc(func(_ context.Context) error {
+				cmd, err := c.rootConfig.Reload()
+				if err != nil {
+					return err
+				}
+				nc, ok := cmd.(*ControllerCommand)
+				if !ok {
+					return fmt.Errorf("unexpected reloaded command %q", cmd.Name())
+				}
+
+				restartChanges, err := c.restartRequiredChanges(*nc)
+				if err != nil {
+					return err
+				}
+
+				procs, err := nc.newDetectionProcessors(ctx, notVerboseLogger, metricsRecorder, repo)
+				if err != nil {
+					return err
+				}
+
+				// Only the running profiles drift detection processors can be reloaded.
+				newProfileProcs := map[string]*detectionProcessors{}
+				if !c.disableDriftDetector {
+					nprofiles, err := nc.detectionProfiles()
+					if err != nil {
+						return fmt.Errorf("invalid drift detection profiles: %w", err)
+					}
+
+					for _, np := range nprofiles {
+						if _, ok := profiles[np.name]; !ok {
+							continue
+						}
+
+						plogger := infoAsDebugLogger{Logger: logger.WithValues(log.Kv{"profile": np.name})}
+						newProfileProcs[np.name], err = np.config.newDetectionProcessors(ctx, plogger, metricsRecorder.WithProfile(np.name), repo)
+						if err != nil {
+							return fmt.Errorf("profile %q: %w", np.name, err)
+						}
+					}
+				}
+
+				// Don't apply partially the new configuration, the reloadable processors depend on the not
+				// reloadable settings (e.g: the detectors list the workspaces with the running tags).
+				if len(restartChanges) > 0 {
+					return fmt.Errorf("%s changes require a restart: %w", strings.Join(restartChanges, ", "), internalerrors.ErrRestartRequired)
+				}
+
+				reloadableProcs.set(procs)
+				for name, procs := range newProfileProcs {
+					profileProcs[name].set(procs)
+				}
+
+				return nil
+			}),
+			File:          watchFile,
+			WatchInterval: c.configWatchInterval,
+			Signals:       sigC,
+		})
+		if err != nil {
+			return fmt.Errorf("controller configuration reloader could not be created: %w", err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		g.Add(
+			func() error {
+				return reloader.Run(ctx)
+			},
+			func(_ error) {
+				cancel()
+			},
+		)
+	}
+
+	// Email digest.
+	if c.emailNotifier.enabled() {
+		emailNotifyProcessor, err := c.emailNotifier.newProcessor(logger, false)
+		if err != nil {
+			return err
+		}
+
 		chain := wksprocess.NewProcessorChain([]wksprocess.Processor{
 			includeProcessor,
 			excludeProcessor,
 			wksprocess.NewHydrateLatestDetectionPlanProcessor(ctx, notVerboseLogger, repo, c.fetchWorkers),
-			wksprocess.NewFilterQueuedDriftDetectorProcessor(notVerboseLogger),
-			wksprocess.NewFilterDriftDetectionsBeforeProcessor(notVerboseLogger, c.notBefore),
-			wksprocess.NewSortByOldestDetectionPlanProcessor(notVerboseLogger),
-			wksprocess.NewLimitMaxProcessor(notVerboseLogger, c.maxPlans),
-			wksprocess.NewDriftDetectionPlanProcessor(notVerboseLogger, repo, c.planMessage),
-			wksprocess.NewDriftDetectionPlanWaitProcessor(notVerboseLogger, repo, waitPolling, c.waitTimeout),
+			emailNotifyProcessor,
 		})
 
-		ctrl, err := controller.NewDriftDetector(controller.DriftDetectorConfig{
-			Logger:             logger,
-			Interval:           c.detectInterval,
+		notifier, err := controller.NewStateNotifier(controller.StateNotifierConfig{
+			Logger:             logger.WithValues(log.Kv{"state-notifier": "email"}),
+			Interval:           c.emailDigestInterval,
 			WorkspaceLister:    repo,
 			WorkspaceProcessor: chain,
 			IncludeTags:        includeTags,
 			ExcludeTags:        excludeTags,
 		})
 		if err != nil {
-			return fmt.Errorf("controller drift detector could not be created: %w", err)
+			return fmt.Errorf("controller email digest could not be created: %w", err)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		g.Add(
+			func() error {
+				err := notifier.Run(ctx)
+				if err != nil {
+					return fmt.Errorf("controller email digest had an error: %w", err)
+				}
+
+				return nil
+			},
+			func(_ error) {
+				cancel()
+			},
+		)
+	}
+
+	// Alertmanager alerts, sent again at regular intervals (before their TTL) with the latest workspaces
+	// state kept in memory (refreshed in the background and updated by the drift detections), this way we
+	// don't retrieve the workspaces from TFE again.
+	if c.alertmanagerNotifier.enabled() {
+		alertmanagerNotifyProcessor, err := c.alertmanagerNotifier.newProcessor(logger)
+		if err != nil {
+			return err
+		}
+
+		resendInterval, err := c.alertmanagerNotifier.resendEvery()
+		if err != nil {
+			return err
+		}
+
+		notifier, err := controller.NewStateNotifier(controller.StateNotifierConfig{
+			Logger:             logger.WithValues(log.Kv{"state-notifier": "alertmanager"}),
+			Interval:           resendInterval,
+			WorkspaceLister:    wkStates,
+			WorkspaceProcessor: alertmanagerNotifyProcessor,
+			IncludeTags:        includeTags,
+			ExcludeTags:        excludeTags,
+		})
+		if err != nil {
+			return fmt.Errorf("controller alertmanager notifier could not be created: %w", err)
 		}
 
 		ctx, cancel := context.WithCancel(ctx)
@@ -175,9 +592,9 @@ func (c ControllerCommand) Run(ctx context.Context) error {
 
 		g.Add(
 			func() error {
-				err = ctrl.Run(ctx)
+				err := notifier.Run(ctx)
 				if err != nil {
-					return fmt.Errorf("controller drift detector had an error: %w", err)
+					return fmt.Errorf("controller alertmanager notifier had an error: %w", err)
 				}
 
 				return nil
@@ -190,24 +607,52 @@ func (c ControllerCommand) Run(ctx context.Context) error {
 
 	// Serving HTTP server.
 	{
-		chain := wksprocess.NewProcessorChain([]wksprocess.Processor{
-			includeProcessor,
-			excludeProcessor,
-			wksprocess.NewHydrateLatestDetectionPlanProcessor(ctx, notVerboseLogger, repo, c.fetchWorkers),
-		})
+		newHydrateChain := func() wksprocess.Processor {
+			return wksprocess.NewProcessorChain([]wksprocess.Processor{
+				includeProcessor,
+				excludeProcessor,
+				wksprocess.NewHydrateLatestDetectionPlanProcessor(ctx, notVerboseLogger, repo, c.fetchWorkers),
+			})
+		}
+
+		// The workspaces listed in the background by the collector refresh the latest workspaces state,
+		// independently of the metrics collections, only when something uses the state.
+		var stateRefresher wksprocess.Processor
+		if c.apiToken != "" || !c.disableUI || c.alertmanagerNotifier.enabled() {
+			stateRefresher = wkStates.RefreshProcessor(newHydrateChain())
+		}
 
 		// Register metrics collector to create the exporter.
-		promCollector, err := internalprometheus.NewCollector(ctx, logger, repo, chain, includeTags, excludeTags, c.metricsTimeout)
+		promCollector, err := internalprometheus.NewCollector(ctx, logger, repo, newHydrateChain(), stateRefresher, includeTags, excludeTags, c.metricsTimeout)
 		if err != nil {
 			return fmt.Errorf("could not create metrics collector: %w", err)
 		}
 		prometheus.DefaultRegisterer.MustRegister(promCollector)
+		prometheus.DefaultRegisterer.MustRegister(internalprometheus.NewBlackoutsCollector(blackouts))
+
+		// Readiness of the controller components.
+		// The metrics cache is refreshed every 75s, let some refreshes fail before not being ready.
+		checks := readinessChecks
+		checks["metrics_cache"] = controller.NewRefreshReadinessCheck(promCollector, 5*time.Minute)
+		if pinger != nil {
+			checks["tfe"] = controller.NewConnectivityReadinessCheck(pinger, 30*time.Second)
+		}
+		if leaderElector != nil {
+			checks["leader"] = controller.NewLeaderReadinessCheck(leaderElector)
+		}
+		readiness, err := controller.NewReadiness(controller.ReadinessConfig{Checks: checks})
+		if err != nil {
+			return fmt.Errorf("could not create readiness: %w", err)
+		}
 
 		logger := logger.WithValues(log.Kv{
 			"addr":         c.listenAddress,
 			"metrics":      c.metricsPath,
 			"health-check": c.healthCheckPath,
+			"liveness":     c.livenessPath,
+			"readiness":    c.readinessPath,
 			"pprof":        c.pprofPath,
+			"ui":           c.uiPath,
 		})
 		mux := http.NewServeMux()
 
@@ -221,8 +666,83 @@ func (c ControllerCommand) Run(ctx context.Context) error {
 		mux.HandleFunc(c.pprofPath+"/symbol", pprof.Symbol)
 		mux.HandleFunc(c.pprofPath+"/trace", pprof.Trace)
 
+		// API.
+		if c.apiToken != "" {
+			apiHandler, err := api.NewHandler(api.HandlerConfig{
+				Logger:          logger,
+				Token:           c.apiToken,
+				Detector:        onDemandDetector,
+				WorkspaceStates: wkStates,
+			})
+			if err != nil {
+				return fmt.Errorf("could not create API handler: %w", err)
+			}
+			mux.Handle(api.Prefix, apiHandler)
+		} else {
+			logger.Infof("HTTP API disabled, API token not set")
+		}
+
+		// UI.
+		if !c.disableUI {
+			uiDetectors := map[string]ui.DriftDetector{}
+			for name, d := range driftDetectors {
+				uiDetectors[name] = d
+			}
+
+			uiHandler, err := ui.NewHandler(ui.HandlerConfig{
+				Logger:          logger,
+				WorkspaceStates: wkStates,
+				DriftDetectors:  uiDetectors,
+			})
+			if err != nil {
+				return fmt.Errorf("could not create UI handler: %w", err)
+			}
+			mux.Handle(c.uiPath+"/", http.StripPrefix(c.uiPath, uiHandler))
+			mux.Handle(c.uiPath, http.RedirectHandler(c.uiPath+"/", http.StatusMovedPermanently))
+		}
+
 		// Health check.
-		mux.Handle(c.healthCheckPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte(`{"status":"ok"}`)) }))
+		mux.Handle(c.healthCheckPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			status := healthStatus{Status: "ok", Blackout: healthBlackout{Windows: blackouts.Status(time.Now())}}
+			for _, w := range status.Blackout.Windows {
+				status.Blackout.Active = status.Blackout.Active || w.Active
+			}
+			if leaderElector != nil {
+				status.Leader = &healthLeader{ID: leaderElector.ID(), Leader: leaderElector.IsLeader()}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(status)
+		}))
+
+		// Liveness, the controller is alive while it serves requests.
+		mux.Handle(c.livenessPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(readinessStatus{Status: readinessOK})
+		}))
+
+		// Readiness.
+		mux.Handle(c.readinessPath, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			st := readiness.Check(r.Context())
+			status := readinessStatus{Status: readinessOK, Components: map[string]readinessComponent{}}
+			if !st.Ready {
+				status.Status = readinessFailing
+			}
+			for name, comp := range st.Components {
+				rc := readinessComponent{Status: readinessOK, Reason: comp.Reason, Details: comp.Details}
+				if !comp.Ready {
+					rc.Status = readinessFailing
+					logger.WithValues(log.Kv{"component": name}).Warningf("Component not ready: %s", comp.Reason)
+				}
+				status.Components[name] = rc
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			if !st.Ready {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			_ = json.NewEncoder(w).Encode(status)
+		}))
 
 		// Create server.
 		server := &http.Server{
@@ -264,6 +784,264 @@ func (c ControllerCommand) Run(ctx context.Context) error {
 	return g.Run()
 }
 
+// healthStatus is the health check response.
+type healthStatus struct {
+	Status   string         `json:"status"`
+	Blackout healthBlackout `json:"blackout"`
+	Leader   *healthLeader  `json:"leader,omitempty"`
+}
+
+type healthLeader struct {
+	ID     string `json:"id"`
+	Leader bool   `json:"leader"`
+}
+
+const (
+	readinessOK      = "ok"
+	readinessFailing = "failing"
+)
+
+// readinessStatus is the liveness and readiness probes response.
+type readinessStatus struct {
+	Status     string                        `json:"status"`
+	Components map[string]readinessComponent `json:"components,omitempty"`
+}
+
+type readinessComponent struct {
+	Status  string         `json:"status"`
+	Reason  string         `json:"reason,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type healthBlackout struct {
+	// Active is true if any blackout window is active.
+	Active  bool                              `json:"active"`
+	Windows []controller.BlackoutWindowStatus `json:"windows"`
+}
+
+// detectionProcessors are the drift detection processors that depend on the reloadable configuration.
+type detectionProcessors struct {
+	include wksprocess.Processor
+	exclude wksprocess.Processor
+	plan    wksprocess.Processor
+	// On-demand drift detections plan all the requested workspaces, so they are not sorted nor limited.
+	onDemandPlan                wksprocess.Processor
+	onDemandBypassNotBeforePlan wksprocess.Processor
+	wait                        wksprocess.Processor
+}
+
+// reloadableDetectionProcessors are the drift detection processors replaced on configuration reloads.
+type reloadableDetectionProcessors struct {
+	include                     *wksprocess.ReloadableProcessor
+	exclude                     *wksprocess.ReloadableProcessor
+	plan                        *wksprocess.ReloadableProcessor
+	onDemandPlan                *wksprocess.ReloadableProcessor
+	onDemandBypassNotBeforePlan *wksprocess.ReloadableProcessor
+	wait                        *wksprocess.ReloadableProcessor
+}
+
+func newReloadableDetectionProcessors(p *detectionProcessors) *reloadableDetectionProcessors {
+	return &reloadableDetectionProcessors{
+		include:                     wksprocess.NewReloadableProcessor(p.include),
+		exclude:                     wksprocess.NewReloadableProcessor(p.exclude),
+		plan:                        wksprocess.NewReloadableProcessor(p.plan),
+		onDemandPlan:                wksprocess.NewReloadableProcessor(p.onDemandPlan),
+		onDemandBypassNotBeforePlan: wksprocess.NewReloadableProcessor(p.onDemandBypassNotBeforePlan),
+		wait:                        wksprocess.NewReloadableProcessor(p.wait),
+	}
+}
+
+func (r *reloadableDetectionProcessors) set(p *detectionProcessors) {
+	r.include.Set(p.include)
+	r.exclude.Set(p.exclude)
+	r.plan.Set(p.plan)
+	r.onDemandPlan.Set(p.onDemandPlan)
+	r.onDemandBypassNotBeforePlan.Set(p.onDemandBypassNotBeforePlan)
+	r.wait.Set(p.wait)
+}
+
+// restartRequiredChanges returns the changed settings of the new configuration that are not reloaded
+// (only the drift detection processors are), so a restart is required to apply them.
+func (c ControllerCommand) restartRequiredChanges(nc ControllerCommand) ([]string, error) {
+	changes := []string{}
+	check := func(name string, changed bool) {
+		if changed {
+			changes = append(changes, name)
+		}
+	}
+
+	r, nr := c.rootConfig, nc.rootConfig
+	check("debug", r.Debug != nr.Debug)
+	check("no-log", r.NoLog != nr.NoLog)
+	check("no-color", r.NoColor != nr.NoColor)
+	check("logger", r.LoggerType != nr.LoggerType)
+	check("app-id", r.AppID != nr.AppID)
+	check("tfe-organization", r.TFEOrg != nr.TFEOrg)
+	check("tfe-token", r.TFEToken != nr.TFEToken)
+	check("tfe-address", r.TFEAddress != nr.TFEAddress)
+
+	check("include-tag", !slices.Equal(c.includeTags, nc.includeTags))
+	check("exclude-tag", !slices.Equal(c.excludeTags, nc.excludeTags))
+	check("dry-run", c.dryRun != nc.dryRun)
+	check("detect-interval", c.detectInterval != nc.detectInterval)
+	check("detect-cron", c.detectCron != nc.detectCron)
+	check("detect-cron-timezone", c.detectCronTimezone != nc.detectCronTimezone)
+	check("blackout-window", !slices.Equal(c.blackoutWindows, nc.blackoutWindows))
+	check("max-inflight-cycles", c.maxInflightCycles != nc.maxInflightCycles)
+	check("disable-drift-detector", c.disableDriftDetector != nc.disableDriftDetector)
+	check("metrics-exporter-timeout", c.metricsTimeout != nc.metricsTimeout)
+	check("listen-address", c.listenAddress != nc.listenAddress)
+	check("metrics-path", c.metricsPath != nc.metricsPath)
+	check("health-check-path", c.healthCheckPath != nc.healthCheckPath)
+	check("liveness-path", c.livenessPath != nc.livenessPath)
+	check("readiness-path", c.readinessPath != nc.readinessPath)
+	check("readiness-max-detection-age", c.readinessMaxDetAge != nc.readinessMaxDetAge)
+	check("pprof-path", c.pprofPath != nc.pprofPath)
+	// The drift detections reload it, but not the metrics exporter.
+	check("fetch-workers", c.fetchWorkers != nc.fetchWorkers)
+	check("fake-tfe", c.fakeTFE != nc.fakeTFE)
+	check("email notifier", !reflect.DeepEqual(c.emailNotifier, nc.emailNotifier) || c.emailDigestInterval != nc.emailDigestInterval)
+	check("github notifier", !reflect.DeepEqual(c.githubNotifier, nc.githubNotifier))
+	check("pagerduty notifier", !reflect.DeepEqual(c.pagerdutyNotifier, nc.pagerdutyNotifier))
+	check("alertmanager notifier", !reflect.DeepEqual(c.alertmanagerNotifier, nc.alertmanagerNotifier))
+	check("notify transitions", c.transitions != nc.transitions)
+	check("leader election", c.leaderElection != nc.leaderElection || c.leaderElectionID != nc.leaderElectionID ||
+		c.leaderElectionTTL != nc.leaderElectionTTL || c.leaderElectionFile != nc.leaderElectionFile || c.leaderElectionTFEWk != nc.leaderElectionTFEWk)
+	check("api-token", c.apiToken != nc.apiToken)
+	check("ui-path", c.uiPath != nc.uiPath)
+	check("disable-ui", c.disableUI != nc.disableUI)
+	check("config-watch-interval", c.configWatchInterval != nc.configWatchInterval)
+
+	// Without profiles, the default profile uses the flags already checked.
+	if c.disableDriftDetector || (len(c.profiles) == 0 && len(nc.profiles) == 0) {
+		return changes, nil
+	}
+
+	profiles, err := c.detectionProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("invalid drift detection profiles: %w", err)
+	}
+	nprofiles, err := nc.detectionProfiles()
+	if err != nil {
+		return nil, fmt.Errorf("invalid drift detection profiles: %w", err)
+	}
+
+	byName := map[string]ControllerCommand{}
+	for _, p := range profiles {
+		byName[p.name] = p.config
+	}
+	newByName := map[string]ControllerCommand{}
+	for _, np := range nprofiles {
+		newByName[np.name] = np.config
+	}
+
+	// New or removed profiles and their schedule and tag filters changes are not reloaded.
+	for _, p := range profiles {
+		_, ok := newByName[p.name]
+		check(fmt.Sprintf("profile %q", p.name), !ok)
+	}
+	for _, np := range nprofiles {
+		pc, ok := byName[np.name]
+		if !ok {
+			check(fmt.Sprintf("profile %q", np.name), true)
+			continue
+		}
+
+		npc := np.config
+		check(fmt.Sprintf("profile %q schedule", np.name), pc.detectInterval != npc.detectInterval || pc.detectCron != npc.detectCron || pc.detectCronTimezone != npc.detectCronTimezone)
+		check(fmt.Sprintf("profile %q tags", np.name), !slices.Equal(pc.includeTags, npc.includeTags) || !slices.Equal(pc.excludeTags, npc.excludeTags))
+	}
+
+	return changes, nil
+}
+
+func (c ControllerCommand) newDetectionProcessors(ctx context.Context, logger log.Logger, rec wksprocess.MetricsRecorder, repo tfestorage.Repository) (*detectionProcessors, error) {
+	if len(c.excludeNameRegexes) > 0 && len(c.includeNameRegexes) > 0 {
+		return nil, fmt.Errorf("include and exclude name options can't be used at the same time")
+	}
+
+	// Sanitize names by splitting using commas.
+	const repeatedArgSplitChar = ","
+	excludeNameRegexes := splitRepeatedArg(c.excludeNameRegexes, repeatedArgSplitChar)
+	includeNameRegexes := splitRepeatedArg(c.includeNameRegexes, repeatedArgSplitChar)
+
+	var includeProcessor process.Processor = process.NoopProcessor
+	if len(includeNameRegexes) > 0 {
+		p, err := wksprocess.NewIncludeNameProcessor(logger, includeNameRegexes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include processor: %w", err)
+		}
+		includeProcessor = p
+	}
+
+	var excludeProcessor process.Processor = process.NoopProcessor
+	if len(excludeNameRegexes) > 0 {
+		p, err := wksprocess.NewExcludeNameProcessor(logger, excludeNameRegexes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude processor: %w", err)
+		}
+		excludeProcessor = p
+	}
+
+	sortProcessor, err := c.sorting.newProcessor(logger, c.rootConfig.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sort processor: %w", err)
+	}
+
+	newPlanChain := func(onDemand, bypassNotBefore bool) wksprocess.Processor {
+		var notBeforeProcessor process.Processor = wksprocess.NewFilterDriftDetectionsBeforeProcessor(logger, c.notBefore)
+		if bypassNotBefore {
+			notBeforeProcessor = process.NoopProcessor
+		}
+
+		procs := []wksprocess.Processor{
+			wksprocess.NewMeasuredProcessor(rec, "include_name", includeProcessor),
+			wksprocess.NewMeasuredProcessor(rec, "exclude_name", excludeProcessor),
+			wksprocess.NewHydrateLatestDetectionPlanProcessor(ctx, logger, repo, c.fetchWorkers),
+			wksprocess.NewMeasuredProcessor(rec, "filter_queued", wksprocess.NewFilterQueuedDriftDetectorProcessor(logger)),
+			wksprocess.NewMeasuredProcessor(rec, "not_before", notBeforeProcessor),
+		}
+		if !onDemand {
+			procs = append(procs,
+				sortProcessor,
+				wksprocess.NewMeasuredProcessor(rec, "limit_max", wksprocess.NewLimitMaxProcessor(logger, c.maxPlans)),
+			)
+		}
+		procs = append(procs, wksprocess.NewDriftDetectionPlanProcessor(logger, rec, repo, c.planMessage))
+
+		return wksprocess.NewProcessorChain(procs)
+	}
+
+	return &detectionProcessors{
+		include:                     includeProcessor,
+		exclude:                     excludeProcessor,
+		plan:                        newPlanChain(false, false),
+		onDemandPlan:                newPlanChain(true, false),
+		onDemandBypassNotBeforePlan: newPlanChain(true, true),
+		wait:                        wksprocess.NewDriftDetectionPlanWaitProcessor(logger, rec, repo, waitPolling, c.waitTimeout),
+	}, nil
+}
+
+func (c ControllerCommand) newLeaderLock(client *tfe.Client) (controller.LeaderLock, error) {
+	switch c.leaderElection {
+	case leaderElectionFile:
+		if c.leaderElectionFile == "" {
+			return nil, fmt.Errorf("file leader election requires a lease file")
+		}
+		return filestorage.NewLeaderLock(c.leaderElectionFile), nil
+	case leaderElectionTFE:
+		if c.leaderElectionTFEWk == "" {
+			return nil, fmt.Errorf("tfe leader election requires a workspace")
+		}
+		if client == nil {
+			return nil, fmt.Errorf("tfe leader election can't be used with a fake TFE")
+		}
+		return tfestorage.NewLeaderLock(tfestorage.NewLeaderClient(client), c.rootConfig.TFEOrg, c.leaderElectionTFEWk), nil
+	}
+
+	return nil, fmt.Errorf("unknown leader election %q", c.leaderElection)
+}
+
 // infoAsDebugLogger is a logger that will be used when we have reusable components that
 // in some cases we want them verbose and others not.
 type infoAsDebugLogger struct {
@@ -0,0 +1,252 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/notify/alertmanager"
+	"github.com/slok/tfe-drift/internal/notify/email"
+	"github.com/slok/tfe-drift/internal/notify/github"
+	"github.com/slok/tfe-drift/internal/notify/pagerduty"
+	filestorage "github.com/slok/tfe-drift/internal/storage/file"
+	tfestorage "github.com/slok/tfe-drift/internal/storage/tfe"
+	wksprocess "github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+// emailNotifierFlags are the flags shared by the commands that can notify using email.
+type emailNotifierFlags struct {
+	smtpAddress               string
+	smtpUsername              string
+	smtpPassword              string
+	smtpNoStartTLS            bool
+	smtpTLSInsecureSkipVerify bool
+	from                      string
+	to                        []string
+	subject                   string
+}
+
+func (e *emailNotifierFlags) register(cmd *kingpin.CmdClause) {
+	cmd.Flag("email-smtp-address", "The SMTP server address (host:port) used to send drift digest emails, if not set, email notifications will be disabled.").StringVar(&e.smtpAddress)
+	cmd.Flag("email-smtp-username", "The SMTP server username.").StringVar(&e.smtpUsername)
+	cmd.Flag("email-smtp-password", "The SMTP server password.").StringVar(&e.smtpPassword)
+	cmd.Flag("email-smtp-no-starttls", "Will not require STARTTLS on the SMTP connection (e.g local SMTP servers).").BoolVar(&e.smtpNoStartTLS)
+	cmd.Flag("email-smtp-tls-insecure-skip-verify", "Will not verify the SMTP server TLS certificate.").BoolVar(&e.smtpTLSInsecureSkipVerify)
+	cmd.Flag("email-from", "The sender address of the drift digest emails.").StringVar(&e.from)
+	cmd.Flag("email-to", "The destination address of the drift digest emails (can be repeated or comma separated).").StringsVar(&e.to)
+	cmd.Flag("email-subject", "The subject of the drift digest emails.").Default("Terraform drift detection digest").StringVar(&e.subject)
+}
+
+func (e emailNotifierFlags) enabled() bool { return e.smtpAddress != "" }
+
+func (e emailNotifierFlags) newProcessor(logger log.Logger, strict bool) (wksprocess.Processor, error) {
+	n, err := email.NewNotifier(email.NotifierConfig{
+		Logger:                logger,
+		Address:               e.smtpAddress,
+		Username:              e.smtpUsername,
+		Password:              e.smtpPassword,
+		From:                  e.from,
+		To:                    splitRepeatedArg(e.to, ","),
+		Subject:               e.subject,
+		DisableStartTLS:       e.smtpNoStartTLS,
+		TLSInsecureSkipVerify: e.smtpTLSInsecureSkipVerify,
+		Timeout:               30 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create email notifier: %w", err)
+	}
+
+	return wksprocess.NewNotifyProcessor(logger, "email", n, strict), nil
+}
+
+// githubNotifierFlags are the flags shared by the commands that can manage GitHub drift issues.
+type githubNotifierFlags struct {
+	token        string
+	apiURL       string
+	repositories []string
+	labels       []string
+	markerLabel  string
+}
+
+func (g *githubNotifierFlags) register(cmd *kingpin.CmdClause) {
+	cmd.Flag("github-token", "The GitHub API token used to manage drift issues, if not set, GitHub issues will be disabled.").StringVar(&g.token)
+	cmd.Flag("github-api-url", "The GitHub API URL (e.g GitHub Enterprise).").Default(github.DefaultAPIURL).StringVar(&g.apiURL)
+	cmd.Flag("github-repository", "Rule to select the repository of the workspace drift issues with `[tag:<tag>|name:<regex>=]<owner>/<repo>` format, first match wins (can be repeated).").StringsVar(&g.repositories)
+	cmd.Flag("github-label", "Rule to set labels on the workspace drift issues with `[tag:<tag>|name:<regex>=]<label>[,<label>]` format, all matches are used (can be repeated).").StringsVar(&g.labels)
+	cmd.Flag("github-marker-label", "The label used to identify the drift issues managed by tfe-drift.").Default("tfe-drift").StringVar(&g.markerLabel)
+}
+
+func (g githubNotifierFlags) enabled() bool { return g.token != "" }
+
+func (g githubNotifierFlags) newProcessor(logger log.Logger, strict bool) (wksprocess.Processor, error) {
+	repoRules, err := selector.ParseRules(g.repositories)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github repository rules: %w", err)
+	}
+
+	labelRules, err := selector.ParseRules(g.labels)
+	if err != nil {
+		return nil, fmt.Errorf("invalid github label rules: %w", err)
+	}
+
+	n, err := github.NewNotifier(github.NotifierConfig{
+		Logger:          logger,
+		APIURL:          g.apiURL,
+		Token:           g.token,
+		RepositoryRules: repoRules,
+		LabelRules:      labelRules,
+		MarkerLabel:     g.markerLabel,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create github notifier: %w", err)
+	}
+
+	return wksprocess.NewNotifyProcessor(logger, "github", n, strict), nil
+}
+
+// pagerdutyNotifierFlags are the flags shared by the commands that can send PagerDuty events.
+type pagerdutyNotifierFlags struct {
+	routingKey      string
+	eventsURL       string
+	severities      []string
+	defaultSeverity string
+}
+
+func (p *pagerdutyNotifierFlags) register(cmd *kingpin.CmdClause) {
+	cmd.Flag("pagerduty-routing-key", "The PagerDuty Events v2 integration routing key, if not set, PagerDuty events will be disabled.").StringVar(&p.routingKey)
+	cmd.Flag("pagerduty-events-url", "The PagerDuty Events v2 API URL.").Default(pagerduty.DefaultEventsURL).StringVar(&p.eventsURL)
+	cmd.Flag("pagerduty-severity", "Rule to select the severity of the workspace events with `[tag:<tag>|name:<regex>=]<critical|error|warning|info>` format, first match wins (can be repeated).").StringsVar(&p.severities)
+	cmd.Flag("pagerduty-default-severity", "The severity of the workspace events that don't match any severity rule.").Default("error").EnumVar(&p.defaultSeverity, "critical", "error", "warning", "info")
+}
+
+func (p pagerdutyNotifierFlags) enabled() bool { return p.routingKey != "" }
+
+func (p pagerdutyNotifierFlags) newProcessor(logger log.Logger, strict bool) (wksprocess.Processor, error) {
+	severityRules, err := selector.ParseRules(p.severities)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pagerduty severity rules: %w", err)
+	}
+
+	n, err := pagerduty.NewNotifier(pagerduty.NotifierConfig{
+		Logger:          logger,
+		EventsURL:       p.eventsURL,
+		RoutingKey:      p.routingKey,
+		SeverityRules:   severityRules,
+		DefaultSeverity: p.defaultSeverity,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create pagerduty notifier: %w", err)
+	}
+
+	return wksprocess.NewNotifyProcessor(logger, "pagerduty", n, strict), nil
+}
+
+// alertmanagerNotifierFlags are the flags shared by the commands that can push alerts to Alertmanager.
+type alertmanagerNotifierFlags struct {
+	url            string
+	alertTTL       time.Duration
+	resendInterval time.Duration
+	labels         []string
+}
+
+func (a *alertmanagerNotifierFlags) register(cmd *kingpin.CmdClause) {
+	cmd.Flag("alertmanager-url", "The Alertmanager URL where drift alerts will be pushed, if not set, Alertmanager alerts will be disabled.").StringVar(&a.url)
+	cmd.Flag("alertmanager-alert-ttl", "The duration a firing alert will be active if it's not refreshed, 0 will use Alertmanager resolve timeout.").Default("1h").DurationVar(&a.alertTTL)
+	cmd.Flag("alertmanager-resend-interval", "The interval the alerts will be sent again to Alertmanager on controller mode, must be lower than the alert TTL (by default a quarter of the alert TTL or 1m without TTL).").DurationVar(&a.resendInterval)
+	cmd.Flag("alertmanager-label", "Label added to all the alerts with `<key>=<value>` format (can be repeated).").StringsVar(&a.labels)
+}
+
+func (a alertmanagerNotifierFlags) enabled() bool { return a.url != "" }
+
+// resendEvery returns the interval the alerts are sent again, so the firing alerts are refreshed before their TTL.
+func (a alertmanagerNotifierFlags) resendEvery() (time.Duration, error) {
+	if a.resendInterval < 0 {
+		return 0, fmt.Errorf("alertmanager resend interval can't be negative")
+	}
+
+	if a.resendInterval == 0 {
+		if a.alertTTL <= 0 {
+			return time.Minute, nil
+		}
+		return a.alertTTL / 4, nil
+	}
+
+	if a.alertTTL > 0 && a.resendInterval >= a.alertTTL {
+		return 0, fmt.Errorf("alertmanager resend interval (%s) must be lower than the alert TTL (%s)", a.resendInterval, a.alertTTL)
+	}
+
+	return a.resendInterval, nil
+}
+
+func (a alertmanagerNotifierFlags) newProcessor(logger log.Logger) (wksprocess.Processor, error) {
+	labels := map[string]string{}
+	for _, l := range a.labels {
+		k, v, ok := strings.Cut(l, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid alertmanager label %q, must be `<key>=<value>`", l)
+		}
+		labels[k] = v
+	}
+
+	n, err := alertmanager.NewNotifier(alertmanager.NotifierConfig{
+		Logger:      logger,
+		URL:         a.url,
+		AlertTTL:    a.alertTTL,
+		ExtraLabels: labels,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not create alertmanager notifier: %w", err)
+	}
+
+	return wksprocess.NewNotifyProcessor(logger, "alertmanager", n, false), nil
+}
+
+const (
+	transitionsSourceNone = "none"
+	transitionsSourceTFE  = "tfe"
+	transitionsSourceFile = "file"
+)
+
+// transitionsFlags are the flags shared by the commands that can notify only on drift state transitions.
+type transitionsFlags struct {
+	source           string
+	stateFile        string
+	reminderInterval time.Duration
+}
+
+func (t *transitionsFlags) register(cmd *kingpin.CmdClause) {
+	cmd.Flag("notify-transitions", "Only notify on workspace drift state transitions (e.g: ok to drift), selects where the previous states are get from (TFE drift detection history or local state file).").Default(transitionsSourceNone).EnumVar(&t.source, transitionsSourceNone, transitionsSourceTFE, transitionsSourceFile)
+	cmd.Flag("notify-transitions-state-file", "The local state file used to track the workspace drift state transitions.").Default("tfe-drift-state.json").StringVar(&t.stateFile)
+	cmd.Flag("notify-reminder-interval", "When notifying only transitions, will notify again the workspaces that maintain a not ok state after this interval (0 disables reminders).").DurationVar(&t.reminderInterval)
+}
+
+func (t transitionsFlags) enabled() bool {
+	return t.source == transitionsSourceTFE || t.source == transitionsSourceFile
+}
+
+// newProcessor returns a processor that wraps the notifier processors so they only receive the
+// workspaces with transitions, if transitions tracking is disabled, all workspaces will be notified.
+//
+// With transitions tracking enabled, the notifier processors should be strict, so the failed notifications are
+// not tracked as notified.
+func (t transitionsFlags) newProcessor(logger log.Logger, repo tfestorage.Repository, notifiers []wksprocess.Processor) (wksprocess.Processor, error) {
+	var verdictRepo wksprocess.WorkspaceVerdictRepository
+	switch t.source {
+	case transitionsSourceTFE:
+		verdictRepo = tfestorage.NewVerdictRepository(repo)
+	case transitionsSourceFile:
+		r, err := filestorage.NewVerdictRepository(t.stateFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not create file verdict repository: %w", err)
+		}
+		verdictRepo = r
+	default:
+		return wksprocess.NewProcessorChain(notifiers), nil
+	}
+
+	return wksprocess.NewTransitionNotifyProcessor(logger, verdictRepo, t.reminderInterval, notifiers), nil
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+	filestorage "github.com/slok/tfe-drift/internal/storage/file"
+	wksprocess "github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+var outFormats = []string{outFormatJSON, outFormatPrettyJSON, outFormatMarkdown, outFormatJUnit, outFormatHTML, outFormatTemplate}
+
+// resultOutput is a result output with `<format>=<path>` format, if the path is
+// empty or `-`, the output will be the stdout.
+type resultOutput struct {
+	format string
+	path   string
+}
+
+func parseResultOutput(s string) (resultOutput, error) {
+	format, path, _ := strings.Cut(s, "=")
+
+	valid := false
+	for _, f := range outFormats {
+		if f == format {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return resultOutput{}, fmt.Errorf("invalid output %q, format must be one of: %s", s, strings.Join(outFormats, ", "))
+	}
+
+	if path == "-" {
+		path = ""
+	}
+
+	return resultOutput{format: format, path: path}, nil
+}
+
+// resultOutProcessorFactory knows how to create result processors in the different output formats.
+type resultOutProcessorFactory struct {
+	snapshot      *wksprocess.WorkspaceSnapshot
+	exitDecider   *wksprocess.ExitDecider
+	schemaVersion int
+	templateFile  string
+	template      report.Renderer
+}
+
+func (r *resultOutProcessorFactory) newProcessor(format string, out io.Writer) (wksprocess.Processor, error) {
+	switch format {
+	case outFormatJSON:
+		return wksprocess.NewDetailedJSONResultProcessor(out, false, r.schemaVersion, r.snapshot, r.exitDecider), nil
+	case outFormatPrettyJSON:
+		return wksprocess.NewDetailedJSONResultProcessor(out, true, r.schemaVersion, r.snapshot, r.exitDecider), nil
+	case outFormatMarkdown:
+		return wksprocess.NewReportResultProcessor(out, report.RendererFunc(report.RenderMarkdown), r.snapshot), nil
+	case outFormatJUnit:
+		return wksprocess.NewReportResultProcessor(out, report.RendererFunc(report.RenderJUnit), r.snapshot), nil
+	case outFormatHTML:
+		return wksprocess.NewReportResultProcessor(out, report.RendererFunc(report.RenderHTML), r.snapshot), nil
+	case outFormatTemplate:
+		// Load the template only once for all the outputs.
+		if r.template == nil {
+			if r.templateFile == "" {
+				return nil, fmt.Errorf("template file is required when using template output format")
+			}
+
+			tpl, err := os.ReadFile(r.templateFile)
+			if err != nil {
+				return nil, fmt.Errorf("could not read template file: %w", err)
+			}
+
+			renderer, err := report.NewTemplateRenderer(string(tpl))
+			if err != nil {
+				return nil, fmt.Errorf("invalid template file: %w", err)
+			}
+			r.template = renderer
+		}
+		return wksprocess.NewReportResultProcessor(out, r.template, r.snapshot), nil
+	}
+
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// newStdoutProcessor returns a result processor that writes on the stdout.
+func (r *resultOutProcessorFactory) newStdoutProcessor(format string, stdout io.Writer) (wksprocess.Processor, error) {
+	// If we are running on GitHub actions, also write the markdown report as the job summary.
+	if path := os.Getenv(githubStepSummaryEnv); format == outFormatMarkdown && path != "" {
+		stdout = io.MultiWriter(stdout, appendFileWriter(path))
+	}
+
+	return r.newProcessor(format, stdout)
+}
+
+// newFileProcessor returns a result processor that renders the result in memory and writes it atomically on a file.
+func (r *resultOutProcessorFactory) newFileProcessor(format string, path string) (wksprocess.Processor, error) {
+	var b bytes.Buffer
+	p, err := r.newProcessor(format, &b)
+	if err != nil {
+		return nil, err
+	}
+
+	return wksprocess.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		b.Reset()
+		wks, err := p.Process(ctx, wks)
+		if err != nil {
+			return nil, err
+		}
+
+		err = filestorage.WriteFileAtomic(path, b.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("could not write %q result file: %w", path, err)
+		}
+
+		return wks, nil
+	}), nil
+}
+
+// appendFileWriter is a writer that appends to the file on each write.
+type appendFileWriter string
+
+func (a appendFileWriter) Write(p []byte) (int, error) {
+	f, err := os.OpenFile(string(a), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	return f.Write(p)
+}
@@ -0,0 +1,135 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultProfileName = "default"
+
+var profileNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// detectionProfile is a named drift detection profile with `<name>=<json>` format, the JSON keys are
+// the controller flag names that can be customized per profile, the unset ones use the controller flags.
+type detectionProfile struct {
+	name               string
+	IncludeNames       []string         `json:"include-name"`
+	ExcludeNames       []string         `json:"exclude-name"`
+	IncludeTags        []string         `json:"include-tag"`
+	ExcludeTags        []string         `json:"exclude-tag"`
+	DetectInterval     *profileDuration `json:"detect-interval"`
+	DetectCron         *string          `json:"detect-cron"`
+	DetectCronTimezone *string          `json:"detect-cron-timezone"`
+	NotBefore          *profileDuration `json:"not-before"`
+	MaxPlans           *int             `json:"limit-max-plans"`
+	PlanMessage        *string          `json:"plan-message"`
+}
+
+func parseDetectionProfile(s string) (detectionProfile, error) {
+	name, data, ok := strings.Cut(s, "=")
+	if !ok {
+		return detectionProfile{}, fmt.Errorf("invalid profile %q, must have `<name>=<json>` format", s)
+	}
+
+	if !profileNameRegexp.MatchString(name) {
+		return detectionProfile{}, fmt.Errorf("invalid profile name %q, must match %s", name, profileNameRegexp)
+	}
+
+	p := detectionProfile{name: name}
+	dec := json.NewDecoder(bytes.NewBufferString(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&p)
+	if err != nil {
+		return detectionProfile{}, fmt.Errorf("invalid profile %q: %w", name, err)
+	}
+
+	return p, nil
+}
+
+// apply returns the controller command customized with the profile.
+func (p detectionProfile) apply(c ControllerCommand) ControllerCommand {
+	// Include and exclude options can't be used at the same time, so they are set together.
+	if p.IncludeNames != nil || p.ExcludeNames != nil {
+		c.includeNameRegexes = p.IncludeNames
+		c.excludeNameRegexes = p.ExcludeNames
+	}
+	if p.IncludeTags != nil || p.ExcludeTags != nil {
+		c.includeTags = p.IncludeTags
+		c.excludeTags = p.ExcludeTags
+	}
+	if p.DetectInterval != nil {
+		c.detectInterval = time.Duration(*p.DetectInterval)
+	}
+	if p.DetectCron != nil {
+		c.detectCron = *p.DetectCron
+	}
+	if p.DetectCronTimezone != nil {
+		c.detectCronTimezone = *p.DetectCronTimezone
+	}
+	if p.NotBefore != nil {
+		c.notBefore = time.Duration(*p.NotBefore)
+	}
+	if p.MaxPlans != nil {
+		c.maxPlans = *p.MaxPlans
+	}
+	if p.PlanMessage != nil {
+		c.planMessage = *p.PlanMessage
+	}
+
+	return c
+}
+
+// profileDuration is a duration with the flags format (e.g: `1h30m`).
+type profileDuration time.Duration
+
+func (d *profileDuration) UnmarshalJSON(data []byte) error {
+	var s string
+	err := json.Unmarshal(data, &s)
+	if err != nil {
+		return fmt.Errorf("duration must be a string: %w", err)
+	}
+
+	pd, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = profileDuration(pd)
+
+	return nil
+}
+
+// controllerProfile is a drift detection profile resolved with the controller flags.
+type controllerProfile struct {
+	name   string
+	config ControllerCommand
+}
+
+// detectionProfiles returns the controller drift detection profiles, if none has been set, a default
+// one with the controller flags will be returned.
+func (c ControllerCommand) detectionProfiles() ([]controllerProfile, error) {
+	if len(c.profiles) == 0 {
+		return []controllerProfile{{name: defaultProfileName, config: c}}, nil
+	}
+
+	profiles := []controllerProfile{}
+	names := map[string]bool{}
+	for _, s := range c.profiles {
+		p, err := parseDetectionProfile(s)
+		if err != nil {
+			return nil, err
+		}
+
+		if names[p.name] {
+			return nil, fmt.Errorf("profile %q is repeated", p.name)
+		}
+		names[p.name] = true
+
+		profiles = append(profiles, controllerProfile{name: p.name, config: p.apply(c)})
+	}
+
+	return profiles, nil
+}
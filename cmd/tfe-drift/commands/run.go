@@ -3,15 +3,22 @@ package commands
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/hashicorp/go-tfe"
 
+	"github.com/slok/tfe-drift/internal/exitpolicy"
+	internalprometheus "github.com/slok/tfe-drift/internal/metrics/prometheus"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/result"
 	tfestorage "github.com/slok/tfe-drift/internal/storage/tfe"
 	"github.com/slok/tfe-drift/internal/workspace/process"
 	wksprocess "github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
 )
 
 var (
@@ -19,6 +26,13 @@ var (
 
 	outFormatJSON       = "json"
 	outFormatPrettyJSON = "pretty-json"
+	outFormatMarkdown   = "markdown"
+	outFormatJUnit      = "junit"
+	outFormatHTML       = "html"
+	outFormatTemplate   = "template"
+
+	// githubStepSummaryEnv is the env var that GitHub actions set with the job summary file path.
+	githubStepSummaryEnv = "GITHUB_STEP_SUMMARY"
 )
 
 type RunCommand struct {
@@ -34,9 +48,24 @@ type RunCommand struct {
 	maxPlans                  int
 	waitTimeout               time.Duration
 	disableDriftPlanExitCodes bool
+	exitSeverities            []string
+	exitDefaultSeverity       string
 	outFormat                 string
+	outputs                   []string
+	resultSchemaVersion       int
+	templateFile              string
 	dryRun                    bool
 	fetchWorkers              int
+	emailNotifier             emailNotifierFlags
+	githubNotifier            githubNotifierFlags
+	pagerdutyNotifier         pagerdutyNotifierFlags
+	alertmanagerNotifier      alertmanagerNotifierFlags
+	transitions               transitionsFlags
+	sorting                   sortFlags
+	metricsTextfile           string
+	metricsPushgatewayURL     string
+	metricsPushgatewayJob     string
+	metricsPushgatewayGroup   []string
 }
 
 // NewRunCommand returns the Run command.
@@ -56,15 +85,31 @@ func NewRunCommand(rootConfig *RootCommand, app *kingpin.Application) *RunComman
 	cmd.Flag("not-before", "Will filter the workspaces that executed a drift detection plan before before this duration.").Short('n').Default("1h").DurationVar(&c.notBefore)
 	cmd.Flag("wait-timeout", "Max time duration to wait for drift detection plans to finish.").Default("2h").DurationVar(&c.waitTimeout)
 	cmd.Flag("disable-drift-plan-exitcodes", "Will disable the drift detection plans related exit codes (2 and 3).").BoolVar(&c.disableDriftPlanExitCodes)
-	cmd.Flag("out-format", "Selects the format of the result output.").Short('o').EnumVar(&c.outFormat, outFormatJSON, outFormatPrettyJSON)
+	cmd.Flag("exit-severity", "Rule to select the severity of the workspaces for the exit code with `[tag:<tag>|name:<regex>=]<error|warning>` format, warning workspaces will not affect the exit code, first match wins (can be repeated).").StringsVar(&c.exitSeverities)
+	cmd.Flag("exit-default-severity", "The exit code severity of the workspaces that don't match any exit severity rule.").Default(exitpolicy.SeverityError).EnumVar(&c.exitDefaultSeverity, exitpolicy.SeverityError, exitpolicy.SeverityWarning)
+	cmd.Flag("out-format", "Selects the format of the result output.").Short('o').EnumVar(&c.outFormat, outFormats...)
+	cmd.Flag("output", "Result output with `<format>=<path>` format, if the path is omitted or `-` it will be written on the stdout, files are written atomically (can be repeated).").StringsVar(&c.outputs)
+	cmd.Flag("result-schema-version", "The JSON result schema version, can be used to pin older result shapes.").Default(strconv.Itoa(result.DefaultVersion)).IntVar(&c.resultSchemaVersion)
+	cmd.Flag("template-file", "Go template file used to render the result output when using `template` output format.").StringVar(&c.templateFile)
 	cmd.Flag("dry-run", "Will execute all the process without creating any drift detection plans, will use latest ones available.").BoolVar(&c.dryRun)
 	cmd.Flag("fetch-workers", "The number of workers running concurrently to fetch workspaces information.").Default("20").IntVar(&c.fetchWorkers)
+	cmd.Flag("metrics-textfile", "Path of the Prometheus node exporter textfile collector file where the run metrics will be written.").StringVar(&c.metricsTextfile)
+	cmd.Flag("metrics-pushgateway-url", "Prometheus Pushgateway URL where the run metrics will be pushed.").StringVar(&c.metricsPushgatewayURL)
+	cmd.Flag("metrics-pushgateway-job", "The job used to push the run metrics to the Prometheus Pushgateway.").Default("tfe-drift").StringVar(&c.metricsPushgatewayJob)
+	cmd.Flag("metrics-pushgateway-grouping", "Grouping label used to push the run metrics to the Prometheus Pushgateway with `<key>=<value>` format (can be repeated).").StringsVar(&c.metricsPushgatewayGroup)
+	c.emailNotifier.register(cmd)
+	c.githubNotifier.register(cmd)
+	c.pagerdutyNotifier.register(cmd)
+	c.alertmanagerNotifier.register(cmd)
+	c.transitions.register(cmd)
+	c.sorting.register(cmd)
 
 	return c
 }
 
 func (c RunCommand) Name() string { return c.cmd.FullCommand() }
 func (c RunCommand) Run(ctx context.Context) error {
+	startedAt := time.Now()
 	logger := c.rootConfig.Logger
 
 	if len(c.excludeNameRegexes) > 0 && len(c.includeNameRegexes) > 0 {
@@ -121,46 +166,148 @@ func (c RunCommand) Run(ctx context.Context) error {
 		excludeProcessor = p
 	}
 
-	var resultOutProcessor process.Processor = process.NoopProcessor
-	switch c.outFormat {
-	case outFormatJSON:
-		resultOutProcessor = wksprocess.NewDetailedJSONResultProcessor(c.rootConfig.Stdout, false)
-	case outFormatPrettyJSON:
-		resultOutProcessor = wksprocess.NewDetailedJSONResultProcessor(c.rootConfig.Stdout, true)
+	sortProcessor, err := c.sorting.newProcessor(logger, c.rootConfig.Stderr)
+	if err != nil {
+		return fmt.Errorf("invalid sort processor: %w", err)
+	}
+
+	exitSeverityRules, err := selector.ParseRules(c.exitSeverities)
+	if err != nil {
+		return fmt.Errorf("invalid exit severity rules: %w", err)
+	}
+
+	exitPolicy, err := exitpolicy.NewPolicy(exitpolicy.PolicyConfig{
+		SeverityRules:         exitSeverityRules,
+		DefaultSeverity:       c.exitDefaultSeverity,
+		DisableDriftPlanCodes: c.disableDriftPlanExitCodes,
+	})
+	if err != nil {
+		return fmt.Errorf("could not create exit policy: %w", err)
+	}
+
+	snapshot := wksprocess.NewWorkspaceSnapshot()
+	processedSnapshot := wksprocess.NewWorkspaceSnapshot()
+	exitDecider := wksprocess.NewExitDecider(exitPolicy, snapshot)
+	resultOutProcessor, err := c.newResultOutProcessor(snapshot, exitDecider)
+	if err != nil {
+		return fmt.Errorf("invalid result output: %w", err)
+	}
+
+	var emailNotifyProcessor process.Processor = process.NoopProcessor
+	if c.emailNotifier.enabled() {
+		p, err := c.emailNotifier.newProcessor(logger, c.transitions.enabled())
+		if err != nil {
+			return err
+		}
+		emailNotifyProcessor = p
+	}
+
+	var githubNotifyProcessor process.Processor = process.NoopProcessor
+	if c.githubNotifier.enabled() {
+		p, err := c.githubNotifier.newProcessor(logger, c.transitions.enabled())
+		if err != nil {
+			return err
+		}
+		githubNotifyProcessor = p
+	}
+
+	var pagerdutyNotifyProcessor process.Processor = process.NoopProcessor
+	if c.pagerdutyNotifier.enabled() {
+		p, err := c.pagerdutyNotifier.newProcessor(logger, c.transitions.enabled())
+		if err != nil {
+			return err
+		}
+		pagerdutyNotifyProcessor = p
+	}
+
+	var alertmanagerNotifyProcessor process.Processor = process.NoopProcessor
+	if c.alertmanagerNotifier.enabled() {
+		p, err := c.alertmanagerNotifier.newProcessor(logger)
+		if err != nil {
+			return err
+		}
+		alertmanagerNotifyProcessor = p
+	}
+
+	// The run stats recorder counts the drift detection plans where they are created, for the run metrics.
+	runStatsRecorder := wksprocess.NewRunStatsRecorder()
+	var runMetricsExporter wksprocess.RunMetricsExporter
+	if c.metricsTextfile != "" || c.metricsPushgatewayURL != "" {
+		grouping := map[string]string{}
+		for _, g := range c.metricsPushgatewayGroup {
+			k, v, ok := strings.Cut(g, "=")
+			if !ok || k == "" {
+				return fmt.Errorf("invalid pushgateway grouping %q, must be `<key>=<value>`", g)
+			}
+			grouping[k] = v
+		}
+
+		e, err := internalprometheus.NewRunMetricsExporter(internalprometheus.RunMetricsExporterConfig{
+			Logger:              logger,
+			TextfilePath:        c.metricsTextfile,
+			PushgatewayURL:      c.metricsPushgatewayURL,
+			PushgatewayJob:      c.metricsPushgatewayJob,
+			PushgatewayGrouping: grouping,
+		})
+		if err != nil {
+			return fmt.Errorf("could not create run metrics exporter: %w", err)
+		}
+		runMetricsExporter = e
+	}
+
+	// Alertmanager is not a notifier that should be affected by the transitions, it deduplicates by itself.
+	notifyProcessor, err := c.transitions.newProcessor(logger, repo, []wksprocess.Processor{
+		emailNotifyProcessor,
+		githubNotifyProcessor,
+		pagerdutyNotifyProcessor,
+	})
+	if err != nil {
+		return fmt.Errorf("invalid notify transitions processor: %w", err)
 	}
 
 	wksProcessors := []wksprocess.Processor{
 		includeProcessor,
 		excludeProcessor,
 		wksprocess.NewHydrateLatestDetectionPlanProcessor(ctx, logger, repo, c.fetchWorkers),
+		snapshot,
 		wksprocess.NewFilterQueuedDriftDetectorProcessor(logger),
 		wksprocess.NewFilterDriftDetectionsBeforeProcessor(logger, c.notBefore),
-		wksprocess.NewSortByOldestDetectionPlanProcessor(logger),
+		sortProcessor,
 		wksprocess.NewLimitMaxProcessor(logger, c.maxPlans),
-		wksprocess.NewDriftDetectionPlanProcessor(logger, repo, c.planMessage),
-		wksprocess.NewDriftDetectionPlanWaitProcessor(logger, repo, waitPolling, c.waitTimeout),
+		wksprocess.NewDriftDetectionPlanProcessor(logger, runStatsRecorder, repo, c.planMessage),
+		wksprocess.NewDriftDetectionPlanWaitProcessor(logger, runStatsRecorder, repo, waitPolling, c.waitTimeout),
+		notifyProcessor,
+		alertmanagerNotifyProcessor,
+		exitDecider,
 		resultOutProcessor,
-		wksprocess.NewDriftDetectionPlansResultProcessor(logger, c.disableDriftPlanExitCodes),
+		processedSnapshot,
+		wksprocess.NewDriftDetectionPlansResultProcessor(logger, exitDecider),
 	}
 
 	// Execute.
-	logger.Infof("Retrieving workspaces")
-	wks, err := repo.ListWorkspaces(ctx, includeTags, excludeTags)
-	if err != nil {
-		return fmt.Errorf("could not list workspaces: %w", err)
-	}
+	chain := wksprocess.NewProcessorChain(wksProcessors)
+	var run process.Processor = process.ProcessorFunc(func(ctx context.Context, _ []model.Workspace) ([]model.Workspace, error) {
+		logger.Infof("Retrieving workspaces")
+		wks, err := repo.ListWorkspaces(ctx, includeTags, excludeTags)
+		if err != nil {
+			return nil, fmt.Errorf("could not list workspaces: %w", err)
+		}
 
-	if len(wks) == 0 {
-		return fmt.Errorf("0 workspaces selected")
-	}
+		_, err = chain.Process(ctx, wks)
+		if err != nil {
+			return nil, fmt.Errorf("workspaces processing failed: %w", err)
+		}
 
-	chain := wksprocess.NewProcessorChain(wksProcessors)
-	_, err = chain.Process(ctx, wks)
-	if err != nil {
-		return fmt.Errorf("workspaces processing failed: %w", err)
+		return nil, nil
+	})
+
+	// Export the run metrics even if the run fails.
+	if runMetricsExporter != nil {
+		run = wksprocess.NewRunMetricsProcessor(logger, runMetricsExporter, runStatsRecorder, processedSnapshot, startedAt, c.dryRun, run)
 	}
 
-	return nil
+	_, err = run.Process(ctx, nil)
+	return err
 }
 
 // splitRepeatedArg will split the strings inside each repeated arg and return flatten.
@@ -172,3 +319,40 @@ func splitRepeatedArg(ss []string, c string) []string {
 
 	return newSS
 }
+
+func (c RunCommand) newResultOutProcessor(snapshot *wksprocess.WorkspaceSnapshot, exitDecider *wksprocess.ExitDecider) (process.Processor, error) {
+	if !slices.Contains(result.Versions, c.resultSchemaVersion) {
+		return nil, fmt.Errorf("unknown result schema version %d", c.resultSchemaVersion)
+	}
+
+	factory := &resultOutProcessorFactory{snapshot: snapshot, exitDecider: exitDecider, schemaVersion: c.resultSchemaVersion, templateFile: c.templateFile}
+	ps := []process.Processor{}
+
+	if c.outFormat != "" {
+		p, err := factory.newStdoutProcessor(c.outFormat, c.rootConfig.Stdout)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+
+	for _, o := range c.outputs {
+		out, err := parseResultOutput(o)
+		if err != nil {
+			return nil, err
+		}
+
+		var p process.Processor
+		if out.path == "" {
+			p, err = factory.newStdoutProcessor(out.format, c.rootConfig.Stdout)
+		} else {
+			p, err = factory.newFileProcessor(out.format, out.path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+
+	return process.NewProcessorChain(ps), nil
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/slok/tfe-drift/internal/result"
+)
+
+type SchemaCommand struct {
+	cmd        *kingpin.CmdClause
+	rootConfig *RootCommand
+
+	resultSchemaVersion int
+}
+
+// NewSchemaCommand returns the schema command.
+func NewSchemaCommand(rootConfig *RootCommand, app *kingpin.Application) *SchemaCommand {
+	cmd := app.Command("schema", "Shows the JSON schema of the JSON result.")
+	c := &SchemaCommand{
+		cmd:        cmd,
+		rootConfig: rootConfig,
+	}
+
+	cmd.Flag("result-schema-version", "The JSON result schema version.").Default(strconv.Itoa(result.DefaultVersion)).IntVar(&c.resultSchemaVersion)
+
+	return c
+}
+
+func (s SchemaCommand) Name() string { return s.cmd.FullCommand() }
+func (s SchemaCommand) Run(ctx context.Context) error {
+	schema, err := result.Schema(s.resultSchemaVersion)
+	if err != nil {
+		return fmt.Errorf("could not get schema: %w", err)
+	}
+
+	_, err = s.rootConfig.Stdout.Write(schema)
+	if err != nil {
+		return fmt.Errorf("could not write schema: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/workspace/priority"
+	wksprocess "github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+const (
+	sortOldest   = "oldest"
+	sortPriority = "priority"
+	sortRandom   = "random"
+	sortName     = "name"
+)
+
+// sortFlags are the flags shared by the commands that sort the workspaces before the drift detections.
+type sortFlags struct {
+	sort        string
+	weights     []string
+	driftWeight float64
+	errorWeight float64
+	ageWeight   float64
+	maxAge      time.Duration
+	explain     bool
+}
+
+func (s *sortFlags) register(cmd *kingpin.CmdClause) {
+	cmd.Flag("sort", "The order of the workspaces drift detections, applied before the limit of drift detection plans.").Default(sortOldest).EnumVar(&s.sort, sortOldest, sortPriority, sortRandom, sortName)
+	cmd.Flag("priority-weight", "Rule to add a weight to the workspaces priority with `[tag:<tag>|name:<regex>=]<weight>` format, all matches are added (can be repeated).").StringsVar(&s.weights)
+	cmd.Flag("priority-drift-weight", "The weight added to the workspaces priority when the latest drift detection had drift.").Default("24").Float64Var(&s.driftWeight)
+	cmd.Flag("priority-error-weight", "The weight added to the workspaces priority when the latest drift detection plan failed.").Default("24").Float64Var(&s.errorWeight)
+	cmd.Flag("priority-age-weight", "The weight added to the workspaces priority per hour since the latest drift detection.").Default("1").Float64Var(&s.ageWeight)
+	cmd.Flag("priority-max-age", "The max age used on the workspaces priority, the workspaces without drift detections will use it.").Default("168h").DurationVar(&s.maxAge)
+	cmd.Flag("priority-explain", "Will write the workspaces priority breakdown on the stderr every time they are sorted by priority.").BoolVar(&s.explain)
+}
+
+func (s sortFlags) newProcessor(logger log.Logger, explain io.Writer) (wksprocess.Processor, error) {
+	switch s.sort {
+	case sortPriority:
+		rules, err := selector.ParseRules(s.weights)
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority weight rules: %w", err)
+		}
+
+		scorer, err := priority.NewScorer(priority.ScorerConfig{
+			WeightRules: rules,
+			DriftWeight: s.driftWeight,
+			ErrorWeight: s.errorWeight,
+			AgeWeight:   s.ageWeight,
+			MaxAge:      s.maxAge,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create priority scorer: %w", err)
+		}
+
+		if !s.explain {
+			explain = nil
+		}
+
+		return wksprocess.NewSortByPriorityProcessor(logger, scorer, explain), nil
+	case sortRandom:
+		return wksprocess.NewSortRandomProcessor(logger), nil
+	case sortName:
+		return wksprocess.NewSortByNameProcessor(logger), nil
+	default:
+		return wksprocess.NewSortByOldestDetectionPlanProcessor(logger), nil
+	}
+}
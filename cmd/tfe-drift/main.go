@@ -22,25 +22,18 @@ import (
 
 // Run runs the main application.
 func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) (err error) {
-	app := kingpin.New("tfe-drift", "Automated Terraform cloud drift checker.")
-	app.DefaultEnvars()
-	rootCmd := commands.NewRootCommand(app)
-
-	// Setup commands (registers flags).
-	versionCmd := commands.NewVersionCommand(rootCmd, app)
-	runCmd := commands.NewRunCommand(rootCmd, app)
-	controllerCmd := commands.NewControllerCommand(rootCmd, app)
-
-	cmds := map[string]commands.Command{
-		versionCmd.Name():    versionCmd,
-		runCmd.Name():        runCmd,
-		controllerCmd.Name(): controllerCmd,
+	rootCmd, cmds, cmdName, err := parseCommands(args)
+	if err != nil {
+		return err
 	}
 
-	// Parse commandline.
-	cmdName, err := app.Parse(args[1:])
-	if err != nil {
-		return fmt.Errorf("invalid command configuration: %w", err)
+	// Reloads get the command from a new parse, so the configuration file changes are loaded.
+	rootCmd.Reload = func() (commands.Command, error) {
+		_, cmds, cmdName, err := parseCommands(args)
+		if err != nil {
+			return nil, err
+		}
+		return cmds[cmdName], nil
 	}
 
 	// Set standard input/output.
@@ -98,6 +91,41 @@ func Run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.
 	return g.Run()
 }
 
+// parseCommands sets up the commands and parses the command line arguments with the configuration file, returning
+// the root command, the commands by name and the selected command name.
+func parseCommands(args []string) (*commands.RootCommand, map[string]commands.Command, string, error) {
+	app := kingpin.New("tfe-drift", "Automated Terraform cloud drift checker.")
+	app.DefaultEnvars()
+	rootCmd := commands.NewRootCommand(app)
+
+	// Setup commands (registers flags).
+	versionCmd := commands.NewVersionCommand(rootCmd, app)
+	runCmd := commands.NewRunCommand(rootCmd, app)
+	controllerCmd := commands.NewControllerCommand(rootCmd, app)
+	schemaCmd := commands.NewSchemaCommand(rootCmd, app)
+
+	cmds := map[string]commands.Command{
+		versionCmd.Name():    versionCmd,
+		runCmd.Name():        runCmd,
+		controllerCmd.Name(): controllerCmd,
+		schemaCmd.Name():     schemaCmd,
+	}
+
+	// Apply the configuration file flags, if any.
+	cmdArgs, err := commands.ApplyConfigFile(app, args[1:])
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("invalid configuration file: %w", err)
+	}
+
+	// Parse commandline.
+	cmdName, err := app.Parse(cmdArgs)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("invalid command configuration: %w", err)
+	}
+
+	return rootCmd, cmds, cmdName, nil
+}
+
 // getLogger returns the application logger.
 func getLogger(ctx context.Context, config commands.RootCommand) log.Logger {
 	if config.NoLog {
@@ -137,14 +165,11 @@ func main() {
 	ctx := context.Background()
 	err := Run(ctx, os.Args, os.Stdin, os.Stdout, os.Stderr)
 	if err != nil {
-		switch {
-		// Detecting drifts is not a regular error: Quiet and other different code.
-		case errors.Is(err, internalerrors.ErrDriftDetected):
-			fmt.Fprint(os.Stderr, "Drift detected")
-			os.Exit(2)
-		case errors.Is(err, internalerrors.ErrDriftDetectionPlanFailed):
-			fmt.Fprint(os.Stderr, "Drift detection plan failed")
-			os.Exit(3)
+		// Exit errors are not regular errors (e.g: drift detected): Quiet and other different code.
+		var exitErr *internalerrors.ExitError
+		if errors.As(err, &exitErr) {
+			fmt.Fprintln(os.Stderr, exitErr)
+			os.Exit(exitErr.Code)
 		}
 
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// BlackoutWindow is a period of time where drift detection plans will not be created.
+type BlackoutWindow struct {
+	raw      string
+	selector selector.Selector
+
+	// One-off windows.
+	from time.Time
+	to   time.Time
+
+	// Recurring windows.
+	recurring bool
+	days      [7]bool
+	start     time.Duration
+	end       time.Duration
+	timezone  *time.Location
+}
+
+// ParseBlackoutWindow parses a blackout window with `[tag:<tag>|name:<regex>=]<window>` format, if the
+// window doesn't have a selector it will apply to all the workspaces. The window can be:
+//
+//   - Recurring: `<days> <HH:MM>-<HH:MM> [<timezone>]` (e.g: `mon-fri 09:00-10:00 Europe/Madrid`), the days
+//     are comma separated days or ranges (e.g: `mon,wed`, `sat-sun`), `*`, `weekdays` or `weekends`. The
+//     timezone is UTC by default and the windows can cross midnight (e.g: `fri 22:00-02:00`).
+//   - One-off: `<RFC3339>/<RFC3339>` (e.g: `2023-12-22T00:00:00Z/2024-01-08T00:00:00Z`).
+func ParseBlackoutWindow(s string) (BlackoutWindow, error) {
+	r, err := selector.ParseRule(s)
+	if err != nil {
+		return BlackoutWindow{}, err
+	}
+
+	b := BlackoutWindow{raw: strings.TrimSpace(s), selector: r.Selector}
+
+	fields := strings.Fields(r.Value)
+	if from, to, ok := strings.Cut(r.Value, "/"); ok && len(fields) == 1 {
+		b.from, err = time.Parse(time.RFC3339, strings.TrimSpace(from))
+		if err != nil {
+			return BlackoutWindow{}, fmt.Errorf("invalid window start: %w", err)
+		}
+
+		b.to, err = time.Parse(time.RFC3339, strings.TrimSpace(to))
+		if err != nil {
+			return BlackoutWindow{}, fmt.Errorf("invalid window end: %w", err)
+		}
+
+		if !b.to.After(b.from) {
+			return BlackoutWindow{}, fmt.Errorf("window end must be after start")
+		}
+
+		return b, nil
+	}
+
+	if len(fields) < 2 || len(fields) > 3 {
+		return BlackoutWindow{}, fmt.Errorf("invalid window %q, must be `<days> <HH:MM>-<HH:MM> [<timezone>]` or `<RFC3339>/<RFC3339>`", r.Value)
+	}
+
+	b.recurring = true
+	b.days, err = parseDays(fields[0])
+	if err != nil {
+		return BlackoutWindow{}, err
+	}
+
+	start, end, ok := strings.Cut(fields[1], "-")
+	if !ok {
+		return BlackoutWindow{}, fmt.Errorf("invalid time range %q, must be `<HH:MM>-<HH:MM>`", fields[1])
+	}
+
+	b.start, err = parseTimeOfDay(start)
+	if err != nil {
+		return BlackoutWindow{}, err
+	}
+
+	b.end, err = parseTimeOfDay(end)
+	if err != nil {
+		return BlackoutWindow{}, err
+	}
+
+	if b.start == b.end {
+		return BlackoutWindow{}, fmt.Errorf("time range start and end can't be the same")
+	}
+
+	b.timezone = time.UTC
+	if len(fields) == 3 {
+		b.timezone, err = time.LoadLocation(fields[2])
+		if err != nil {
+			return BlackoutWindow{}, fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	return b, nil
+}
+
+func parseDays(s string) ([7]bool, error) {
+	var days [7]bool
+
+	for _, d := range strings.Split(strings.ToLower(s), ",") {
+		switch d {
+		case "*":
+			return [7]bool{true, true, true, true, true, true, true}, nil
+		case "weekdays":
+			d = "mon-fri"
+		case "weekends":
+			d = "sat-sun"
+		}
+
+		from, to, isRange := strings.Cut(d, "-")
+		fromDay, ok := weekdays[from]
+		if !ok {
+			return days, fmt.Errorf("invalid day %q", from)
+		}
+
+		if !isRange {
+			days[fromDay] = true
+			continue
+		}
+
+		toDay, ok := weekdays[to]
+		if !ok {
+			return days, fmt.Errorf("invalid day %q", to)
+		}
+
+		for wd := fromDay; ; wd = (wd + 1) % 7 {
+			days[wd] = true
+			if wd == toDay {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+func parseTimeOfDay(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, must be `HH:MM`", s)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Active returns true if the window is active at the time.
+func (b BlackoutWindow) Active(t time.Time) bool {
+	if !b.recurring {
+		return !t.Before(b.from) && t.Before(b.to)
+	}
+
+	t = t.In(b.timezone)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, b.timezone)
+	tod := t.Sub(midnight)
+
+	// Windows that don't cross midnight.
+	if b.start < b.end {
+		return b.days[t.Weekday()] && tod >= b.start && tod < b.end
+	}
+
+	// Windows crossing midnight start on the window day and end on the next one.
+	yesterday := (t.Weekday() + 6) % 7
+	return (b.days[t.Weekday()] && tod >= b.start) || (b.days[yesterday] && tod < b.end)
+}
+
+// Scoped returns true if the window only applies to the workspaces that match its selector.
+func (b BlackoutWindow) Scoped() bool { return b.selector.String() != "*" }
+
+// Match returns true if the window applies to the workspace.
+func (b BlackoutWindow) Match(wk model.Workspace) bool { return b.selector.Match(wk) }
+
+func (b BlackoutWindow) String() string { return b.raw }
+
+// BlackoutWindowStatus is the status of a blackout window at a specific time.
+type BlackoutWindowStatus struct {
+	Window string `json:"window"`
+	Scoped bool   `json:"scoped"`
+	Active bool   `json:"active"`
+}
+
+// Blackouts are the blackout windows of the controller.
+type Blackouts []BlackoutWindow
+
+// ParseBlackouts parses multiple blackout windows (check ParseBlackoutWindow), the windows are
+// identified by their definition so duplicated windows are not allowed.
+func ParseBlackouts(ss []string) (Blackouts, error) {
+	blackouts := make(Blackouts, 0, len(ss))
+	seen := map[string]bool{}
+	for _, s := range ss {
+		b, err := ParseBlackoutWindow(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blackout window %q: %w", s, err)
+		}
+
+		if seen[b.String()] {
+			return nil, fmt.Errorf("duplicated blackout window %q", b)
+		}
+		seen[b.String()] = true
+
+		blackouts = append(blackouts, b)
+	}
+
+	return blackouts, nil
+}
+
+// Active returns the active blackout windows at the time.
+func (b Blackouts) Active(t time.Time) Blackouts {
+	active := Blackouts{}
+	for _, w := range b {
+		if w.Active(t) {
+			active = append(active, w)
+		}
+	}
+
+	return active
+}
+
+// Status returns the status of all the blackout windows at the time.
+func (b Blackouts) Status(t time.Time) []BlackoutWindowStatus {
+	status := make([]BlackoutWindowStatus, 0, len(b))
+	for _, w := range b {
+		status = append(status, BlackoutWindowStatus{Window: w.String(), Scoped: w.Scoped(), Active: w.Active(t)})
+	}
+
+	return status
+}
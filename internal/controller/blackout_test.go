@@ -0,0 +1,214 @@
+package controller_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+func TestBlackoutWindow(t *testing.T) {
+	// 2023-01-13 is a Friday.
+	date := func(day, hour, min int) time.Time { return time.Date(2023, 1, day, hour, min, 0, 0, time.UTC) }
+
+	tests := map[string]struct {
+		window    string
+		t         time.Time
+		wk        model.Workspace
+		expErr    bool
+		expActive bool
+		expScoped bool
+		expMatch  bool
+	}{
+		"An invalid window should fail.": {
+			window: "something",
+			expErr: true,
+		},
+
+		"An invalid day should fail.": {
+			window: "monday 09:00-10:00",
+			expErr: true,
+		},
+
+		"An invalid time range should fail.": {
+			window: "mon 09:00",
+			expErr: true,
+		},
+
+		"An invalid timezone should fail.": {
+			window: "mon 09:00-10:00 Europe/Nowhere",
+			expErr: true,
+		},
+
+		"A one-off window with the end before the start should fail.": {
+			window: "2023-01-10T00:00:00Z/2023-01-01T00:00:00Z",
+			expErr: true,
+		},
+
+		"An invalid selector should fail.": {
+			window: "something:t1=mon 09:00-10:00",
+			expErr: true,
+		},
+
+		"A recurring window inside the time range should be active.": {
+			window:    "weekdays 09:00-10:00",
+			t:         date(13, 9, 30),
+			expActive: true,
+			expMatch:  true,
+		},
+
+		"A recurring window outside the time range should not be active.": {
+			window:   "weekdays 09:00-10:00",
+			t:        date(13, 10, 0),
+			expMatch: true,
+		},
+
+		"A recurring window outside the days should not be active.": {
+			window:   "weekdays 09:00-10:00",
+			t:        date(14, 9, 30),
+			expMatch: true,
+		},
+
+		"A recurring window with a timezone should use the timezone.": {
+			window:    "fri 09:00-10:00 Europe/Madrid",
+			t:         date(13, 8, 30),
+			expActive: true,
+			expMatch:  true,
+		},
+
+		"A recurring window with day ranges crossing the week should be active.": {
+			window:    "sat-mon 09:00-10:00",
+			t:         date(15, 9, 30),
+			expActive: true,
+			expMatch:  true,
+		},
+
+		"A recurring window crossing midnight should be active on the next day.": {
+			window:    "fri 22:00-02:00",
+			t:         date(14, 1, 0),
+			expActive: true,
+			expMatch:  true,
+		},
+
+		"A recurring window crossing midnight should not be active on the previous day morning.": {
+			window:   "fri 22:00-02:00",
+			t:        date(13, 1, 0),
+			expMatch: true,
+		},
+
+		"A one-off window inside the range should be active.": {
+			window:    "2023-01-10T00:00:00Z/2023-01-20T00:00:00Z",
+			t:         date(13, 9, 30),
+			expActive: true,
+			expMatch:  true,
+		},
+
+		"A one-off window outside the range should not be active.": {
+			window:   "2023-01-10T00:00:00Z/2023-01-13T00:00:00Z",
+			t:        date(13, 9, 30),
+			expMatch: true,
+		},
+
+		"A scoped window should match the workspaces with the tag.": {
+			window:    "tag:sandbox=* 00:00-23:59",
+			t:         date(13, 9, 30),
+			wk:        model.Workspace{Tags: []string{"sandbox"}},
+			expActive: true,
+			expScoped: true,
+			expMatch:  true,
+		},
+
+		"A scoped window should not match the workspaces without the tag.": {
+			window:    "tag:sandbox=* 00:00-23:59",
+			t:         date(13, 9, 30),
+			wk:        model.Workspace{Tags: []string{"prod"}},
+			expActive: true,
+			expScoped: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			b, err := controller.ParseBlackoutWindow(test.window)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(test.expActive, b.Active(test.t))
+			assert.Equal(test.expScoped, b.Scoped())
+			assert.Equal(test.expMatch, b.Match(test.wk))
+		})
+	}
+}
+
+func TestBlackoutsStatus(t *testing.T) {
+	t0 := time.Date(2023, 1, 13, 9, 30, 0, 0, time.UTC)
+
+	b1, err := controller.ParseBlackoutWindow("weekdays 09:00-10:00")
+	require.NoError(t, err)
+	b2, err := controller.ParseBlackoutWindow("tag:sandbox=weekends 09:00-10:00")
+	require.NoError(t, err)
+
+	bs := controller.Blackouts{b1, b2}
+
+	assert.Equal(t, controller.Blackouts{b1}, bs.Active(t0))
+	assert.Equal(t, []controller.BlackoutWindowStatus{
+		{Window: "weekdays 09:00-10:00", Active: true},
+		{Window: "tag:sandbox=weekends 09:00-10:00", Scoped: true},
+	}, bs.Status(t0))
+}
+
+func TestParseBlackouts(t *testing.T) {
+	tests := map[string]struct {
+		windows    []string
+		expWindows []string
+		expErr     bool
+	}{
+		"No windows should return empty blackouts.": {
+			windows:    nil,
+			expWindows: []string{},
+		},
+
+		"Multiple windows should be parsed in order.": {
+			windows:    []string{"weekdays 09:00-10:00", "tag:sandbox=weekends 09:00-10:00"},
+			expWindows: []string{"weekdays 09:00-10:00", "tag:sandbox=weekends 09:00-10:00"},
+		},
+
+		"Invalid windows should fail.": {
+			windows: []string{"weekdays 09:00-10:00", "weekdays 09:00"},
+			expErr:  true,
+		},
+
+		"Duplicated windows should fail.": {
+			windows: []string{"weekdays 09:00-10:00", "tag:sandbox=weekends 09:00-10:00", " weekdays 09:00-10:00"},
+			expErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bs, err := controller.ParseBlackouts(test.windows)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(t, err)
+
+			gotWindows := []string{}
+			for _, s := range bs.Status(time.Now()) {
+				gotWindows = append(gotWindows, s.Window)
+			}
+			assert.Equal(test.expWindows, gotWindows)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package controller
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/slok/tfe-drift/internal/log"
@@ -15,17 +16,42 @@ type WorkspaceLister interface {
 }
 
 type DriftDetectorConfig struct {
-	Logger             log.Logger
-	Interval           time.Duration
+	Logger   log.Logger
+	Interval time.Duration
+	// Schedule is used to know when to run the drift detections, if not set, Interval will be used.
+	Schedule        Schedule
+	MetricsRecorder MetricsRecorder
+	// Blackouts are the windows where drift detections will not be run, scoped windows will
+	// only skip the workspaces that match them.
+	Blackouts Blackouts
+	// Leader is used to only run the drift detections on the leader replica, if not set, it will always run.
+	Leader Leader
+	// Locks are used to not run drift detections concurrently on the same workspaces of other
+	// detectors (e.g: on-demand), if not set, workspaces will not be locked.
+	Locks              *WorkspaceLocks
 	WorkspaceLister    WorkspaceLister
 	WorkspaceProcessor wkprocess.Processor
-	IncludeTags        []string
-	ExcludeTags        []string
+	// TrackerProcessor is used to track the drift detection plans created by the workspace processor in the background
+	// (e.g: wait for the plans and notify), so the next drift detection cycles don't wait for them. If not set, the
+	// workspace processor will be run until the end on every cycle.
+	TrackerProcessor wkprocess.Processor
+	// MaxInflightCycles is the maximum number of cycles tracking plans in the background, when reached, the new cycles
+	// will be skipped, 0 means no limit.
+	MaxInflightCycles int
+	IncludeTags       []string
+	ExcludeTags       []string
 }
 
 func (c *DriftDetectorConfig) defaults() error {
-	if c.Interval == 0 {
-		return fmt.Errorf("interval can't be 0")
+	if c.Schedule == nil {
+		if c.Interval == 0 {
+			return fmt.Errorf("interval can't be 0")
+		}
+		c.Schedule = IntervalSchedule(c.Interval)
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = NoopMetricsRecorder
 	}
 
 	if c.WorkspaceLister == nil {
@@ -36,6 +62,10 @@ func (c *DriftDetectorConfig) defaults() error {
 		return fmt.Errorf("workspace processor is required")
 	}
 
+	if c.MaxInflightCycles < 0 {
+		return fmt.Errorf("max inflight cycles can't be negative")
+	}
+
 	if c.Logger == nil {
 		c.Logger = log.Noop
 	}
@@ -46,11 +76,86 @@ func (c *DriftDetectorConfig) defaults() error {
 
 type DriftDetector struct {
 	logger      log.Logger
-	interval    time.Duration
+	schedule    Schedule
+	metrics     MetricsRecorder
+	blackouts   Blackouts
+	leader      Leader
+	locks       *WorkspaceLocks
 	wkLister    WorkspaceLister
 	wprocessor  wkprocess.Processor
+	tprocessor  wkprocess.Processor
+	maxInflight int
 	includeTags []string
 	excludeTags []string
+	status      *driftDetectorStatus
+	trackers    *sync.WaitGroup
+}
+
+// Cycle is the summary of a drift detection cycle.
+type Cycle struct {
+	StartedAt  time.Time
+	FinishedAt time.Time
+	// Skip is the kind of skip of the drift detection cycle, empty if not skipped.
+	Skip CycleSkip
+	// SkippedReason is the reason of skipping the drift detection cycle, empty if not skipped.
+	SkippedReason string
+	// OverlappedCycles is the number of previous cycles that were still tracking their plans when the cycle started.
+	OverlappedCycles int
+	// Selected is the number of workspaces selected for the drift detection.
+	Selected int
+	// Processed are the number of processed workspaces by drift state.
+	Processed map[model.DriftState]int
+	Err       error
+}
+
+// CycleResult is the result of a drift detection cycle.
+type CycleResult string
+
+const (
+	CycleResultSuccess CycleResult = "success"
+	CycleResultError   CycleResult = "error"
+	CycleResultSkipped CycleResult = "skipped"
+)
+
+// CycleSkip is the kind of skip of a drift detection cycle.
+type CycleSkip string
+
+const (
+	CycleSkipNotLeader   CycleSkip = "not_leader"
+	CycleSkipBlackout    CycleSkip = "blackout"
+	CycleSkipMaxInflight CycleSkip = "max_inflight"
+)
+
+// Result returns the result of the drift detection cycle.
+func (c Cycle) Result() CycleResult {
+	switch {
+	case c.Err != nil:
+		return CycleResultError
+	case c.SkippedReason != "":
+		return CycleResultSkipped
+	default:
+		return CycleResultSuccess
+	}
+}
+
+// DriftDetectorStatus is the status of the drift detector.
+type DriftDetectorStatus struct {
+	Schedule string
+	NextRun  time.Time
+	// LastCycle is the last finished drift detection cycle, nil if none.
+	LastCycle *Cycle
+	// LastSuccessAt is when the last successful (not failed nor skipped) drift detection cycle finished, zero if none.
+	LastSuccessAt time.Time
+	// InflightCycles is the number of cycles tracking their plans in the background.
+	InflightCycles int
+}
+
+type driftDetectorStatus struct {
+	mu            sync.RWMutex
+	nextRun       time.Time
+	lastCycle     *Cycle
+	lastSuccessAt time.Time
+	inflight      int
 }
 
 func NewDriftDetector(config DriftDetectorConfig) (*DriftDetector, error) {
@@ -61,59 +166,289 @@ func NewDriftDetector(config DriftDetectorConfig) (*DriftDetector, error) {
 
 	return &DriftDetector{
 		logger:      config.Logger,
-		interval:    config.Interval,
+		schedule:    config.Schedule,
+		metrics:     config.MetricsRecorder,
+		blackouts:   config.Blackouts,
+		leader:      config.Leader,
+		locks:       config.Locks,
 		wkLister:    config.WorkspaceLister,
 		wprocessor:  config.WorkspaceProcessor,
+		tprocessor:  config.TrackerProcessor,
+		maxInflight: config.MaxInflightCycles,
 		includeTags: config.IncludeTags,
 		excludeTags: config.ExcludeTags,
+		status:      &driftDetectorStatus{},
+		trackers:    &sync.WaitGroup{},
 	}, nil
 }
 
+// Status returns the current status of the drift detector.
+func (d DriftDetector) Status() DriftDetectorStatus {
+	d.status.mu.RLock()
+	defer d.status.mu.RUnlock()
+
+	st := DriftDetectorStatus{
+		Schedule:       fmt.Sprint(d.schedule),
+		NextRun:        d.status.nextRun,
+		LastSuccessAt:  d.status.lastSuccessAt,
+		InflightCycles: d.status.inflight,
+	}
+	if d.status.lastCycle != nil {
+		c := *d.status.lastCycle
+		st.LastCycle = &c
+	}
+
+	return st
+}
+
+func (d DriftDetector) setNextRun(ctx context.Context, next time.Time) {
+	d.metrics.SetDriftDetectorNextRun(ctx, next)
+
+	d.status.mu.Lock()
+	d.status.nextRun = next
+	d.status.mu.Unlock()
+}
+
+// Run runs the drift detections on the schedule, if a drift detection takes longer than the next scheduled
+// runs, these will be skipped and counted as missed. With a tracker processor, the cycles only take the
+// time of creating the plans, the plans are tracked in the background while the next cycles run.
 func (d DriftDetector) Run(ctx context.Context) error {
-	t := time.NewTicker(d.interval)
+	// Wait for the background trackers, they will end as the context is done.
+	defer d.trackers.Wait()
 
-	// We run this once outside the loop so we don't wait for the first tick.
-	d.logger.Infof("Drift detection started")
-	err := d.run(ctx)
-	if err != nil {
-		d.logger.Errorf("Drift detection failed: %s", err)
-	} else {
-		d.logger.Infof("Drift detection finished")
+	next := d.schedule.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("schedule doesn't have next run")
 	}
+	d.setNextRun(ctx, next)
+	d.logger.WithValues(log.Kv{"schedule": fmt.Sprint(d.schedule), "next-run": next.Format(time.RFC3339), "missed-schedules": 0}).Infof("Drift detector started")
+
+	// We run this once outside the loop so we don't wait for the first scheduled run.
+	d.detect(ctx)
 
 	for {
+		next = d.nextRun(ctx, next)
+		if next.IsZero() {
+			return fmt.Errorf("schedule doesn't have next run")
+		}
+
+		t := time.NewTimer(time.Until(next))
 		select {
 		case <-ctx.Done():
+			t.Stop()
 			d.logger.Infof("Stopping controller...")
 			return ctx.Err()
 		case <-t.C:
-			d.logger.Infof("Drift detection started")
+			d.detect(ctx)
+			next = d.schedule.Next(next)
+		}
+	}
+}
 
-			err := d.run(ctx)
-			if err != nil {
-				d.logger.Errorf("Drift detection failed: %s", err)
-			} else {
-				d.logger.Infof("Drift detection finished")
-			}
+// nextRun returns the next scheduled run, skipping the ones that have been missed.
+func (d DriftDetector) nextRun(ctx context.Context, next time.Time) time.Time {
+	now := time.Now()
+	missed := 0
+	for !next.IsZero() && next.Before(now) {
+		missed++
+		next = d.schedule.Next(next)
+	}
+
+	logger := d.logger.WithValues(log.Kv{"next-run": next.Format(time.RFC3339), "missed-schedules": missed})
+	if missed > 0 {
+		d.metrics.AddDriftDetectorMissedSchedules(ctx, missed)
+		logger.Warningf("Drift detection took longer than the schedule, skipping missed schedules")
+	}
+	d.setNextRun(ctx, next)
+	logger.Debugf("Next drift detection scheduled")
+
+	return next
+}
+
+func (d DriftDetector) detect(ctx context.Context) {
+	d.logger.Infof("Drift detection started")
+
+	cycle := &Cycle{StartedAt: time.Now().UTC(), Processed: map[model.DriftState]int{}}
+
+	d.status.mu.Lock()
+	cycle.OverlappedCycles = d.status.inflight
+	d.status.mu.Unlock()
+	if cycle.OverlappedCycles > 0 {
+		d.metrics.AddDriftDetectorOverlappingCycles(ctx)
+		d.logger.WithValues(log.Kv{"inflight-cycles": cycle.OverlappedCycles}).Infof("Drift detection overlaps with previous cycles still tracking plans")
+	}
+
+	wks, unlock, err := d.run(ctx, cycle)
+	if err != nil || d.tprocessor == nil || len(wks) == 0 {
+		unlock()
+		d.finish(ctx, cycle, wks, err)
+		return
+	}
+
+	// Track the created plans in the background, the workspaces remain locked until tracked.
+	d.logger.Infof("%d workspaces drift detection plans handed to the background tracker", len(wks))
+	d.addInflight(ctx, 1)
+	d.trackers.Add(1)
+	go func() {
+		defer d.trackers.Done()
+		defer d.addInflight(ctx, -1)
+		defer unlock()
+
+		wks, err := d.tprocessor.Process(ctx, wks)
+		if err != nil {
+			err = fmt.Errorf("drift detection plans tracking failed: %w", err)
 		}
+		d.finish(ctx, cycle, wks, err)
+	}()
+}
+
+// finish finishes the drift detection cycle with the processed workspaces.
+func (d DriftDetector) finish(ctx context.Context, cycle *Cycle, wks []model.Workspace, err error) {
+	cycle.FinishedAt = time.Now().UTC()
+	if err != nil {
+		d.logger.Errorf("Drift detection failed: %s", err)
+		cycle.Err = err
+	} else {
+		for _, wk := range wks {
+			cycle.Processed[wk.DriftState()]++
+		}
+		d.logger.Infof("Drift detection finished")
+	}
+
+	// Skipped cycles (e.g: not the leader, blackouts) are not successful drift detections.
+	success := cycle.Result() == CycleResultSuccess
+	d.metrics.ObserveDriftDetectorCycle(ctx, cycle.Result(), cycle.FinishedAt.Sub(cycle.StartedAt))
+	if success {
+		d.metrics.SetDriftDetectorLastSuccess(ctx, cycle.FinishedAt)
+	}
+
+	d.status.mu.Lock()
+	defer d.status.mu.Unlock()
+
+	// With background trackers, cycles can finish out of order.
+	if d.status.lastCycle == nil || !cycle.StartedAt.Before(d.status.lastCycle.StartedAt) {
+		d.status.lastCycle = cycle
+	}
+	if success && cycle.FinishedAt.After(d.status.lastSuccessAt) {
+		d.status.lastSuccessAt = cycle.FinishedAt
 	}
 }
 
-func (d DriftDetector) run(ctx context.Context) error {
+func (d DriftDetector) addInflight(ctx context.Context, n int) {
+	d.status.mu.Lock()
+	d.status.inflight += n
+	inflight := d.status.inflight
+	d.status.mu.Unlock()
+
+	d.metrics.SetDriftDetectorInflightCycles(ctx, inflight)
+}
+
+// run runs the drift detection cycle, returns the processed workspaces and the function to unlock them.
+func (d DriftDetector) run(ctx context.Context, cycle *Cycle) ([]model.Workspace, func(), error) {
+	noop := func() {}
+
+	if d.leader != nil && !d.leader.IsLeader() {
+		d.logger.Infof("Not the leader, skipping drift detection")
+		cycle.Skip = CycleSkipNotLeader
+		cycle.SkippedReason = "not the leader"
+		return nil, noop, nil
+	}
+
+	if d.maxInflight > 0 && cycle.OverlappedCycles >= d.maxInflight {
+		d.logger.Warningf("Max inflight cycles reached, skipping drift detection")
+		cycle.Skip = CycleSkipMaxInflight
+		cycle.SkippedReason = fmt.Sprintf("%d cycles still tracking plans", cycle.OverlappedCycles)
+		return nil, noop, nil
+	}
+
+	blackouts := d.blackouts.Active(time.Now())
+	for _, b := range blackouts {
+		if !b.Scoped() {
+			d.logger.WithValues(log.Kv{"blackout": b.String()}).Infof("Blackout window active, skipping drift detection")
+			cycle.Skip = CycleSkipBlackout
+			cycle.SkippedReason = fmt.Sprintf("blackout window %q active", b.String())
+			return nil, noop, nil
+		}
+	}
+
 	wks, err := d.wkLister.ListWorkspaces(ctx, d.includeTags, d.excludeTags)
 	if err != nil {
-		return fmt.Errorf("could not list workspaces: %w", err)
+		return nil, noop, fmt.Errorf("could not list workspaces: %w", err)
+	}
+
+	if len(blackouts) > 0 {
+		wks = d.filterBlackouts(blackouts, wks)
 	}
 
+	unlock := noop
+	if d.locks != nil {
+		locked, busy := d.locks.TryLock(wks)
+		if len(busy) > 0 {
+			d.logger.Infof("%d workspaces ignored by running drift detections", len(busy))
+		}
+		wks = locked
+		unlock = func() { d.locks.Unlock(locked) }
+	}
+
+	cycle.Selected = len(wks)
 	if len(wks) == 0 {
 		d.logger.Warningf("0 workspaces selected")
-		return nil
+		return nil, unlock, nil
 	}
 
-	_, err = d.wprocessor.Process(ctx, wks)
+	processed, err := d.wprocessor.Process(ctx, wks)
 	if err != nil {
-		return fmt.Errorf("workspaces processing failed: %w", err)
+		unlock()
+		return nil, noop, fmt.Errorf("workspaces processing failed: %w", err)
 	}
 
-	return nil
+	// Only keep locked the processed workspaces.
+	if d.locks != nil {
+		d.locks.Unlock(notProcessed(wks, processed))
+		unlock = func() { d.locks.Unlock(processed) }
+	}
+
+	return processed, unlock, nil
+}
+
+// notProcessed returns the workspaces that are not on the processed ones.
+func notProcessed(wks, processed []model.Workspace) []model.Workspace {
+	names := map[string]struct{}{}
+	for _, wk := range processed {
+		names[wk.Name] = struct{}{}
+	}
+
+	notProcessed := []model.Workspace{}
+	for _, wk := range wks {
+		if _, ok := names[wk.Name]; !ok {
+			notProcessed = append(notProcessed, wk)
+		}
+	}
+
+	return notProcessed
+}
+
+// filterBlackouts removes the workspaces that match any of the scoped blackout windows.
+func (d DriftDetector) filterBlackouts(blackouts Blackouts, wks []model.Workspace) []model.Workspace {
+	newWks := []model.Workspace{}
+	for _, wk := range wks {
+		blackedOut := false
+		for _, b := range blackouts {
+			if b.Match(wk) {
+				d.logger.WithValues(log.Kv{"workspace": wk.Name, "blackout": b.String()}).Debugf("Blackout window active, ignoring workspace")
+				blackedOut = true
+				break
+			}
+		}
+
+		if !blackedOut {
+			newWks = append(newWks, wk)
+		}
+	}
+
+	if ignored := len(wks) - len(newWks); ignored > 0 {
+		d.logger.Infof("%d workspaces ignored by active blackout windows", ignored)
+	}
+
+	return newWks
 }
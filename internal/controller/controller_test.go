@@ -0,0 +1,169 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+func TestDriftDetectorStatus(t *testing.T) {
+	tests := map[string]struct {
+		leader           controller.Leader
+		wks              []model.Workspace
+		expSkippedReason string
+		expLastSuccess   bool
+		expResult        controller.CycleResult
+		expSelected      int
+		expProcessed     map[model.DriftState]int
+	}{
+		"A drift detection cycle should summarize the processed workspaces.": {
+			wks: []model.Workspace{
+				{Name: "wk-1", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedOK, HasChanges: true}},
+				{Name: "wk-2", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedOK}},
+				{Name: "wk-3", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedOK}},
+			},
+			expLastSuccess: true,
+			expResult:      controller.CycleResultSuccess,
+			expSelected:    3,
+			expProcessed: map[model.DriftState]int{
+				model.DriftStateDrift: 1,
+				model.DriftStateOK:    2,
+			},
+		},
+
+		"A drift detection cycle on a replica that is not the leader should be skipped.": {
+			leader:           testLeader(false),
+			wks:              []model.Workspace{{Name: "wk-1"}},
+			expSkippedReason: "not the leader",
+			expResult:        controller.CycleResultSkipped,
+			expProcessed:     map[model.DriftState]int{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			d, err := controller.NewDriftDetector(controller.DriftDetectorConfig{
+				Interval:           time.Hour,
+				Leader:             test.leader,
+				WorkspaceLister:    testWorkspaceLister(test.wks),
+				WorkspaceProcessor: process.NoopProcessor,
+			})
+			require.NoError(err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { _ = d.Run(ctx) }()
+
+			var st controller.DriftDetectorStatus
+			require.Eventually(func() bool {
+				st = d.Status()
+				return st.LastCycle != nil
+			}, time.Second, time.Millisecond)
+
+			assert.Equal("@every 1h0m0s", st.Schedule)
+			assert.False(st.NextRun.IsZero())
+			assert.NoError(st.LastCycle.Err)
+			if test.expLastSuccess {
+				assert.Equal(st.LastCycle.FinishedAt, st.LastSuccessAt)
+			} else {
+				assert.True(st.LastSuccessAt.IsZero())
+			}
+			assert.Equal(test.expSkippedReason, st.LastCycle.SkippedReason)
+			assert.Equal(test.expResult, st.LastCycle.Result())
+			assert.Equal(test.expSelected, st.LastCycle.Selected)
+			assert.Equal(test.expProcessed, st.LastCycle.Processed)
+		})
+	}
+}
+
+func TestDriftDetectorTracker(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	release := make(chan struct{})
+	tracker := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		<-release
+		return wks, nil
+	})
+
+	locks := controller.NewWorkspaceLocks()
+	d, err := controller.NewDriftDetector(controller.DriftDetectorConfig{
+		Interval:           5 * time.Millisecond,
+		Locks:              locks,
+		WorkspaceLister:    testWorkspaceLister([]model.Workspace{{Name: "wk-1"}}),
+		WorkspaceProcessor: process.NoopProcessor,
+		TrackerProcessor:   tracker,
+	})
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = d.Run(ctx)
+		close(done)
+	}()
+
+	// The next cycles should not be blocked by the plans being tracked, and as the tracked
+	// workspace remains locked, they will not select it.
+	require.Eventually(func() bool {
+		st := d.Status()
+		return st.InflightCycles == 1 && st.LastCycle != nil
+	}, time.Second, time.Millisecond)
+	_, busy := locks.TryLock([]model.Workspace{{Name: "wk-1"}})
+	assert.Len(busy, 1)
+
+	st := d.Status()
+	assert.Equal(0, st.LastCycle.Selected)
+	assert.Equal(1, st.LastCycle.OverlappedCycles)
+
+	// Tracking the plans should finish the cycles and unlock the workspaces.
+	close(release)
+	require.Eventually(func() bool {
+		return d.Status().InflightCycles == 0
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	locked, _ := locks.TryLock([]model.Workspace{{Name: "wk-1"}})
+	assert.Len(locked, 1)
+}
+
+func TestDriftDetectorMaxInflightCycles(t *testing.T) {
+	require := require.New(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	tracker := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		<-release
+		return wks, nil
+	})
+
+	d, err := controller.NewDriftDetector(controller.DriftDetectorConfig{
+		Interval:           5 * time.Millisecond,
+		WorkspaceLister:    testWorkspaceLister([]model.Workspace{{Name: "wk-1"}}),
+		WorkspaceProcessor: process.NoopProcessor,
+		TrackerProcessor:   tracker,
+		MaxInflightCycles:  2,
+	})
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = d.Run(ctx) }()
+
+	// Reaching the max inflight cycles should skip the next cycles.
+	require.Eventually(func() bool {
+		st := d.Status()
+		return st.InflightCycles == 2 && st.LastCycle != nil && st.LastCycle.SkippedReason == "2 cycles still tracking plans"
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/log"
+)
+
+// LeaderLock is the lock used to elect the leader between multiple controller replicas.
+type LeaderLock interface {
+	// Acquire acquires or renews the lock for the holder during the TTL, returns true if the holder has the lock.
+	Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error)
+	// Release releases the lock if the holder has it.
+	Release(ctx context.Context, holder string) error
+}
+
+//go:generate mockery --case underscore --output controllermock --outpkg controllermock --name LeaderLock
+
+// Leader knows if the controller replica is the leader.
+type Leader interface {
+	IsLeader() bool
+}
+
+type LeaderElectorConfig struct {
+	Logger log.Logger
+	Lock   LeaderLock
+	// ID is the identity of the controller replica.
+	ID string
+	// TTL is the duration of the leadership without renewals.
+	TTL time.Duration
+	// RenewInterval is the interval used to acquire or renew the leadership, must be lower than the TTL.
+	RenewInterval   time.Duration
+	MetricsRecorder MetricsRecorder
+}
+
+func (c *LeaderElectorConfig) defaults() error {
+	if c.Lock == nil {
+		return fmt.Errorf("lock is required")
+	}
+
+	if c.ID == "" {
+		return fmt.Errorf("ID is required")
+	}
+
+	if c.TTL == 0 {
+		c.TTL = 30 * time.Second
+	}
+
+	if c.RenewInterval == 0 {
+		c.RenewInterval = c.TTL / 3
+	}
+
+	if c.RenewInterval >= c.TTL {
+		return fmt.Errorf("renew interval must be lower than the TTL")
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = NoopMetricsRecorder
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "controller.LeaderElector", "leader-id": c.ID})
+
+	return nil
+}
+
+// LeaderElector elects a leader between multiple controller replicas using a lock. On any error
+// acquiring the lock the replica will stop being the leader to avoid multiple leaders.
+type LeaderElector struct {
+	logger        log.Logger
+	lock          LeaderLock
+	id            string
+	ttl           time.Duration
+	renewInterval time.Duration
+	metrics       MetricsRecorder
+	leader        atomic.Bool
+	mu            sync.Mutex
+	electErr      error
+}
+
+func NewLeaderElector(config LeaderElectorConfig) (*LeaderElector, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &LeaderElector{
+		logger:        config.Logger,
+		lock:          config.Lock,
+		id:            config.ID,
+		ttl:           config.TTL,
+		renewInterval: config.RenewInterval,
+		metrics:       config.MetricsRecorder,
+	}, nil
+}
+
+// Run runs the leader election until the context is done, then the leadership will be released.
+func (l *LeaderElector) Run(ctx context.Context) error {
+	t := time.NewTicker(l.renewInterval)
+	defer t.Stop()
+
+	l.logger.Infof("Leader election started")
+	l.elect(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			l.release()
+			return ctx.Err()
+		case <-t.C:
+			l.elect(ctx)
+		}
+	}
+}
+
+func (l *LeaderElector) elect(ctx context.Context) {
+	// Don't let a slow lock keep us as leaders after the TTL.
+	ctx, cancel := context.WithTimeout(ctx, l.ttl-l.renewInterval)
+	defer cancel()
+
+	leader, err := l.lock.Acquire(ctx, l.id, l.ttl)
+	if err != nil {
+		l.logger.Errorf("Could not acquire leader lock: %s", err)
+		leader = false
+	}
+
+	l.mu.Lock()
+	l.electErr = err
+	l.mu.Unlock()
+
+	l.setLeader(ctx, leader)
+}
+
+func (l *LeaderElector) release() {
+	if !l.leader.Load() {
+		return
+	}
+
+	// Parent context is done, use a new one to release the leadership.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := l.lock.Release(ctx, l.id)
+	if err != nil {
+		l.logger.Errorf("Could not release leader lock: %s", err)
+	}
+
+	l.setLeader(ctx, false)
+}
+
+func (l *LeaderElector) setLeader(ctx context.Context, leader bool) {
+	l.metrics.SetLeader(ctx, leader)
+
+	if l.leader.Swap(leader) == leader {
+		return
+	}
+
+	if leader {
+		l.logger.Infof("Leadership acquired")
+	} else {
+		l.logger.Warningf("Leadership lost")
+	}
+}
+
+// IsLeader returns true if the controller replica is the leader.
+func (l *LeaderElector) IsLeader() bool { return l.leader.Load() }
+
+// ID returns the identity of the controller replica.
+func (l *LeaderElector) ID() string { return l.id }
+
+// Err returns the error of the last leader election, nil if the lock could be acquired or checked.
+func (l *LeaderElector) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.electErr
+}
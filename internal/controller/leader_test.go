@@ -0,0 +1,85 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/controller/controllermock"
+	"github.com/slok/tfe-drift/internal/log"
+)
+
+func TestLeaderElector(t *testing.T) {
+	tests := map[string]struct {
+		mock      func(m *controllermock.LeaderLock)
+		expLeader bool
+		expErr    bool
+	}{
+		"Acquiring the lock should be the leader and release it when stopped.": {
+			mock: func(m *controllermock.LeaderLock) {
+				m.On("Acquire", mock.Anything, "test-id", time.Hour).Once().Return(true, nil)
+				m.On("Release", mock.Anything, "test-id").Once().Return(nil)
+			},
+			expLeader: true,
+		},
+
+		"Not acquiring the lock should not be the leader.": {
+			mock: func(m *controllermock.LeaderLock) {
+				m.On("Acquire", mock.Anything, "test-id", time.Hour).Once().Return(false, nil)
+			},
+			expLeader: false,
+		},
+
+		"Having an error acquiring the lock should not be the leader.": {
+			mock: func(m *controllermock.LeaderLock) {
+				m.On("Acquire", mock.Anything, "test-id", time.Hour).Once().Return(true, fmt.Errorf("something"))
+			},
+			expLeader: false,
+			expErr:    true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := controllermock.NewLeaderLock(t)
+			test.mock(m)
+
+			l, err := controller.NewLeaderElector(controller.LeaderElectorConfig{
+				Logger:        log.Noop,
+				Lock:          m,
+				ID:            "test-id",
+				TTL:           time.Hour,
+				RenewInterval: 30 * time.Minute,
+			})
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				_ = l.Run(ctx)
+				close(done)
+			}()
+
+			assert.Eventually(func() bool { return l.IsLeader() == test.expLeader }, time.Second, time.Millisecond)
+			time.Sleep(10 * time.Millisecond) // Give time to the first election.
+			assert.Equal(test.expLeader, l.IsLeader())
+			if test.expErr {
+				assert.Error(l.Err())
+			} else {
+				assert.NoError(l.Err())
+			}
+
+			cancel()
+			<-done
+			assert.False(l.IsLeader())
+		})
+	}
+}
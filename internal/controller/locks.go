@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+// WorkspaceLocks are used to not run drift detections concurrently on the same workspace
+// (e.g: scheduled and on-demand drift detections).
+type WorkspaceLocks struct {
+	mu     sync.Mutex
+	locked map[string]struct{}
+}
+
+func NewWorkspaceLocks() *WorkspaceLocks {
+	return &WorkspaceLocks{locked: map[string]struct{}{}}
+}
+
+// TryLock locks the workspaces that are not already locked, returns the locked ones and the
+// ones that were already locked (busy).
+func (l *WorkspaceLocks) TryLock(wks []model.Workspace) (locked, busy []model.Workspace) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, wk := range wks {
+		if _, ok := l.locked[wk.Name]; ok {
+			busy = append(busy, wk)
+			continue
+		}
+
+		l.locked[wk.Name] = struct{}{}
+		locked = append(locked, wk)
+	}
+
+	return locked, busy
+}
+
+// Unlock unlocks the workspaces.
+func (l *WorkspaceLocks) Unlock(wks []model.Workspace) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, wk := range wks {
+		delete(l.locked, wk.Name)
+	}
+}
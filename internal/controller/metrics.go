@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"context"
+	"time"
+)
+
+// MetricsRecorder knows how to record the controller metrics.
+type MetricsRecorder interface {
+	// SetDriftDetectorNextRun sets when the next drift detection will be run.
+	SetDriftDetectorNextRun(ctx context.Context, t time.Time)
+	// AddDriftDetectorMissedSchedules adds the drift detection schedules that have been missed
+	// because the previous drift detection was still running.
+	AddDriftDetectorMissedSchedules(ctx context.Context, n int)
+	// SetLeader sets if the controller replica is the leader.
+	SetLeader(ctx context.Context, leader bool)
+	// ObserveDriftDetectorCycle observes the duration of a drift detection cycle by its result.
+	ObserveDriftDetectorCycle(ctx context.Context, result CycleResult, duration time.Duration)
+	// SetDriftDetectorLastSuccess sets when the last successful drift detection cycle finished.
+	SetDriftDetectorLastSuccess(ctx context.Context, t time.Time)
+	// SetDriftDetectorInflightCycles sets the number of drift detection cycles tracking plans in the background.
+	SetDriftDetectorInflightCycles(ctx context.Context, n int)
+	// AddDriftDetectorOverlappingCycles adds a drift detection cycle that started while previous
+	// cycles were still tracking plans.
+	AddDriftDetectorOverlappingCycles(ctx context.Context)
+	// AddConfigReload adds a configuration reload by its result.
+	AddConfigReload(ctx context.Context, result ConfigReloadResult)
+	// SetConfigLastReloadSuccess sets when the configuration was reloaded successfully for the last time.
+	SetConfigLastReloadSuccess(ctx context.Context, t time.Time)
+}
+
+// NoopMetricsRecorder is a metrics recorder that doesn't record anything.
+var NoopMetricsRecorder MetricsRecorder = noopMetricsRecorder(0)
+
+type noopMetricsRecorder int
+
+func (noopMetricsRecorder) SetDriftDetectorNextRun(ctx context.Context, t time.Time)              {}
+func (noopMetricsRecorder) AddDriftDetectorMissedSchedules(ctx context.Context, n int)            {}
+func (noopMetricsRecorder) SetLeader(ctx context.Context, leader bool)                            {}
+func (noopMetricsRecorder) ObserveDriftDetectorCycle(context.Context, CycleResult, time.Duration) {}
+func (noopMetricsRecorder) SetDriftDetectorLastSuccess(ctx context.Context, t time.Time)          {}
+func (noopMetricsRecorder) SetDriftDetectorInflightCycles(ctx context.Context, n int)             {}
+func (noopMetricsRecorder) AddDriftDetectorOverlappingCycles(ctx context.Context)                 {}
+func (noopMetricsRecorder) AddConfigReload(context.Context, ConfigReloadResult)                   {}
+func (noopMetricsRecorder) SetConfigLastReloadSuccess(ctx context.Context, t time.Time)           {}
@@ -0,0 +1,365 @@
+package controller
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	wkprocess "github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+// DetectionRequest is an on-demand drift detection request.
+type DetectionRequest struct {
+	// Workspaces are the names of the workspaces to select.
+	Workspaces []string
+	// Tags are the tags that the workspaces must have to be selected.
+	Tags []string
+	// BypassNotBefore will ignore the not before filter so recent drift detections are run again.
+	BypassNotBefore bool
+}
+
+func (r DetectionRequest) validate() error {
+	if len(r.Workspaces) == 0 && len(r.Tags) == 0 {
+		return fmt.Errorf("workspaces or tags are required: %w", internalerrors.ErrNotValid)
+	}
+
+	return nil
+}
+
+// DetectionStatus is the status of an on-demand drift detection.
+type DetectionStatus string
+
+const (
+	DetectionStatusQueued   DetectionStatus = "queued"
+	DetectionStatusRunning  DetectionStatus = "running"
+	DetectionStatusFinished DetectionStatus = "finished"
+	DetectionStatusFailed   DetectionStatus = "failed"
+)
+
+// Detection is an on-demand drift detection.
+type Detection struct {
+	ID         string
+	Request    DetectionRequest
+	Status     DetectionStatus
+	Err        error
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	// NotFound are the requested workspace names that could not be selected.
+	NotFound []string
+	// Busy are the selected workspaces ignored because other drift detection was running on them.
+	Busy []model.Workspace
+	// Selected are the selected workspaces.
+	Selected []model.Workspace
+	// Processed are the workspaces processed by the drift detection.
+	Processed []model.Workspace
+}
+
+type OnDemandDetectorConfig struct {
+	Logger log.Logger
+	// Leader is used to only accept drift detections on the leader replica, if not set, they will always be accepted.
+	Leader Leader
+	// Locks are used to not run drift detections concurrently on the same workspaces of other detectors.
+	Locks              *WorkspaceLocks
+	WorkspaceLister    WorkspaceLister
+	WorkspaceProcessor wkprocess.Processor
+	// BypassNotBeforeWorkspaceProcessor is the processor used by the requests that bypass the not before filter.
+	BypassNotBeforeWorkspaceProcessor wkprocess.Processor
+	// TrackerProcessor is used to track the drift detection plans created by the workspace processor (e.g: wait
+	// for the plans and notify), only the workspaces processed by the workspace processor remain locked while
+	// tracked. If not set, the workspace processor result will be used.
+	TrackerProcessor wkprocess.Processor
+	IncludeTags      []string
+	ExcludeTags      []string
+	// MaxDetections is the number of drift detections that will be stored to be retrieved.
+	MaxDetections int
+	// MaxQueued is the number of drift detections that can be waiting to be run.
+	MaxQueued int
+}
+
+func (c *OnDemandDetectorConfig) defaults() error {
+	if c.Locks == nil {
+		c.Locks = NewWorkspaceLocks()
+	}
+
+	if c.WorkspaceLister == nil {
+		return fmt.Errorf("workspace lister is required")
+	}
+
+	if c.WorkspaceProcessor == nil {
+		return fmt.Errorf("workspace processor is required")
+	}
+
+	if c.BypassNotBeforeWorkspaceProcessor == nil {
+		c.BypassNotBeforeWorkspaceProcessor = c.WorkspaceProcessor
+	}
+
+	if c.MaxDetections == 0 {
+		c.MaxDetections = 100
+	}
+
+	if c.MaxQueued == 0 {
+		c.MaxQueued = 10
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "controller.OnDemandDetector"})
+
+	return nil
+}
+
+// OnDemandDetector runs drift detections on demand, the drift detections are run in background
+// and can be retrieved by their ID once triggered.
+type OnDemandDetector struct {
+	logger              log.Logger
+	leader              Leader
+	locks               *WorkspaceLocks
+	wkLister            WorkspaceLister
+	wprocessor          wkprocess.Processor
+	bypassNBWprocessor  wkprocess.Processor
+	tprocessor          wkprocess.Processor
+	includeTags         []string
+	excludeTags         []string
+	maxDetections       int
+	queue               chan string
+	mu                  sync.Mutex
+	detections          map[string]*Detection
+	detectionsByCreated []string
+}
+
+func NewOnDemandDetector(config OnDemandDetectorConfig) (*OnDemandDetector, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &OnDemandDetector{
+		logger:             config.Logger,
+		leader:             config.Leader,
+		locks:              config.Locks,
+		wkLister:           config.WorkspaceLister,
+		wprocessor:         config.WorkspaceProcessor,
+		bypassNBWprocessor: config.BypassNotBeforeWorkspaceProcessor,
+		tprocessor:         config.TrackerProcessor,
+		includeTags:        config.IncludeTags,
+		excludeTags:        config.ExcludeTags,
+		maxDetections:      config.MaxDetections,
+		queue:              make(chan string, config.MaxQueued),
+		detections:         map[string]*Detection{},
+	}, nil
+}
+
+// Trigger queues a drift detection, returns the queued drift detection.
+func (o *OnDemandDetector) Trigger(ctx context.Context, r DetectionRequest) (*Detection, error) {
+	err := r.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.leader != nil && !o.leader.IsLeader() {
+		return nil, fmt.Errorf("drift detections are only run on the leader: %w", internalerrors.ErrNotLeader)
+	}
+
+	id, err := newDetectionID()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Detection{
+		ID:        id,
+		Request:   r,
+		Status:    DetectionStatusQueued,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	select {
+	case o.queue <- id:
+	default:
+		return nil, fmt.Errorf("too many queued drift detections")
+	}
+	o.store(d)
+
+	o.logger.WithValues(log.Kv{"detection-id": id}).Infof("Drift detection triggered")
+
+	return copyDetection(d), nil
+}
+
+// Detection returns a drift detection by its ID.
+func (o *OnDemandDetector) Detection(id string) (*Detection, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	d, ok := o.detections[id]
+	if !ok {
+		return nil, fmt.Errorf("detection %q: %w", id, internalerrors.ErrNotExist)
+	}
+
+	return copyDetection(d), nil
+}
+
+// Run runs the queued drift detections until the context is done, the drift detections run concurrently
+// between them, the locks will take care of not running the same workspaces concurrently.
+func (o *OnDemandDetector) Run(ctx context.Context) error {
+	o.logger.Infof("On-demand drift detector started")
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case <-ctx.Done():
+			o.logger.Infof("Stopping on-demand drift detector...")
+			return ctx.Err()
+		case id := <-o.queue:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				o.detect(ctx, id)
+			}()
+		}
+	}
+}
+
+func (o *OnDemandDetector) detect(ctx context.Context, id string) {
+	logger := o.logger.WithValues(log.Kv{"detection-id": id})
+	logger.Infof("Drift detection started")
+
+	o.mu.Lock()
+	d := copyDetection(o.detections[id])
+	o.mu.Unlock()
+
+	d.Status = DetectionStatusRunning
+	o.update(d)
+
+	err := o.run(ctx, d)
+	d.FinishedAt = time.Now().UTC()
+	if err != nil {
+		logger.Errorf("Drift detection failed: %s", err)
+		d.Status = DetectionStatusFailed
+		d.Err = err
+	} else {
+		logger.Infof("Drift detection finished")
+		d.Status = DetectionStatusFinished
+	}
+	o.update(d)
+}
+
+func (o *OnDemandDetector) run(ctx context.Context, d *Detection) error {
+	includeTags := append(append([]string{}, o.includeTags...), d.Request.Tags...)
+	wks, err := o.wkLister.ListWorkspaces(ctx, includeTags, o.excludeTags)
+	if err != nil {
+		return fmt.Errorf("could not list workspaces: %w", err)
+	}
+
+	if len(d.Request.Workspaces) > 0 {
+		wks, d.NotFound = selectWorkspacesByName(wks, d.Request.Workspaces)
+	}
+
+	// The leadership could have been lost while the detection was queued.
+	if o.leader != nil && !o.leader.IsLeader() {
+		return fmt.Errorf("drift detections are only run on the leader: %w", internalerrors.ErrNotLeader)
+	}
+
+	locked, busy := o.locks.TryLock(wks)
+	d.Selected = locked
+	d.Busy = busy
+	o.update(d)
+
+	if len(locked) == 0 {
+		return nil
+	}
+
+	processor := o.wprocessor
+	if d.Request.BypassNotBefore {
+		processor = o.bypassNBWprocessor
+	}
+
+	processed, err := processor.Process(ctx, locked)
+	if err != nil {
+		o.locks.Unlock(locked)
+		return fmt.Errorf("workspaces processing failed: %w", err)
+	}
+
+	// Only keep locked the processed workspaces while tracked.
+	o.locks.Unlock(notProcessed(locked, processed))
+	defer o.locks.Unlock(processed)
+
+	if o.tprocessor != nil {
+		processed, err = o.tprocessor.Process(ctx, processed)
+		if err != nil {
+			return fmt.Errorf("drift detection plans tracking failed: %w", err)
+		}
+	}
+	d.Processed = processed
+
+	return nil
+}
+
+func selectWorkspacesByName(wks []model.Workspace, names []string) (selected []model.Workspace, notFound []string) {
+	byName := map[string]model.Workspace{}
+	for _, wk := range wks {
+		byName[wk.Name] = wk
+	}
+
+	for _, name := range names {
+		wk, ok := byName[name]
+		if !ok {
+			notFound = append(notFound, name)
+			continue
+		}
+		selected = append(selected, wk)
+	}
+
+	return selected, notFound
+}
+
+func (o *OnDemandDetector) update(d *Detection) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.detections[d.ID]; ok {
+		o.detections[d.ID] = copyDetection(d)
+	}
+}
+
+// store stores the detection removing the oldest finished detections if required. Must be called with the lock held.
+func (o *OnDemandDetector) store(d *Detection) {
+	o.detections[d.ID] = d
+	o.detectionsByCreated = append(o.detectionsByCreated, d.ID)
+
+	for i := 0; len(o.detections) > o.maxDetections && i < len(o.detectionsByCreated); {
+		id := o.detectionsByCreated[i]
+		status := o.detections[id].Status
+		if status != DetectionStatusFinished && status != DetectionStatusFailed {
+			i++
+			continue
+		}
+		delete(o.detections, id)
+		o.detectionsByCreated = append(o.detectionsByCreated[:i], o.detectionsByCreated[i+1:]...)
+	}
+}
+
+func copyDetection(d *Detection) *Detection {
+	c := *d
+	return &c
+}
+
+func newDetectionID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("could not generate detection ID: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
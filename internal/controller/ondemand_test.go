@@ -0,0 +1,263 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+type testWorkspaceLister []model.Workspace
+
+func (t testWorkspaceLister) ListWorkspaces(ctx context.Context, includeTags, excludeTags []string) ([]model.Workspace, error) {
+	return t, nil
+}
+
+type testWorkspaceListerFunc func(ctx context.Context) ([]model.Workspace, error)
+
+func (t testWorkspaceListerFunc) ListWorkspaces(ctx context.Context, includeTags, excludeTags []string) ([]model.Workspace, error) {
+	return t(ctx)
+}
+
+type testLeader bool
+
+func (t testLeader) IsLeader() bool { return bool(t) }
+
+type testLeaderFunc func() bool
+
+func (t testLeaderFunc) IsLeader() bool { return t() }
+
+func TestOnDemandDetector(t *testing.T) {
+	wks := testWorkspaceLister{{Name: "wk-1"}, {Name: "wk-2"}, {Name: "wk-3"}}
+	processorName := func(name string) process.Processor {
+		return process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+			res := []model.Workspace{}
+			for _, wk := range wks {
+				wk.ID = name
+				res = append(res, wk)
+			}
+			return res, nil
+		})
+	}
+
+	tests := map[string]struct {
+		leader       controller.Leader
+		lockedWks    []model.Workspace
+		request      controller.DetectionRequest
+		expErr       error
+		expDetection controller.Detection
+	}{
+		"A request without workspaces nor tags should fail.": {
+			request: controller.DetectionRequest{},
+			expErr:  internalerrors.ErrNotValid,
+		},
+
+		"A request on a replica that is not the leader should fail.": {
+			leader:  testLeader(false),
+			request: controller.DetectionRequest{Workspaces: []string{"wk-1"}},
+			expErr:  internalerrors.ErrNotLeader,
+		},
+
+		"A request with workspaces should process the selected workspaces.": {
+			leader:  testLeader(true),
+			request: controller.DetectionRequest{Workspaces: []string{"wk-1", "wk-3", "wk-4"}},
+			expDetection: controller.Detection{
+				Status:    controller.DetectionStatusFinished,
+				NotFound:  []string{"wk-4"},
+				Selected:  []model.Workspace{{Name: "wk-1"}, {Name: "wk-3"}},
+				Processed: []model.Workspace{{Name: "wk-1", ID: "regular"}, {Name: "wk-3", ID: "regular"}},
+			},
+		},
+
+		"A request with tags should process all the listed workspaces.": {
+			request: controller.DetectionRequest{Tags: []string{"t1"}},
+			expDetection: controller.Detection{
+				Status:    controller.DetectionStatusFinished,
+				Selected:  []model.Workspace{{Name: "wk-1"}, {Name: "wk-2"}, {Name: "wk-3"}},
+				Processed: []model.Workspace{{Name: "wk-1", ID: "regular"}, {Name: "wk-2", ID: "regular"}, {Name: "wk-3", ID: "regular"}},
+			},
+		},
+
+		"A request bypassing not before should use the bypass processor.": {
+			request: controller.DetectionRequest{Workspaces: []string{"wk-2"}, BypassNotBefore: true},
+			expDetection: controller.Detection{
+				Status:    controller.DetectionStatusFinished,
+				Selected:  []model.Workspace{{Name: "wk-2"}},
+				Processed: []model.Workspace{{Name: "wk-2", ID: "bypass"}},
+			},
+		},
+
+		"A request with workspaces being processed by other detections should ignore them.": {
+			lockedWks: []model.Workspace{{Name: "wk-1"}},
+			request:   controller.DetectionRequest{Workspaces: []string{"wk-1", "wk-2"}},
+			expDetection: controller.Detection{
+				Status:    controller.DetectionStatusFinished,
+				Busy:      []model.Workspace{{Name: "wk-1"}},
+				Selected:  []model.Workspace{{Name: "wk-2"}},
+				Processed: []model.Workspace{{Name: "wk-2", ID: "regular"}},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			locks := controller.NewWorkspaceLocks()
+			locks.TryLock(test.lockedWks)
+
+			d, err := controller.NewOnDemandDetector(controller.OnDemandDetectorConfig{
+				Leader:                            test.leader,
+				Locks:                             locks,
+				WorkspaceLister:                   wks,
+				WorkspaceProcessor:                processorName("regular"),
+				BypassNotBeforeWorkspaceProcessor: processorName("bypass"),
+			})
+			require.NoError(err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go func() { _ = d.Run(ctx) }()
+
+			det, err := d.Trigger(ctx, test.request)
+			if test.expErr != nil {
+				assert.True(errors.Is(err, test.expErr))
+				return
+			}
+			require.NoError(err)
+			assert.NotEmpty(det.ID)
+
+			var got *controller.Detection
+			require.Eventually(func() bool {
+				got, err = d.Detection(det.ID)
+				require.NoError(err)
+				return got.Status == controller.DetectionStatusFinished || got.Status == controller.DetectionStatusFailed
+			}, time.Second, time.Millisecond)
+
+			// Normalize.
+			test.expDetection.ID = det.ID
+			test.expDetection.Request = test.request
+			test.expDetection.CreatedAt = got.CreatedAt
+			test.expDetection.FinishedAt = got.FinishedAt
+			assert.Equal(test.expDetection, *got)
+
+			// Locks should have been released.
+			locked, _ := locks.TryLock(got.Selected)
+			assert.Equal(got.Selected, locked)
+		})
+	}
+}
+
+func TestOnDemandDetectorTracksOnlyProcessedWorkspacesLocked(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	wks := testWorkspaceLister{{Name: "wk-1"}, {Name: "wk-2"}, {Name: "wk-3"}}
+	locks := controller.NewWorkspaceLocks()
+
+	// Only plan the first workspace (e.g: limited plans).
+	processor := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		return wks[:1], nil
+	})
+
+	var trackedLocked, trackedBusy []model.Workspace
+	tracker := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		trackedLocked, trackedBusy = locks.TryLock([]model.Workspace{{Name: "wk-1"}, {Name: "wk-2"}, {Name: "wk-3"}})
+		locks.Unlock(trackedLocked)
+
+		res := []model.Workspace{}
+		for _, wk := range wks {
+			wk.ID = "tracked"
+			res = append(res, wk)
+		}
+		return res, nil
+	})
+
+	d, err := controller.NewOnDemandDetector(controller.OnDemandDetectorConfig{
+		Locks:              locks,
+		WorkspaceLister:    wks,
+		WorkspaceProcessor: processor,
+		TrackerProcessor:   tracker,
+	})
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = d.Run(ctx) }()
+
+	det, err := d.Trigger(ctx, controller.DetectionRequest{Tags: []string{"t1"}})
+	require.NoError(err)
+
+	var got *controller.Detection
+	require.Eventually(func() bool {
+		got, err = d.Detection(det.ID)
+		require.NoError(err)
+		return got.Status == controller.DetectionStatusFinished || got.Status == controller.DetectionStatusFailed
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(controller.DetectionStatusFinished, got.Status)
+	assert.Equal([]model.Workspace{{Name: "wk-1", ID: "tracked"}}, got.Processed)
+
+	// While tracked, only the processed workspaces should be locked.
+	assert.Equal([]model.Workspace{{Name: "wk-2"}, {Name: "wk-3"}}, trackedLocked)
+	assert.Equal([]model.Workspace{{Name: "wk-1"}}, trackedBusy)
+
+	// Locks should have been released.
+	locked, _ := locks.TryLock(got.Selected)
+	assert.Equal(got.Selected, locked)
+}
+
+func TestOnDemandDetectorLeadershipLost(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	// Lose the leadership after accepting the detection.
+	var leader atomic.Bool
+	leader.Store(true)
+	lister := testWorkspaceListerFunc(func(ctx context.Context) ([]model.Workspace, error) {
+		leader.Store(false)
+		return []model.Workspace{{Name: "wk-1"}}, nil
+	})
+
+	locks := controller.NewWorkspaceLocks()
+	processed := false
+	d, err := controller.NewOnDemandDetector(controller.OnDemandDetectorConfig{
+		Leader:          testLeaderFunc(leader.Load),
+		Locks:           locks,
+		WorkspaceLister: lister,
+		WorkspaceProcessor: process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+			processed = true
+			return wks, nil
+		}),
+	})
+	require.NoError(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = d.Run(ctx) }()
+
+	det, err := d.Trigger(ctx, controller.DetectionRequest{Workspaces: []string{"wk-1"}})
+	require.NoError(err)
+
+	var got *controller.Detection
+	require.Eventually(func() bool {
+		got, err = d.Detection(det.ID)
+		require.NoError(err)
+		return got.Status == controller.DetectionStatusFinished || got.Status == controller.DetectionStatusFailed
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(controller.DetectionStatusFailed, got.Status)
+	assert.ErrorIs(got.Err, internalerrors.ErrNotLeader)
+	assert.False(processed)
+	assert.Empty(got.Selected)
+}
@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ComponentReadiness is the readiness of a controller component.
+type ComponentReadiness struct {
+	Ready bool
+	// Reason is why the component is not ready, empty if ready.
+	Reason string
+	// Details is extra information of the component state.
+	Details map[string]any
+}
+
+// ReadinessCheck knows how to check if a controller component is ready.
+type ReadinessCheck interface {
+	Check(ctx context.Context) ComponentReadiness
+}
+
+// ReadinessCheckFunc is a helper to create readiness checks from functions.
+type ReadinessCheckFunc func(ctx context.Context) ComponentReadiness
+
+func (r ReadinessCheckFunc) Check(ctx context.Context) ComponentReadiness { return r(ctx) }
+
+// ReadinessStatus is the readiness of the controller.
+type ReadinessStatus struct {
+	// Ready is true when all the components are ready.
+	Ready      bool
+	Components map[string]ComponentReadiness
+}
+
+type ReadinessConfig struct {
+	// Checks are the readiness checks of the controller components by name.
+	Checks map[string]ReadinessCheck
+	// Timeout is the max duration of the readiness checks, on timeout the component will not be ready.
+	Timeout time.Duration
+}
+
+func (c *ReadinessConfig) defaults() error {
+	if c.Checks == nil {
+		c.Checks = map[string]ReadinessCheck{}
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = 5 * time.Second
+	}
+
+	return nil
+}
+
+// Readiness checks the readiness of the controller components.
+type Readiness struct {
+	checks  map[string]ReadinessCheck
+	timeout time.Duration
+}
+
+func NewReadiness(config ReadinessConfig) (*Readiness, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Readiness{
+		checks:  config.Checks,
+		timeout: config.Timeout,
+	}, nil
+}
+
+// Check runs all the component readiness checks concurrently.
+func (r *Readiness) Check(ctx context.Context) ReadinessStatus {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	status := ReadinessStatus{Ready: true, Components: map[string]ComponentReadiness{}}
+	for name, check := range r.checks {
+		wg.Add(1)
+		go func(name string, check ReadinessCheck) {
+			defer wg.Done()
+
+			resC := make(chan ComponentReadiness, 1)
+			go func() { resC <- check.Check(ctx) }()
+
+			var res ComponentReadiness
+			select {
+			case <-ctx.Done():
+				res = ComponentReadiness{Reason: fmt.Sprintf("readiness check timed out: %s", ctx.Err())}
+			case res = <-resC:
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			status.Components[name] = res
+			status.Ready = status.Ready && res.Ready
+		}(name, check)
+	}
+	wg.Wait()
+
+	return status
+}
+
+// Pinger knows how to check the connectivity with a service.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// NewConnectivityReadinessCheck returns a readiness check that is ready when the pinger succeeds, the
+// result is reused during the cache TTL so frequent probes don't hammer the service.
+func NewConnectivityReadinessCheck(p Pinger, cacheTTL time.Duration) ReadinessCheck {
+	var mu sync.Mutex
+	var checkedAt time.Time
+	var lastErr error
+
+	return ReadinessCheckFunc(func(ctx context.Context) ComponentReadiness {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if checkedAt.IsZero() || time.Since(checkedAt) >= cacheTTL {
+			lastErr = p.Ping(ctx)
+			checkedAt = time.Now().UTC()
+		}
+
+		res := ComponentReadiness{Ready: lastErr == nil, Details: map[string]any{"checked_at": checkedAt}}
+		if lastErr != nil {
+			res.Reason = fmt.Sprintf("could not connect: %s", lastErr)
+		}
+
+		return res
+	})
+}
+
+// DriftDetectorStatusGetter knows how to get the drift detector status.
+type DriftDetectorStatusGetter interface {
+	Status() DriftDetectorStatus
+}
+
+// NewDriftDetectorReadinessCheck returns a readiness check that is ready while the last successful drift
+// detection cycle is not older than the max age, until the first one, the check creation is used instead.
+// While the drift detection cycles are skipped on purpose (e.g: not the leader, blackout windows, max
+// inflight cycles) the replica is ready.
+func NewDriftDetectorReadinessCheck(d DriftDetectorStatusGetter, maxAge time.Duration) ReadinessCheck {
+	startedAt := time.Now()
+
+	return ReadinessCheckFunc(func(_ context.Context) ComponentReadiness {
+		st := d.Status()
+
+		res := ComponentReadiness{Ready: true, Details: map[string]any{}}
+		since := startedAt
+		if !st.LastSuccessAt.IsZero() {
+			since = st.LastSuccessAt
+			res.Details["last_success_at"] = st.LastSuccessAt
+		}
+		if st.LastCycle != nil && st.LastCycle.Err != nil {
+			res.Details["last_error"] = st.LastCycle.Err.Error()
+		}
+
+		if st.LastCycle != nil && st.LastCycle.Skip != "" {
+			res.Details["skipped"] = string(st.LastCycle.Skip)
+			res.Details["skipped_reason"] = st.LastCycle.SkippedReason
+			return res
+		}
+
+		if age := time.Since(since); age > maxAge {
+			res.Ready = false
+			res.Reason = fmt.Sprintf("no successful drift detection in %s (max %s)", age.Round(time.Second), maxAge)
+		}
+
+		return res
+	})
+}
+
+// Refresher knows when it was refreshed successfully for the last time.
+type Refresher interface {
+	LastRefresh() time.Time
+}
+
+// NewRefreshReadinessCheck returns a readiness check that is ready while the last successful refresh is
+// not older than the max age.
+func NewRefreshReadinessCheck(r Refresher, maxAge time.Duration) ReadinessCheck {
+	return ReadinessCheckFunc(func(_ context.Context) ComponentReadiness {
+		last := r.LastRefresh()
+		if last.IsZero() {
+			return ComponentReadiness{Reason: "never refreshed", Details: map[string]any{}}
+		}
+
+		res := ComponentReadiness{Ready: true, Details: map[string]any{"last_refresh_at": last}}
+		if age := time.Since(last); age > maxAge {
+			res.Ready = false
+			res.Reason = fmt.Sprintf("not refreshed in %s (max %s)", age.Round(time.Second), maxAge)
+		}
+
+		return res
+	})
+}
+
+// LeaderElection knows the state of the leader election.
+type LeaderElection interface {
+	Leader
+	ID() string
+	Err() error
+}
+
+// NewLeaderReadinessCheck returns a readiness check that is ready while the leader election works, not
+// being the leader is a valid state of a ready replica.
+func NewLeaderReadinessCheck(l LeaderElection) ReadinessCheck {
+	return ReadinessCheckFunc(func(_ context.Context) ComponentReadiness {
+		res := ComponentReadiness{Ready: true, Details: map[string]any{"id": l.ID(), "leader": l.IsLeader()}}
+		if err := l.Err(); err != nil {
+			res.Ready = false
+			res.Reason = fmt.Sprintf("leader election failed: %s", err)
+		}
+
+		return res
+	})
+}
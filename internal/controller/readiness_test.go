@@ -0,0 +1,231 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+)
+
+type testPinger struct {
+	err   error
+	calls int
+}
+
+func (t *testPinger) Ping(ctx context.Context) error {
+	t.calls++
+	return t.err
+}
+
+type testDriftDetectorStatus controller.DriftDetectorStatus
+
+func (t testDriftDetectorStatus) Status() controller.DriftDetectorStatus {
+	return controller.DriftDetectorStatus(t)
+}
+
+type testRefresher time.Time
+
+func (t testRefresher) LastRefresh() time.Time { return time.Time(t) }
+
+type testLeaderElection struct {
+	leader bool
+	err    error
+}
+
+func (t testLeaderElection) IsLeader() bool { return t.leader }
+func (t testLeaderElection) ID() string     { return "test-id" }
+func (t testLeaderElection) Err() error     { return t.err }
+
+func TestReadinessChecks(t *testing.T) {
+	now := time.Now().UTC()
+
+	tests := map[string]struct {
+		check     controller.ReadinessCheck
+		expReady  bool
+		expReason string
+		expDetail map[string]any
+	}{
+		"Connectivity without errors should be ready.": {
+			check:    controller.NewConnectivityReadinessCheck(&testPinger{}, time.Minute),
+			expReady: true,
+		},
+
+		"Connectivity with errors should not be ready.": {
+			check:     controller.NewConnectivityReadinessCheck(&testPinger{err: fmt.Errorf("something")}, time.Minute),
+			expReason: "could not connect: something",
+		},
+
+		"A drift detector without successful cycles should be ready until the max age.": {
+			check:     controller.NewDriftDetectorReadinessCheck(testDriftDetectorStatus{}, time.Hour),
+			expReady:  true,
+			expDetail: map[string]any{},
+		},
+
+		"A drift detector with a recent successful cycle should be ready.": {
+			check: controller.NewDriftDetectorReadinessCheck(testDriftDetectorStatus{
+				LastSuccessAt: now.Add(-30 * time.Minute),
+			}, time.Hour),
+			expReady:  true,
+			expDetail: map[string]any{"last_success_at": now.Add(-30 * time.Minute)},
+		},
+
+		"A drift detector with an old successful cycle should not be ready.": {
+			check: controller.NewDriftDetectorReadinessCheck(testDriftDetectorStatus{
+				LastSuccessAt: now.Add(-2 * time.Hour),
+				LastCycle:     &controller.Cycle{Err: fmt.Errorf("something")},
+			}, time.Hour),
+			expReason: "no successful drift detection in 2h0m0s (max 1h0m0s)",
+			expDetail: map[string]any{"last_success_at": now.Add(-2 * time.Hour), "last_error": "something"},
+		},
+
+		"A drift detector with an old successful cycle that is not the leader should be ready.": {
+			check: controller.NewDriftDetectorReadinessCheck(testDriftDetectorStatus{
+				LastSuccessAt: now.Add(-2 * time.Hour),
+				LastCycle:     &controller.Cycle{Skip: controller.CycleSkipNotLeader, SkippedReason: "not the leader"},
+			}, time.Hour),
+			expReady:  true,
+			expDetail: map[string]any{"last_success_at": now.Add(-2 * time.Hour), "skipped": "not_leader", "skipped_reason": "not the leader"},
+		},
+
+		"A drift detector with an old successful cycle on a blackout window should be ready.": {
+			check: controller.NewDriftDetectorReadinessCheck(testDriftDetectorStatus{
+				LastSuccessAt: now.Add(-2 * time.Hour),
+				LastCycle:     &controller.Cycle{Skip: controller.CycleSkipBlackout, SkippedReason: `blackout window "*" active`},
+			}, time.Hour),
+			expReady:  true,
+			expDetail: map[string]any{"last_success_at": now.Add(-2 * time.Hour), "skipped": "blackout", "skipped_reason": `blackout window "*" active`},
+		},
+
+		"A drift detector with an old successful cycle and max inflight cycles should be ready.": {
+			check: controller.NewDriftDetectorReadinessCheck(testDriftDetectorStatus{
+				LastSuccessAt: now.Add(-2 * time.Hour),
+				LastCycle:     &controller.Cycle{Skip: controller.CycleSkipMaxInflight, SkippedReason: "10 cycles still tracking plans"},
+			}, time.Hour),
+			expReady:  true,
+			expDetail: map[string]any{"last_success_at": now.Add(-2 * time.Hour), "skipped": "max_inflight", "skipped_reason": "10 cycles still tracking plans"},
+		},
+
+		"A recent refresh should be ready.": {
+			check:     controller.NewRefreshReadinessCheck(testRefresher(now.Add(-time.Minute)), time.Hour),
+			expReady:  true,
+			expDetail: map[string]any{"last_refresh_at": now.Add(-time.Minute)},
+		},
+
+		"An old refresh should not be ready.": {
+			check:     controller.NewRefreshReadinessCheck(testRefresher(now.Add(-2*time.Hour)), time.Hour),
+			expReason: "not refreshed in 2h0m0s (max 1h0m0s)",
+			expDetail: map[string]any{"last_refresh_at": now.Add(-2 * time.Hour)},
+		},
+
+		"Without refreshes it should not be ready.": {
+			check:     controller.NewRefreshReadinessCheck(testRefresher{}, time.Hour),
+			expReason: "never refreshed",
+			expDetail: map[string]any{},
+		},
+
+		"A replica that is not the leader should be ready.": {
+			check:     controller.NewLeaderReadinessCheck(testLeaderElection{leader: false}),
+			expReady:  true,
+			expDetail: map[string]any{"id": "test-id", "leader": false},
+		},
+
+		"A failing leader election should not be ready.": {
+			check:     controller.NewLeaderReadinessCheck(testLeaderElection{err: fmt.Errorf("something")}),
+			expReason: "leader election failed: something",
+			expDetail: map[string]any{"id": "test-id", "leader": false},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			res := test.check.Check(context.TODO())
+
+			assert.Equal(test.expReady, res.Ready)
+			assert.Equal(test.expReason, res.Reason)
+			if test.expDetail != nil {
+				assert.Equal(test.expDetail, res.Details)
+			}
+		})
+	}
+}
+
+func TestConnectivityReadinessCheckCache(t *testing.T) {
+	assert := assert.New(t)
+
+	p := &testPinger{}
+	check := controller.NewConnectivityReadinessCheck(p, time.Hour)
+	for i := 0; i < 3; i++ {
+		_ = check.Check(context.TODO())
+	}
+
+	assert.Equal(1, p.calls)
+}
+
+func TestReadiness(t *testing.T) {
+	ready := controller.ReadinessCheckFunc(func(_ context.Context) controller.ComponentReadiness {
+		return controller.ComponentReadiness{Ready: true}
+	})
+	notReady := controller.ReadinessCheckFunc(func(_ context.Context) controller.ComponentReadiness {
+		return controller.ComponentReadiness{Reason: "something"}
+	})
+	release := make(chan struct{})
+	defer close(release)
+	blocked := controller.ReadinessCheckFunc(func(_ context.Context) controller.ComponentReadiness {
+		<-release
+		return controller.ComponentReadiness{Ready: true}
+	})
+
+	tests := map[string]struct {
+		checks    map[string]controller.ReadinessCheck
+		expStatus controller.ReadinessStatus
+	}{
+		"Without checks it should be ready.": {
+			expStatus: controller.ReadinessStatus{Ready: true, Components: map[string]controller.ComponentReadiness{}},
+		},
+
+		"With all the components ready it should be ready.": {
+			checks: map[string]controller.ReadinessCheck{"c1": ready, "c2": ready},
+			expStatus: controller.ReadinessStatus{Ready: true, Components: map[string]controller.ComponentReadiness{
+				"c1": {Ready: true},
+				"c2": {Ready: true},
+			}},
+		},
+
+		"With any component not ready it should not be ready and detail the failing component.": {
+			checks: map[string]controller.ReadinessCheck{"c1": ready, "c2": notReady},
+			expStatus: controller.ReadinessStatus{Ready: false, Components: map[string]controller.ComponentReadiness{
+				"c1": {Ready: true},
+				"c2": {Reason: "something"},
+			}},
+		},
+
+		"A check that times out should not be ready.": {
+			checks: map[string]controller.ReadinessCheck{"c1": blocked},
+			expStatus: controller.ReadinessStatus{Ready: false, Components: map[string]controller.ComponentReadiness{
+				"c1": {Reason: "readiness check timed out: context deadline exceeded"},
+			}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			r, err := controller.NewReadiness(controller.ReadinessConfig{
+				Checks:  test.checks,
+				Timeout: 10 * time.Millisecond,
+			})
+			require.NoError(err)
+
+			assert.Equal(test.expStatus, r.Check(context.TODO()))
+		})
+	}
+}
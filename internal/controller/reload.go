@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/log"
+)
+
+// ConfigReloadResult is the result of a configuration reload.
+type ConfigReloadResult string
+
+const (
+	ConfigReloadResultSuccess ConfigReloadResult = "success"
+	ConfigReloadResultError   ConfigReloadResult = "error"
+	// ConfigReloadResultRestartRequired is the result of the reloads with changes that can't be reloaded,
+	// the configuration is not applied until the controller is restarted.
+	ConfigReloadResultRestartRequired ConfigReloadResult = "restart_required"
+)
+
+// ConfigLoader knows how to load the configuration again and apply it. When some of the changes
+// can't be applied, it should not apply any of them and return an internalerrors.ErrRestartRequired error.
+type ConfigLoader interface {
+	Load(ctx context.Context) error
+}
+
+// ConfigLoaderFunc is a helper to create config loaders from functions.
+type ConfigLoaderFunc func(ctx context.Context) error
+
+func (c ConfigLoaderFunc) Load(ctx context.Context) error { return c(ctx) }
+
+type ReloaderConfig struct {
+	Logger          log.Logger
+	MetricsRecorder MetricsRecorder
+	ConfigLoader    ConfigLoader
+	// File is the configuration file watched for changes, if not set, it will not be watched.
+	File string
+	// WatchInterval is the interval used to check the configuration file changes.
+	WatchInterval time.Duration
+	// Signals will trigger a reload on every received signal (e.g: SIGHUP).
+	Signals <-chan os.Signal
+}
+
+func (c *ReloaderConfig) defaults() error {
+	if c.ConfigLoader == nil {
+		return fmt.Errorf("config loader is required")
+	}
+
+	if c.WatchInterval == 0 {
+		c.WatchInterval = 10 * time.Second
+	}
+
+	if c.MetricsRecorder == nil {
+		c.MetricsRecorder = NoopMetricsRecorder
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "controller.Reloader"})
+
+	return nil
+}
+
+// Reloader reloads the configuration when signaled or when the configuration file changes, the
+// configuration file is watched by content so replaced files (e.g: Kubernetes configmaps) are detected.
+type Reloader struct {
+	logger        log.Logger
+	metrics       MetricsRecorder
+	loader        ConfigLoader
+	file          string
+	watchInterval time.Duration
+	signals       <-chan os.Signal
+}
+
+func NewReloader(config ReloaderConfig) (*Reloader, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Reloader{
+		logger:        config.Logger,
+		metrics:       config.MetricsRecorder,
+		loader:        config.ConfigLoader,
+		file:          config.File,
+		watchInterval: config.WatchInterval,
+		signals:       config.Signals,
+	}, nil
+}
+
+func (r Reloader) Run(ctx context.Context) error {
+	var watchC <-chan time.Time
+	var lastSum [sha256.Size]byte
+	if r.file != "" {
+		t := time.NewTicker(r.watchInterval)
+		defer t.Stop()
+		watchC = t.C
+
+		sum, err := r.fileSum()
+		if err != nil {
+			r.logger.Warningf("Could not read config file: %s", err)
+		}
+		lastSum = sum
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case s := <-r.signals:
+			// Don't reload again for the file changes already loaded.
+			if r.file != "" {
+				if sum, err := r.fileSum(); err == nil {
+					lastSum = sum
+				}
+			}
+			r.reload(ctx, fmt.Sprintf("signal %s", s))
+		case <-watchC:
+			sum, err := r.fileSum()
+			if err != nil {
+				r.logger.Warningf("Could not read config file: %s", err)
+				continue
+			}
+			if sum == lastSum {
+				continue
+			}
+			lastSum = sum
+			r.reload(ctx, "config file changed")
+		}
+	}
+}
+
+func (r Reloader) reload(ctx context.Context, reason string) {
+	logger := r.logger.WithValues(log.Kv{"reason": reason})
+	logger.Infof("Reloading configuration")
+
+	err := r.loader.Load(ctx)
+	switch {
+	case err == nil:
+		r.metrics.AddConfigReload(ctx, ConfigReloadResultSuccess)
+		r.metrics.SetConfigLastReloadSuccess(ctx, time.Now().UTC())
+		logger.Infof("Configuration reloaded")
+	case errors.Is(err, internalerrors.ErrRestartRequired):
+		r.metrics.AddConfigReload(ctx, ConfigReloadResultRestartRequired)
+		logger.Warningf("Configuration not reloaded, a restart is required to apply it: %s", err)
+	default:
+		r.metrics.AddConfigReload(ctx, ConfigReloadResultError)
+		logger.Errorf("Configuration reload failed, using the previous configuration: %s", err)
+	}
+}
+
+func (r Reloader) fileSum() ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(r.file)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+
+	return sha256.Sum256(data), nil
+}
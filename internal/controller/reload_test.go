@@ -0,0 +1,123 @@
+package controller_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/internalerrors"
+)
+
+type testReloadMetricsRecorder struct {
+	controller.MetricsRecorder
+	mu      sync.Mutex
+	results []controller.ConfigReloadResult
+}
+
+func (t *testReloadMetricsRecorder) AddConfigReload(_ context.Context, result controller.ConfigReloadResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.results = append(t.results, result)
+}
+
+func (t *testReloadMetricsRecorder) SetConfigLastReloadSuccess(context.Context, time.Time) {}
+
+func (t *testReloadMetricsRecorder) Results() []controller.ConfigReloadResult {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]controller.ConfigReloadResult{}, t.results...)
+}
+
+func TestReloader(t *testing.T) {
+	tests := map[string]struct {
+		loadErr    error
+		trigger    func(t *testing.T, file string, sigC chan os.Signal)
+		expResults []controller.ConfigReloadResult
+	}{
+		"A signal should reload the configuration.": {
+			trigger: func(t *testing.T, file string, sigC chan os.Signal) {
+				sigC <- syscall.SIGHUP
+			},
+			expResults: []controller.ConfigReloadResult{controller.ConfigReloadResultSuccess},
+		},
+
+		"A config file change should reload the configuration.": {
+			trigger: func(t *testing.T, file string, sigC chan os.Signal) {
+				err := os.WriteFile(file, []byte("changed"), 0o600)
+				require.NoError(t, err)
+			},
+			expResults: []controller.ConfigReloadResult{controller.ConfigReloadResultSuccess},
+		},
+
+		"Without config file changes it should not reload the configuration.": {
+			trigger: func(t *testing.T, file string, sigC chan os.Signal) {
+				err := os.WriteFile(file, []byte("initial"), 0o600)
+				require.NoError(t, err)
+			},
+			expResults: []controller.ConfigReloadResult{},
+		},
+
+		"A reload with changes that require a restart should be reported.": {
+			loadErr: fmt.Errorf("something: %w", internalerrors.ErrRestartRequired),
+			trigger: func(t *testing.T, file string, sigC chan os.Signal) {
+				sigC <- syscall.SIGHUP
+			},
+			expResults: []controller.ConfigReloadResult{controller.ConfigReloadResultRestartRequired},
+		},
+
+		"A failed reload should be reported.": {
+			loadErr: fmt.Errorf("something"),
+			trigger: func(t *testing.T, file string, sigC chan os.Signal) {
+				sigC <- syscall.SIGHUP
+			},
+			expResults: []controller.ConfigReloadResult{controller.ConfigReloadResultError},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			file := filepath.Join(t.TempDir(), "config.yaml")
+			err := os.WriteFile(file, []byte("initial"), 0o600)
+			require.NoError(err)
+
+			rec := &testReloadMetricsRecorder{MetricsRecorder: controller.NoopMetricsRecorder, results: []controller.ConfigReloadResult{}}
+			sigC := make(chan os.Signal)
+			r, err := controller.NewReloader(controller.ReloaderConfig{
+				MetricsRecorder: rec,
+				ConfigLoader:    controller.ConfigLoaderFunc(func(_ context.Context) error { return test.loadErr }),
+				File:            file,
+				WatchInterval:   5 * time.Millisecond,
+				Signals:         sigC,
+			})
+			require.NoError(err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_ = r.Run(ctx)
+			}()
+
+			// Let the reloader start watching the file.
+			time.Sleep(20 * time.Millisecond)
+			test.trigger(t, file, sigC)
+			time.Sleep(50 * time.Millisecond)
+			cancel()
+			<-done
+
+			assert.Equal(test.expResults, rec.Results())
+		})
+	}
+}
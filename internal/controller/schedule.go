@@ -0,0 +1,44 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule knows when the drift detections should be run.
+type Schedule interface {
+	// Next returns the next activation time after t, zero time if there isn't any.
+	Next(t time.Time) time.Time
+}
+
+// IntervalSchedule is a schedule that activates at regular intervals.
+type IntervalSchedule time.Duration
+
+func (i IntervalSchedule) Next(t time.Time) time.Time { return t.Add(time.Duration(i)) }
+func (i IntervalSchedule) String() string             { return "@every " + time.Duration(i).String() }
+
+// NewCronSchedule returns a schedule based on a standard cron expression (e.g: `0 * * * 1-5`,
+// `0 */6 * * 0,6`) or descriptor (e.g: `@hourly`) evaluated on the timezone.
+func NewCronSchedule(expr string, timezone *time.Location) (Schedule, error) {
+	if timezone == nil {
+		timezone = time.UTC
+	}
+
+	s, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+
+	return cronSchedule{expr: expr, s: s, timezone: timezone}, nil
+}
+
+type cronSchedule struct {
+	expr     string
+	s        cron.Schedule
+	timezone *time.Location
+}
+
+func (c cronSchedule) Next(t time.Time) time.Time { return c.s.Next(t.In(c.timezone)) }
+func (c cronSchedule) String() string             { return c.expr + " (" + c.timezone.String() + ")" }
@@ -0,0 +1,85 @@
+package controller_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+)
+
+func TestCronSchedule(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	t0, _ := time.Parse(time.RFC3339, "2023-01-13T10:30:00Z") // Friday.
+
+	tests := map[string]struct {
+		expr     string
+		timezone *time.Location
+		t        time.Time
+		expNext  time.Time
+		expErr   bool
+	}{
+		"An invalid expression should fail.": {
+			expr:   "* * *",
+			expErr: true,
+		},
+
+		"Hourly on weekdays should return the next hour.": {
+			expr:    "0 * * * 1-5",
+			t:       t0,
+			expNext: time.Date(2023, 1, 13, 11, 0, 0, 0, time.UTC),
+		},
+
+		"Hourly on weekdays should skip the weekend.": {
+			expr:    "0 * * * 1-5",
+			t:       time.Date(2023, 1, 13, 23, 30, 0, 0, time.UTC),
+			expNext: time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC),
+		},
+
+		"Every 6h on weekends should return the next weekend run.": {
+			expr:    "0 */6 * * 0,6",
+			t:       t0,
+			expNext: time.Date(2023, 1, 14, 0, 0, 0, 0, time.UTC),
+		},
+
+		"Descriptors should be supported.": {
+			expr:    "@daily",
+			t:       t0,
+			expNext: time.Date(2023, 1, 14, 0, 0, 0, 0, time.UTC),
+		},
+
+		"The timezone should be used to evaluate the expression.": {
+			expr:     "0 9 * * *",
+			timezone: madrid,
+			t:        t0,
+			expNext:  time.Date(2023, 1, 14, 8, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			s, err := controller.NewCronSchedule(test.expr, test.timezone)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(t, err)
+
+			gotNext := s.Next(test.t)
+			assert.True(test.expNext.Equal(gotNext), "expected %s, got %s", test.expNext, gotNext)
+		})
+	}
+}
+
+func TestIntervalSchedule(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2023-01-13T10:30:00Z")
+
+	s := controller.IntervalSchedule(5 * time.Minute)
+	assert.Equal(t, t0.Add(5*time.Minute), s.Next(t0))
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/log"
+	wkprocess "github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+type StateNotifierConfig struct {
+	Logger             log.Logger
+	Interval           time.Duration
+	WorkspaceLister    WorkspaceLister
+	WorkspaceProcessor wkprocess.Processor
+	IncludeTags        []string
+	ExcludeTags        []string
+	// RunOnStart will process the workspaces on start instead of waiting for the first interval.
+	RunOnStart bool
+}
+
+func (c *StateNotifierConfig) defaults() error {
+	if c.Interval == 0 {
+		return fmt.Errorf("interval can't be 0")
+	}
+
+	if c.WorkspaceLister == nil {
+		return fmt.Errorf("workspace lister is required")
+	}
+
+	if c.WorkspaceProcessor == nil {
+		return fmt.Errorf("workspace processor is required")
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "interval.StateNotifier"})
+
+	return nil
+}
+
+// StateNotifier will process the current state of the workspaces at regular intervals, normally used
+// to send notifications based on the latest drift detections (e.g digests, alerts...).
+//
+// Unlike the drift detector, by default it will wait for the first interval before processing, this way
+// a restart of the controller doesn't send a notification (e.g a digest).
+type StateNotifier struct {
+	logger      log.Logger
+	interval    time.Duration
+	wkLister    WorkspaceLister
+	wprocessor  wkprocess.Processor
+	includeTags []string
+	excludeTags []string
+	runOnStart  bool
+}
+
+func NewStateNotifier(config StateNotifierConfig) (*StateNotifier, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &StateNotifier{
+		logger:      config.Logger,
+		interval:    config.Interval,
+		wkLister:    config.WorkspaceLister,
+		wprocessor:  config.WorkspaceProcessor,
+		includeTags: config.IncludeTags,
+		excludeTags: config.ExcludeTags,
+		runOnStart:  config.RunOnStart,
+	}, nil
+}
+
+func (s StateNotifier) Run(ctx context.Context) error {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	if s.runOnStart {
+		s.notify(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.logger.Infof("Stopping state notifier...")
+			return ctx.Err()
+		case <-t.C:
+			s.notify(ctx)
+		}
+	}
+}
+
+func (s StateNotifier) notify(ctx context.Context) {
+	s.logger.Infof("State notification started")
+
+	err := s.run(ctx)
+	if err != nil {
+		s.logger.Errorf("State notification failed: %s", err)
+	} else {
+		s.logger.Infof("State notification finished")
+	}
+}
+
+func (s StateNotifier) run(ctx context.Context) error {
+	wks, err := s.wkLister.ListWorkspaces(ctx, s.includeTags, s.excludeTags)
+	if err != nil {
+		return fmt.Errorf("could not list workspaces: %w", err)
+	}
+
+	_, err = s.wprocessor.Process(ctx, wks)
+	if err != nil {
+		return fmt.Errorf("workspaces processing failed: %w", err)
+	}
+
+	return nil
+}
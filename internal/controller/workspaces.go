@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/model"
+	wkprocess "github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+// WorkspaceState is the latest known state of a workspace.
+type WorkspaceState struct {
+	Workspace model.Workspace
+	// CheckedAt is the last time the workspace state was retrieved.
+	CheckedAt time.Time
+}
+
+// WorkspaceStates are the latest known hydrated states of the workspaces, kept in memory so
+// they can be served without retrieving them from TFE.
+type WorkspaceStates struct {
+	mu     sync.RWMutex
+	states map[string]WorkspaceState
+	now    func() time.Time
+}
+
+func NewWorkspaceStates() *WorkspaceStates {
+	return &WorkspaceStates{
+		states: map[string]WorkspaceState{},
+		now:    time.Now,
+	}
+}
+
+// SetProcessor returns a processor that will replace all the workspace states with the processed
+// workspaces, must be used with processors that process all the workspaces (e.g: state refresh).
+func (w *WorkspaceStates) SetProcessor() wkprocess.Processor {
+	return wkprocess.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		now := w.now().UTC()
+		states := make(map[string]WorkspaceState, len(wks))
+		for _, wk := range wks {
+			states[wk.Name] = WorkspaceState{Workspace: wk, CheckedAt: now}
+		}
+
+		w.mu.Lock()
+		w.states = states
+		w.mu.Unlock()
+
+		return wks, nil
+	})
+}
+
+// RefreshProcessor returns a processor that will refresh the workspace states with the workspaces
+// processed by the p processor (e.g: hydrate), must be used with processors that process all the
+// workspaces. The refreshed states are checked when the refresh started, and the states are merged
+// by workspace, keeping the ones updated since the refresh started (e.g: drift detections).
+func (w *WorkspaceStates) RefreshProcessor(p wkprocess.Processor) wkprocess.Processor {
+	return wkprocess.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		refreshedAt := w.now().UTC()
+		wks, err := p.Process(ctx, wks)
+		if err != nil {
+			return nil, err
+		}
+
+		states := make(map[string]WorkspaceState, len(wks))
+		for _, wk := range wks {
+			states[wk.Name] = WorkspaceState{Workspace: wk, CheckedAt: refreshedAt}
+		}
+
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for name, s := range w.states {
+			if !s.CheckedAt.Before(refreshedAt) {
+				states[name] = s
+			}
+		}
+		w.states = states
+
+		return wks, nil
+	})
+}
+
+// UpdateProcessor returns a processor that will update the workspace states of the processed
+// workspaces (e.g: drift detections).
+func (w *WorkspaceStates) UpdateProcessor() wkprocess.Processor {
+	return wkprocess.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		now := w.now().UTC()
+
+		w.mu.Lock()
+		for _, wk := range wks {
+			w.states[wk.Name] = WorkspaceState{Workspace: wk, CheckedAt: now}
+		}
+		w.mu.Unlock()
+
+		return wks, nil
+	})
+}
+
+// List returns all the workspace states sorted by name.
+func (w *WorkspaceStates) List() []WorkspaceState {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	states := make([]WorkspaceState, 0, len(w.states))
+	for _, s := range w.states {
+		states = append(states, s)
+	}
+	sort.SliceStable(states, func(i, j int) bool { return states[i].Workspace.Name < states[j].Workspace.Name })
+
+	return states
+}
+
+// ListWorkspaces returns the workspaces of the states, filtered by tags like TFE does: having all the
+// include tags and none of the exclude tags. This way the states can be used as an in-memory
+// workspace lister that doesn't retrieve the workspaces from TFE.
+func (w *WorkspaceStates) ListWorkspaces(_ context.Context, includeTags, excludeTags []string) ([]model.Workspace, error) {
+	wks := []model.Workspace{}
+	for _, s := range w.List() {
+		if hasAllTags(s.Workspace, includeTags) && !hasAnyTag(s.Workspace, excludeTags) {
+			wks = append(wks, s.Workspace)
+		}
+	}
+
+	return wks, nil
+}
+
+func hasAllTags(wk model.Workspace, tags []string) bool {
+	for _, t := range tags {
+		if !slices.Contains(wk.Tags, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAnyTag(wk model.Workspace, tags []string) bool {
+	for _, t := range tags {
+		if slices.Contains(wk.Tags, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the workspace state by its name.
+func (w *WorkspaceStates) Get(name string) (WorkspaceState, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	s, ok := w.states[name]
+	return s, ok
+}
@@ -0,0 +1,161 @@
+package controller_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+func TestWorkspaceStates(t *testing.T) {
+	tests := map[string]struct {
+		set      []model.Workspace
+		update   []model.Workspace
+		expNames []string
+		expPlans map[string]string
+	}{
+		"Without states, it should be empty.": {
+			expNames: []string{},
+			expPlans: map[string]string{},
+		},
+
+		"Setting the states should return them sorted by name.": {
+			set:      []model.Workspace{{Name: "wk-2"}, {Name: "wk-1"}},
+			expNames: []string{"wk-1", "wk-2"},
+			expPlans: map[string]string{"wk-1": "", "wk-2": ""},
+		},
+
+		"Updating the states should only update the processed workspaces.": {
+			set:      []model.Workspace{{Name: "wk-1"}, {Name: "wk-2"}},
+			update:   []model.Workspace{{Name: "wk-2", LastDriftPlan: &model.Plan{ID: "p-2"}}, {Name: "wk-3", LastDriftPlan: &model.Plan{ID: "p-3"}}},
+			expNames: []string{"wk-1", "wk-2", "wk-3"},
+			expPlans: map[string]string{"wk-1": "", "wk-2": "p-2", "wk-3": "p-3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s := controller.NewWorkspaceStates()
+
+			// The old states should be replaced.
+			_, err := s.SetProcessor().Process(context.TODO(), []model.Workspace{{Name: "old"}})
+			require.NoError(err)
+
+			_, err = s.SetProcessor().Process(context.TODO(), test.set)
+			require.NoError(err)
+			_, err = s.UpdateProcessor().Process(context.TODO(), test.update)
+			require.NoError(err)
+
+			gotNames := []string{}
+			gotPlans := map[string]string{}
+			for _, st := range s.List() {
+				assert.False(st.CheckedAt.IsZero())
+				gotNames = append(gotNames, st.Workspace.Name)
+				gotPlans[st.Workspace.Name] = ""
+				if st.Workspace.LastDriftPlan != nil {
+					gotPlans[st.Workspace.Name] = st.Workspace.LastDriftPlan.ID
+				}
+			}
+			assert.Equal(test.expNames, gotNames)
+			assert.Equal(test.expPlans, gotPlans)
+
+			_, ok := s.Get("old")
+			assert.False(ok)
+		})
+	}
+}
+
+func TestWorkspaceStatesRefresh(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	s := controller.NewWorkspaceStates()
+	_, err := s.SetProcessor().Process(context.TODO(), []model.Workspace{{Name: "wk-1"}, {Name: "wk-2"}, {Name: "deleted"}})
+	require.NoError(err)
+
+	// A drift detection finishes while the refresh is hydrating the workspaces.
+	hydrate := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		_, err := s.UpdateProcessor().Process(ctx, []model.Workspace{{Name: "wk-2", LastDriftPlan: &model.Plan{ID: "p-2-new"}}})
+		require.NoError(err)
+
+		res := []model.Workspace{}
+		for _, wk := range wks {
+			wk.LastDriftPlan = &model.Plan{ID: "p-" + wk.Name[3:] + "-old"}
+			res = append(res, wk)
+		}
+		return res, nil
+	})
+
+	_, err = s.RefreshProcessor(hydrate).Process(context.TODO(), []model.Workspace{{Name: "wk-1"}, {Name: "wk-2"}})
+	require.NoError(err)
+
+	gotPlans := map[string]string{}
+	for _, st := range s.List() {
+		gotPlans[st.Workspace.Name] = st.Workspace.LastDriftPlan.ID
+	}
+
+	// The newer drift detection state should be kept and the not refreshed workspaces removed.
+	assert.Equal(map[string]string{"wk-1": "p-1-old", "wk-2": "p-2-new"}, gotPlans)
+
+	// The refreshed states are checked when the refresh started.
+	wk1, _ := s.Get("wk-1")
+	wk2, _ := s.Get("wk-2")
+	assert.False(wk1.CheckedAt.After(wk2.CheckedAt))
+}
+
+func TestWorkspaceStatesListWorkspaces(t *testing.T) {
+	wks := []model.Workspace{
+		{Name: "wk-1", Tags: []string{"prod", "team-a"}},
+		{Name: "wk-2", Tags: []string{"prod"}},
+		{Name: "wk-3", Tags: []string{"dev", "team-a"}},
+		{Name: "wk-4"},
+	}
+
+	tests := map[string]struct {
+		includeTags []string
+		excludeTags []string
+		expNames    []string
+	}{
+		"Without tags, it should list all the workspaces.": {
+			expNames: []string{"wk-1", "wk-2", "wk-3", "wk-4"},
+		},
+
+		"Include tags should list the workspaces that have all of them.": {
+			includeTags: []string{"prod", "team-a"},
+			expNames:    []string{"wk-1"},
+		},
+
+		"Exclude tags should not list the workspaces that have any of them.": {
+			excludeTags: []string{"prod", "dev"},
+			expNames:    []string{"wk-4"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s := controller.NewWorkspaceStates()
+			_, err := s.SetProcessor().Process(context.TODO(), wks)
+			require.NoError(err)
+
+			gotWks, err := s.ListWorkspaces(context.TODO(), test.includeTags, test.excludeTags)
+			require.NoError(err)
+
+			gotNames := []string{}
+			for _, wk := range gotWks {
+				gotNames = append(gotNames, wk.Name)
+			}
+			assert.Equal(test.expNames, gotNames)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+// Package exitpolicy decides the exit code of a drift detection run based on the processed
+// workspaces and the severity of each of them.
+package exitpolicy
+
+import (
+	"fmt"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+// Exit codes.
+const (
+	CodeOK             = 0
+	CodeError          = 1
+	CodeDrift          = 2
+	CodeDriftPlanError = 3
+	CodeAPIError       = 4
+	CodeWaitTimeout    = 5
+	CodeNoWorkspaces   = 6
+)
+
+// Severities of the workspaces.
+const (
+	// SeverityError workspaces will affect the exit code.
+	SeverityError = "error"
+	// SeverityWarning workspaces will be logged but will not affect the exit code.
+	SeverityWarning = "warning"
+)
+
+var validSeverities = map[string]bool{SeverityError: true, SeverityWarning: true}
+
+// DefaultRule is the rule name used when a workspace doesn't match any severity rule.
+const DefaultRule = "default"
+
+// Reason is the reason of an exit code.
+type Reason string
+
+const (
+	ReasonOK             Reason = "ok"
+	ReasonNoWorkspaces   Reason = "no_workspaces_selected"
+	ReasonDrift          Reason = "drift"
+	ReasonDriftPlanError Reason = "drift_plan_error"
+	ReasonWaitTimeout    Reason = "wait_timeout"
+	ReasonAPIError       Reason = "api_error"
+)
+
+// Decision is the exit code decision of a drift detection run.
+type Decision struct {
+	Code   int
+	Reason Reason
+	// Rule is the severity rule of the first workspace that determined the exit code (`default` if it
+	// didn't match any rule), empty if the exit code has not been determined by a workspace.
+	Rule string
+	// Workspaces are the names of the error severity workspaces affected by the reason.
+	Workspaces []string
+}
+
+// PolicyConfig is the configuration of the exit Policy.
+type PolicyConfig struct {
+	// SeverityRules are the rules used to select the severity of the workspaces,
+	// first rule that matches will be used.
+	SeverityRules   []selector.Rule
+	DefaultSeverity string
+	// DisableDriftPlanCodes will disable the drift and drift detection plan error exit codes.
+	DisableDriftPlanCodes bool
+}
+
+func (c *PolicyConfig) defaults() error {
+	if c.DefaultSeverity == "" {
+		c.DefaultSeverity = SeverityError
+	}
+
+	if !validSeverities[c.DefaultSeverity] {
+		return fmt.Errorf("invalid default severity %q", c.DefaultSeverity)
+	}
+
+	for _, r := range c.SeverityRules {
+		if !validSeverities[r.Value] {
+			return fmt.Errorf("invalid severity %q on %q rule", r.Value, r)
+		}
+	}
+
+	return nil
+}
+
+// Policy decides the exit code of the runs, the checks are made in order, the first
+// one that has error severity workspaces will determine the exit code:
+//
+//   - No workspaces selected (6).
+//   - Drift (2).
+//   - Drift detection plan errors (3).
+//   - Drift detection plan wait timeouts (5).
+//   - API errors (4).
+type Policy struct {
+	severityRules         []selector.Rule
+	defaultSeverity       string
+	disableDriftPlanCodes bool
+}
+
+// NewPolicy returns a new exit Policy.
+func NewPolicy(config PolicyConfig) (*Policy, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Policy{
+		severityRules:         config.SeverityRules,
+		defaultSeverity:       config.DefaultSeverity,
+		disableDriftPlanCodes: config.DisableDriftPlanCodes,
+	}, nil
+}
+
+// Severity returns the severity of the workspace and the rule that selected it.
+func (p *Policy) Severity(wk model.Workspace) (severity, rule string) {
+	if r, ok := selector.FirstMatch(p.severityRules, wk); ok {
+		return r.Value, r.String()
+	}
+
+	return p.defaultSeverity, DefaultRule
+}
+
+type check struct {
+	code   int
+	reason Reason
+	match  func(wk model.Workspace) bool
+}
+
+// Decide returns the exit code decision using the selected workspaces (the ones that could be
+// processed) and the processed ones.
+func (p *Policy) Decide(selected, processed []model.Workspace) Decision {
+	if len(selected) == 0 {
+		return Decision{Code: CodeNoWorkspaces, Reason: ReasonNoWorkspaces, Workspaces: []string{}}
+	}
+
+	checks := []check{
+		{code: CodeWaitTimeout, reason: ReasonWaitTimeout, match: func(wk model.Workspace) bool {
+			return wk.HasProcessError(model.ProcessErrorKindWaitTimeout)
+		}},
+		{code: CodeAPIError, reason: ReasonAPIError, match: func(wk model.Workspace) bool {
+			return wk.HasProcessError(model.ProcessErrorKindAPI)
+		}},
+	}
+	if !p.disableDriftPlanCodes {
+		checks = append([]check{
+			{code: CodeDrift, reason: ReasonDrift, match: func(wk model.Workspace) bool {
+				return wk.DriftState() == model.DriftStateDrift
+			}},
+			{code: CodeDriftPlanError, reason: ReasonDriftPlanError, match: func(wk model.Workspace) bool {
+				return wk.DriftState() == model.DriftStateError
+			}},
+		}, checks...)
+	}
+
+	for _, c := range checks {
+		d := Decision{Code: c.code, Reason: c.reason, Workspaces: []string{}}
+		for _, wk := range processed {
+			if !c.match(wk) {
+				continue
+			}
+
+			severity, rule := p.Severity(wk)
+			if severity != SeverityError {
+				continue
+			}
+
+			if d.Rule == "" {
+				d.Rule = rule
+			}
+			d.Workspaces = append(d.Workspaces, wk.Name)
+		}
+
+		if len(d.Workspaces) > 0 {
+			return d
+		}
+	}
+
+	return Decision{Code: CodeOK, Reason: ReasonOK, Workspaces: []string{}}
+}
@@ -0,0 +1,155 @@
+package exitpolicy_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/exitpolicy"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+func TestPolicyDecide(t *testing.T) {
+	apiErr := []model.ProcessError{{Kind: model.ProcessErrorKindAPI, Err: fmt.Errorf("something")}}
+	timeoutErr := []model.ProcessError{{Kind: model.ProcessErrorKindWaitTimeout, Err: fmt.Errorf("something")}}
+
+	tests := map[string]struct {
+		config      exitpolicy.PolicyConfig
+		selected    []model.Workspace
+		processed   []model.Workspace
+		expDecision exitpolicy.Decision
+		expErr      bool
+	}{
+		"Invalid severity rules should fail.": {
+			config: exitpolicy.PolicyConfig{SeverityRules: []selector.Rule{{Value: "critical"}}},
+			expErr: true,
+		},
+
+		"Invalid default severity should fail.": {
+			config: exitpolicy.PolicyConfig{DefaultSeverity: "critical"},
+			expErr: true,
+		},
+
+		"Not having selected workspaces should return the no workspaces code.": {
+			selected:    []model.Workspace{},
+			processed:   []model.Workspace{},
+			expDecision: exitpolicy.Decision{Code: 6, Reason: "no_workspaces_selected", Workspaces: []string{}},
+		},
+
+		"Selected workspaces without processed ones should be ok.": {
+			selected:    []model.Workspace{{Name: "wk1"}},
+			processed:   []model.Workspace{},
+			expDecision: exitpolicy.Decision{Code: 0, Reason: "ok", Workspaces: []string{}},
+		},
+
+		"Workspaces without drift nor errors should be ok.": {
+			selected: []model.Workspace{{Name: "wk1"}, {Name: "wk2"}},
+			processed: []model.Workspace{
+				{Name: "wk1", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedOK}},
+				{Name: "wk2", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedOK}},
+			},
+			expDecision: exitpolicy.Decision{Code: 0, Reason: "ok", Workspaces: []string{}},
+		},
+
+		"Drift should have priority over the other reasons.": {
+			selected: []model.Workspace{{Name: "wk1"}},
+			processed: []model.Workspace{
+				{Name: "wk1", ProcessErrors: apiErr},
+				{Name: "wk2", ProcessErrors: timeoutErr},
+				{Name: "wk3", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedNotOK}},
+				{Name: "wk4", LastDriftPlan: &model.Plan{HasChanges: true}},
+				{Name: "wk5", LastDriftPlan: &model.Plan{HasChanges: true}},
+			},
+			expDecision: exitpolicy.Decision{Code: 2, Reason: "drift", Rule: "default", Workspaces: []string{"wk4", "wk5"}},
+		},
+
+		"Drift detection plan errors should have priority over wait timeouts and API errors.": {
+			selected: []model.Workspace{{Name: "wk1"}},
+			processed: []model.Workspace{
+				{Name: "wk1", ProcessErrors: apiErr},
+				{Name: "wk2", ProcessErrors: timeoutErr},
+				{Name: "wk3", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedNotOK}},
+			},
+			expDecision: exitpolicy.Decision{Code: 3, Reason: "drift_plan_error", Rule: "default", Workspaces: []string{"wk3"}},
+		},
+
+		"Wait timeouts should have priority over API errors.": {
+			selected: []model.Workspace{{Name: "wk1"}},
+			processed: []model.Workspace{
+				{Name: "wk1", ProcessErrors: apiErr},
+				{Name: "wk2", ProcessErrors: timeoutErr},
+			},
+			expDecision: exitpolicy.Decision{Code: 5, Reason: "wait_timeout", Rule: "default", Workspaces: []string{"wk2"}},
+		},
+
+		"API errors should return the API error code.": {
+			selected: []model.Workspace{{Name: "wk1"}},
+			processed: []model.Workspace{
+				{Name: "wk1", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedOK}},
+				{Name: "wk2", ProcessErrors: apiErr},
+			},
+			expDecision: exitpolicy.Decision{Code: 4, Reason: "api_error", Rule: "default", Workspaces: []string{"wk2"}},
+		},
+
+		"Disabling drift plan codes should ignore drift and drift detection plan errors.": {
+			config:   exitpolicy.PolicyConfig{DisableDriftPlanCodes: true},
+			selected: []model.Workspace{{Name: "wk1"}},
+			processed: []model.Workspace{
+				{Name: "wk1", LastDriftPlan: &model.Plan{HasChanges: true}},
+				{Name: "wk2", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedNotOK}},
+				{Name: "wk3", ProcessErrors: apiErr},
+			},
+			expDecision: exitpolicy.Decision{Code: 4, Reason: "api_error", Rule: "default", Workspaces: []string{"wk3"}},
+		},
+
+		"Warning severity workspaces should not affect the exit code.": {
+			config: exitpolicy.PolicyConfig{SeverityRules: []selector.Rule{
+				mustParseRule(t, "tag:sandbox=warning"),
+				mustParseRule(t, "name:^prod-=error"),
+			}, DefaultSeverity: "warning"},
+			selected: []model.Workspace{{Name: "wk1"}},
+			processed: []model.Workspace{
+				{Name: "prod-wk1", Tags: []string{"sandbox"}, LastDriftPlan: &model.Plan{HasChanges: true}},
+				{Name: "wk2", LastDriftPlan: &model.Plan{HasChanges: true}},
+				{Name: "prod-wk3", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedNotOK}},
+				{Name: "prod-wk4", LastDriftPlan: &model.Plan{Status: model.PlanStatusFinishedNotOK}},
+			},
+			expDecision: exitpolicy.Decision{Code: 3, Reason: "drift_plan_error", Rule: "name:^prod-=error", Workspaces: []string{"prod-wk3", "prod-wk4"}},
+		},
+
+		"Only warning severity workspaces should be ok.": {
+			config:   exitpolicy.PolicyConfig{SeverityRules: []selector.Rule{mustParseRule(t, "tag:sandbox=warning")}},
+			selected: []model.Workspace{{Name: "wk1"}},
+			processed: []model.Workspace{
+				{Name: "wk1", Tags: []string{"sandbox"}, LastDriftPlan: &model.Plan{HasChanges: true}},
+				{Name: "wk2", Tags: []string{"sandbox"}, ProcessErrors: apiErr},
+			},
+			expDecision: exitpolicy.Decision{Code: 0, Reason: "ok", Workspaces: []string{}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			p, err := exitpolicy.NewPolicy(test.config)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(t, err)
+
+			gotDecision := p.Decide(test.selected, test.processed)
+			assert.Equal(test.expDecision, gotDecision)
+		})
+	}
+}
+
+func mustParseRule(t *testing.T, s string) selector.Rule {
+	r, err := selector.ParseRule(s)
+	require.NoError(t, err)
+	return r
+}
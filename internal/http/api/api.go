@@ -0,0 +1,121 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/log"
+)
+
+// Prefix is the path prefix of the API.
+const Prefix = "/api/v1/"
+
+type HandlerConfig struct {
+	Logger log.Logger
+	// Token is the bearer token required to use the API.
+	Token string
+	// Detector is used to trigger on-demand drift detections, if not set, the detections API will be disabled.
+	Detector Detector
+	// WorkspaceStates is used to get the workspaces state, if not set, the workspaces API will be disabled.
+	WorkspaceStates WorkspaceStates
+}
+
+func (c *HandlerConfig) defaults() error {
+	if c.Token == "" {
+		return fmt.Errorf("token is required")
+	}
+
+	if c.Detector == nil && c.WorkspaceStates == nil {
+		return fmt.Errorf("detector or workspace states are required")
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "http.API"})
+
+	return nil
+}
+
+type handler struct {
+	logger   log.Logger
+	token    []byte
+	detector Detector
+	wkStates WorkspaceStates
+	mux      *http.ServeMux
+}
+
+// NewHandler returns the controller HTTP API handler, must be served on the API prefix.
+func NewHandler(config HandlerConfig) (http.Handler, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	h := handler{
+		logger:   config.Logger,
+		token:    []byte(config.Token),
+		detector: config.Detector,
+		wkStates: config.WorkspaceStates,
+		mux:      http.NewServeMux(),
+	}
+
+	if h.detector != nil {
+		h.mux.HandleFunc(Prefix+"detections", h.detections())
+		h.mux.HandleFunc(Prefix+"detections/", h.detection())
+	}
+
+	if h.wkStates != nil {
+		h.mux.HandleFunc(Prefix+"workspaces", h.workspaces())
+		h.mux.HandleFunc(Prefix+"workspaces/", h.workspace())
+	}
+
+	return h, nil
+}
+
+func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), h.token) != 1 {
+		h.writeError(w, http.StatusUnauthorized, fmt.Errorf("unauthorized"))
+		return
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h handler) writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		h.logger.Errorf("Could not write response: %s", err)
+	}
+}
+
+func (h handler) writeError(w http.ResponseWriter, status int, err error) {
+	h.writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// errorStatus maps the errors to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, internalerrors.ErrNotValid):
+		return http.StatusBadRequest
+	case errors.Is(err, internalerrors.ErrNotExist):
+		return http.StatusNotFound
+	case errors.Is(err, internalerrors.ErrNotLeader):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
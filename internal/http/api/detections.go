@@ -0,0 +1,137 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/report"
+	"github.com/slok/tfe-drift/internal/result"
+)
+
+// Detector knows how to trigger on-demand drift detections and retrieve them.
+type Detector interface {
+	Trigger(ctx context.Context, r controller.DetectionRequest) (*controller.Detection, error)
+	Detection(id string) (*controller.Detection, error)
+}
+
+type detectionRequest struct {
+	Workspaces      []string `json:"workspaces"`
+	Tags            []string `json:"tags"`
+	BypassNotBefore bool     `json:"bypass_not_before"`
+}
+
+type detectionResponse struct {
+	ID         string           `json:"id"`
+	Status     string           `json:"status"`
+	Error      string           `json:"error,omitempty"`
+	Request    detectionRequest `json:"request"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at"`
+	NotFound   []string         `json:"not_found"`
+	Busy       []string         `json:"busy"`
+	// Result is the drift detection result, only available when finished.
+	Result *result.V2 `json:"result"`
+}
+
+func (h handler) detections() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			h.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+			return
+		}
+
+		req := detectionRequest{}
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+			return
+		}
+
+		d, err := h.detector.Trigger(r.Context(), controller.DetectionRequest{
+			Workspaces:      req.Workspaces,
+			Tags:            req.Tags,
+			BypassNotBefore: req.BypassNotBefore,
+		})
+		if err != nil {
+			h.writeError(w, errorStatus(err), err)
+			return
+		}
+
+		w.Header().Set("Location", Prefix+"detections/"+d.ID)
+		h.writeJSON(w, http.StatusAccepted, newDetectionResponse(d))
+	}
+}
+
+func (h handler) detection() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			h.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+			return
+		}
+
+		id := strings.TrimPrefix(r.URL.Path, Prefix+"detections/")
+		if id == "" || strings.Contains(id, "/") {
+			h.writeError(w, http.StatusNotFound, internalerrors.ErrNotExist)
+			return
+		}
+
+		d, err := h.detector.Detection(id)
+		if err != nil {
+			h.writeError(w, errorStatus(err), err)
+			return
+		}
+
+		h.writeJSON(w, http.StatusOK, newDetectionResponse(d))
+	}
+}
+
+func newDetectionResponse(d *controller.Detection) detectionResponse {
+	resp := detectionResponse{
+		ID:     d.ID,
+		Status: string(d.Status),
+		Request: detectionRequest{
+			Workspaces:      d.Request.Workspaces,
+			Tags:            d.Request.Tags,
+			BypassNotBefore: d.Request.BypassNotBefore,
+		},
+		CreatedAt: d.CreatedAt,
+		NotFound:  d.NotFound,
+		Busy:      []string{},
+	}
+	if resp.Request.Workspaces == nil {
+		resp.Request.Workspaces = []string{}
+	}
+	if resp.Request.Tags == nil {
+		resp.Request.Tags = []string{}
+	}
+	if resp.NotFound == nil {
+		resp.NotFound = []string{}
+	}
+	for _, wk := range d.Busy {
+		resp.Busy = append(resp.Busy, wk.Name)
+	}
+
+	if d.Err != nil {
+		resp.Error = d.Err.Error()
+	}
+
+	if !d.FinishedAt.IsZero() {
+		finishedAt := d.FinishedAt
+		resp.FinishedAt = &finishedAt
+	}
+
+	if d.Status == controller.DetectionStatusFinished {
+		res := result.NewV2(report.New(d.Processed, d.Selected, d.FinishedAt), nil)
+		resp.Result = &res
+	}
+
+	return resp
+}
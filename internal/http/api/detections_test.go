@@ -0,0 +1,177 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/http/api"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+type testWorkspaceLister []model.Workspace
+
+func (t testWorkspaceLister) ListWorkspaces(ctx context.Context, includeTags, excludeTags []string) ([]model.Workspace, error) {
+	return t, nil
+}
+
+func newTestDetector(t *testing.T) *controller.OnDemandDetector {
+	d, err := controller.NewOnDemandDetector(controller.OnDemandDetectorConfig{
+		WorkspaceLister: testWorkspaceLister{{ID: "id-1", Name: "wk-1"}, {ID: "id-2", Name: "wk-2"}},
+		WorkspaceProcessor: process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+			res := []model.Workspace{}
+			for _, wk := range wks {
+				wk.LastDriftPlan = &model.Plan{ID: "run-" + wk.Name, URL: "https://test/" + wk.Name, HasChanges: true, Status: model.PlanStatusFinishedOK}
+				res = append(res, wk)
+			}
+			return res, nil
+		}),
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() { _ = d.Run(ctx) }()
+
+	return d
+}
+
+func TestHandlerDetections(t *testing.T) {
+	tests := map[string]struct {
+		method    string
+		path      string
+		token     string
+		body      string
+		expStatus int
+		expBody   string
+	}{
+		"A request without token should be unauthorized.": {
+			method:    http.MethodPost,
+			path:      "/api/v1/detections",
+			body:      `{"workspaces": ["wk-1"]}`,
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"unauthorized"}`,
+		},
+
+		"A request with an invalid token should be unauthorized.": {
+			method:    http.MethodPost,
+			path:      "/api/v1/detections",
+			token:     "wrong",
+			body:      `{"workspaces": ["wk-1"]}`,
+			expStatus: http.StatusUnauthorized,
+			expBody:   `{"error":"unauthorized"}`,
+		},
+
+		"A request with an invalid method should fail.": {
+			method:    http.MethodGet,
+			path:      "/api/v1/detections",
+			token:     "test-token",
+			expStatus: http.StatusMethodNotAllowed,
+			expBody:   `{"error":"method not allowed"}`,
+		},
+
+		"A request with an invalid body should fail.": {
+			method:    http.MethodPost,
+			path:      "/api/v1/detections",
+			token:     "test-token",
+			body:      `{`,
+			expStatus: http.StatusBadRequest,
+			expBody:   `{"error":"invalid request body: unexpected EOF"}`,
+		},
+
+		"A request without workspaces nor tags should fail.": {
+			method:    http.MethodPost,
+			path:      "/api/v1/detections",
+			token:     "test-token",
+			body:      `{}`,
+			expStatus: http.StatusBadRequest,
+			expBody:   `{"error":"workspaces or tags are required: not valid"}`,
+		},
+
+		"Getting a missing detection should fail.": {
+			method:    http.MethodGet,
+			path:      "/api/v1/detections/missing",
+			token:     "test-token",
+			expStatus: http.StatusNotFound,
+			expBody:   `{"error":"detection \"missing\": resource does not exist"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			h, err := api.NewHandler(api.HandlerConfig{Token: "test-token", Detector: newTestDetector(t)})
+			require.NoError(err)
+
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			if test.token != "" {
+				req.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(test.expStatus, rec.Code)
+			assert.JSONEq(test.expBody, rec.Body.String())
+		})
+	}
+}
+
+func TestHandlerDetectionsTriggerAndPoll(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	h, err := api.NewHandler(api.HandlerConfig{Token: "test-token", Detector: newTestDetector(t)})
+	require.NoError(err)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-token")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Trigger.
+	rec := do(http.MethodPost, "/api/v1/detections", `{"workspaces": ["wk-1", "wk-3"], "bypass_not_before": true}`)
+	require.Equal(http.StatusAccepted, rec.Code)
+
+	triggered := map[string]any{}
+	require.NoError(json.Unmarshal(rec.Body.Bytes(), &triggered))
+	id := triggered["id"].(string)
+	assert.Equal("/api/v1/detections/"+id, rec.Header().Get("Location"))
+	assert.Equal(map[string]any{"workspaces": []any{"wk-1", "wk-3"}, "tags": []any{}, "bypass_not_before": true}, triggered["request"])
+
+	// Poll.
+	var got map[string]any
+	require.Eventually(func() bool {
+		rec := do(http.MethodGet, "/api/v1/detections/"+id, "")
+		require.Equal(http.StatusOK, rec.Code)
+		got = map[string]any{}
+		require.NoError(json.Unmarshal(rec.Body.Bytes(), &got))
+		return got["status"] == "finished"
+	}, time.Second, time.Millisecond)
+
+	assert.Equal([]any{"wk-3"}, got["not_found"])
+	assert.Equal([]any{}, got["busy"])
+	assert.NotNil(got["finished_at"])
+
+	res := got["result"].(map[string]any)
+	assert.Equal(true, res["drift"])
+	wks := res["workspaces"].([]any)
+	require.Len(wks, 1)
+	wk := wks[0].(map[string]any)
+	assert.Equal("wk-1", wk["name"])
+	assert.Equal("drift", wk["status"])
+	assert.Equal("https://test/wk-1", wk["drift_detection_run"].(map[string]any)["url"])
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+// WorkspaceStates knows how to get the latest known workspace states.
+type WorkspaceStates interface {
+	List() []controller.WorkspaceState
+	Get(name string) (controller.WorkspaceState, bool)
+}
+
+type workspacesResponse struct {
+	Workspaces []workspaceResponse `json:"workspaces"`
+}
+
+type workspaceResponse struct {
+	Name         string        `json:"name"`
+	ID           string        `json:"id"`
+	Organization string        `json:"organization"`
+	Tags         []string      `json:"tags"`
+	Status       string        `json:"status"`
+	URL          string        `json:"url"`
+	LastPlan     *planResponse `json:"last_plan"`
+	CheckedAt    time.Time     `json:"checked_at"`
+}
+
+type planResponse struct {
+	ID              string             `json:"id"`
+	URL             string             `json:"url"`
+	Message         string             `json:"message"`
+	HasChanges      bool               `json:"has_changes"`
+	CreatedAt       time.Time          `json:"created_at"`
+	FinishedAt      *time.Time         `json:"finished_at"`
+	DurationSeconds float64            `json:"duration_seconds"`
+	Resources       *resourcesResponse `json:"resources"`
+}
+
+type resourcesResponse struct {
+	Additions    int `json:"additions"`
+	Changes      int `json:"changes"`
+	Destructions int `json:"destructions"`
+	Imports      int `json:"imports"`
+}
+
+var validStatuses = map[string]bool{
+	string(model.DriftStateUnknown): true,
+	string(model.DriftStateWaiting): true,
+	string(model.DriftStateOK):      true,
+	string(model.DriftStateDrift):   true,
+	string(model.DriftStateError):   true,
+}
+
+// workspacesFilter filters the workspaces by status (any of them) and tags (all of them).
+type workspacesFilter struct {
+	statuses map[string]bool
+	tags     []string
+}
+
+func newWorkspacesFilter(q url.Values) (*workspacesFilter, error) {
+	f := &workspacesFilter{statuses: map[string]bool{}}
+	for _, s := range splitQueryValues(q["status"]) {
+		if !validStatuses[s] {
+			return nil, fmt.Errorf("invalid status %q: %w", s, internalerrors.ErrNotValid)
+		}
+		f.statuses[s] = true
+	}
+	f.tags = splitQueryValues(q["tag"])
+
+	return f, nil
+}
+
+func (f workspacesFilter) match(wk model.Workspace) bool {
+	if len(f.statuses) > 0 && !f.statuses[string(wk.DriftState())] {
+		return false
+	}
+
+	for _, tag := range f.tags {
+		found := false
+		for _, wkTag := range wk.Tags {
+			if wkTag == tag {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// splitQueryValues supports repeated and comma separated query values.
+func splitQueryValues(values []string) []string {
+	res := []string{}
+	for _, v := range values {
+		for _, s := range strings.Split(v, ",") {
+			s = strings.TrimSpace(s)
+			if s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+
+	return res
+}
+
+func (h handler) workspaces() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			h.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+			return
+		}
+
+		filter, err := newWorkspacesFilter(r.URL.Query())
+		if err != nil {
+			h.writeError(w, errorStatus(err), err)
+			return
+		}
+
+		resp := workspacesResponse{Workspaces: []workspaceResponse{}}
+		for _, s := range h.wkStates.List() {
+			if filter.match(s.Workspace) {
+				resp.Workspaces = append(resp.Workspaces, newWorkspaceResponse(s))
+			}
+		}
+
+		h.writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+func (h handler) workspace() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			h.writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+			return
+		}
+
+		name := strings.TrimPrefix(r.URL.Path, Prefix+"workspaces/")
+		s, ok := h.wkStates.Get(name)
+		if name == "" || !ok {
+			h.writeError(w, http.StatusNotFound, fmt.Errorf("workspace %q: %w", name, internalerrors.ErrNotExist))
+			return
+		}
+
+		h.writeJSON(w, http.StatusOK, newWorkspaceResponse(s))
+	}
+}
+
+func newWorkspaceResponse(s controller.WorkspaceState) workspaceResponse {
+	wk := s.Workspace
+	resp := workspaceResponse{
+		Name:         wk.Name,
+		ID:           wk.ID,
+		Organization: wk.Org,
+		Tags:         wk.Tags,
+		Status:       string(wk.DriftState()),
+		URL:          wk.URL,
+		CheckedAt:    s.CheckedAt,
+	}
+	if resp.Tags == nil {
+		resp.Tags = []string{}
+	}
+
+	if p := wk.LastDriftPlan; p != nil {
+		resp.LastPlan = &planResponse{
+			ID:              p.ID,
+			URL:             p.URL,
+			Message:         p.Message,
+			HasChanges:      p.HasChanges,
+			CreatedAt:       p.CreatedAt,
+			DurationSeconds: p.PlanRunDuration.Seconds(),
+		}
+
+		if !p.FinishedAt.IsZero() {
+			finishedAt := p.FinishedAt
+			resp.LastPlan.FinishedAt = &finishedAt
+		}
+
+		if res := p.Resources; res != nil {
+			resp.LastPlan.Resources = &resourcesResponse{
+				Additions:    res.Additions,
+				Changes:      res.Changes,
+				Destructions: res.Destructions,
+				Imports:      res.Imports,
+			}
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,107 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/http/api"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+func TestHandlerWorkspaces(t *testing.T) {
+	t0 := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	wks := []model.Workspace{
+		{Name: "wk-1", ID: "id-1", Org: "org", URL: "https://test/wk-1", Tags: []string{"prod", "team-a"}, LastDriftPlan: &model.Plan{
+			ID: "run-1", URL: "https://test/wk-1/runs/run-1", Message: "Drift detection", HasChanges: true, Status: model.PlanStatusFinishedOK,
+			CreatedAt: t0, FinishedAt: t0.Add(time.Minute), PlanRunDuration: time.Minute, Resources: &model.PlanResources{Changes: 2},
+		}},
+		{Name: "wk-2", ID: "id-2", Org: "org", URL: "https://test/wk-2", Tags: []string{"prod"}, LastDriftPlan: &model.Plan{
+			ID: "run-2", URL: "https://test/wk-2/runs/run-2", Status: model.PlanStatusWaiting, CreatedAt: t0,
+		}},
+		{Name: "wk-3", ID: "id-3", Org: "org", URL: "https://test/wk-3"},
+	}
+
+	expWk1 := `{"name":"wk-1","id":"id-1","organization":"org","tags":["prod","team-a"],"status":"drift","url":"https://test/wk-1","last_plan":{"id":"run-1","url":"https://test/wk-1/runs/run-1","message":"Drift detection","has_changes":true,"created_at":"2023-05-06T07:08:09Z","finished_at":"2023-05-06T07:09:09Z","duration_seconds":60,"resources":{"additions":0,"changes":2,"destructions":0,"imports":0}},"checked_at":"CHECKED_AT"}`
+	expWk2 := `{"name":"wk-2","id":"id-2","organization":"org","tags":["prod"],"status":"waiting","url":"https://test/wk-2","last_plan":{"id":"run-2","url":"https://test/wk-2/runs/run-2","message":"","has_changes":false,"created_at":"2023-05-06T07:08:09Z","finished_at":null,"duration_seconds":0,"resources":null},"checked_at":"CHECKED_AT"}`
+	expWk3 := `{"name":"wk-3","id":"id-3","organization":"org","tags":[],"status":"unknown","url":"https://test/wk-3","last_plan":null,"checked_at":"CHECKED_AT"}`
+
+	tests := map[string]struct {
+		path      string
+		expStatus int
+		expBody   string
+	}{
+		"Listing the workspaces should return all the workspaces.": {
+			path:      "/api/v1/workspaces",
+			expStatus: http.StatusOK,
+			expBody:   `{"workspaces":[` + expWk1 + `,` + expWk2 + `,` + expWk3 + `]}`,
+		},
+
+		"Listing the workspaces filtering by status should return the workspaces with any of the statuses.": {
+			path:      "/api/v1/workspaces?status=drift,unknown",
+			expStatus: http.StatusOK,
+			expBody:   `{"workspaces":[` + expWk1 + `,` + expWk3 + `]}`,
+		},
+
+		"Listing the workspaces filtering by tag should return the workspaces with all the tags.": {
+			path:      "/api/v1/workspaces?tag=prod&tag=team-a",
+			expStatus: http.StatusOK,
+			expBody:   `{"workspaces":[` + expWk1 + `]}`,
+		},
+
+		"Listing the workspaces without matches should return an empty list.": {
+			path:      "/api/v1/workspaces?status=ok",
+			expStatus: http.StatusOK,
+			expBody:   `{"workspaces":[]}`,
+		},
+
+		"Listing the workspaces with an invalid status should fail.": {
+			path:      "/api/v1/workspaces?status=wrong",
+			expStatus: http.StatusBadRequest,
+			expBody:   `{"error":"invalid status \"wrong\": not valid"}`,
+		},
+
+		"Getting a workspace should return the workspace.": {
+			path:      "/api/v1/workspaces/wk-2",
+			expStatus: http.StatusOK,
+			expBody:   expWk2,
+		},
+
+		"Getting a missing workspace should fail.": {
+			path:      "/api/v1/workspaces/wk-4",
+			expStatus: http.StatusNotFound,
+			expBody:   `{"error":"workspace \"wk-4\": resource does not exist"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			states := controller.NewWorkspaceStates()
+			_, err := states.SetProcessor().Process(context.TODO(), wks)
+			require.NoError(err)
+			checkedAt := states.List()[0].CheckedAt.Format(time.RFC3339Nano)
+
+			h, err := api.NewHandler(api.HandlerConfig{Token: "test-token", WorkspaceStates: states})
+			require.NoError(err)
+
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("Authorization", "Bearer test-token")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(test.expStatus, rec.Code)
+			assert.JSONEq(strings.ReplaceAll(test.expBody, "CHECKED_AT", checkedAt), rec.Body.String())
+		})
+	}
+}
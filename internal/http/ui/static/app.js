@@ -0,0 +1,157 @@
+(function () {
+  "use strict";
+
+  var statusLabels = { drift: "Drift", drift_plan_error: "Errors", waiting: "Waiting", unknown: "Unknown", ok: "OK" };
+  var state = { data: null, activeTags: {} };
+
+  function el(tag, attrs, children) {
+    var e = document.createElement(tag);
+    Object.keys(attrs || {}).forEach(function (k) {
+      if (k === "text") { e.textContent = attrs[k]; } else { e.setAttribute(k, attrs[k]); }
+    });
+    (children || []).forEach(function (c) { e.appendChild(c); });
+    return e;
+  }
+
+  function fmtTime(t) {
+    if (!t) { return "-"; }
+    return new Date(t).toLocaleString();
+  }
+
+  function fmtAgo(t) {
+    if (!t) { return "-"; }
+    var s = Math.round((Date.now() - new Date(t).getTime()) / 1000);
+    if (s < 0) { return "in " + fmtDuration(-s); }
+    return fmtDuration(s) + " ago";
+  }
+
+  function fmtDuration(s) {
+    s = Math.round(s);
+    if (s < 60) { return s + "s"; }
+    if (s < 3600) { return Math.floor(s / 60) + "m"; }
+    if (s < 86400) { return Math.floor(s / 3600) + "h" + Math.floor((s % 3600) / 60) + "m"; }
+    return Math.floor(s / 86400) + "d" + Math.floor((s % 86400) / 3600) + "h";
+  }
+
+  function kvRows(table, rows) {
+    table.replaceChildren.apply(table, rows.map(function (r) {
+      return el("tr", {}, [el("td", { text: r[0] }), el("td", { text: String(r[1]) })]);
+    }));
+  }
+
+  function renderSummary(d) {
+    var cards = [el("div", { class: "card" }, [el("div", { class: "value", text: String(d.workspaces.length) }), el("div", { class: "label", text: "Total" })])];
+    Object.keys(statusLabels).forEach(function (s) {
+      cards.push(el("div", { class: "card " + s }, [el("div", { class: "value", text: String(d.summary[s] || 0) }), el("div", { class: "label", text: statusLabels[s] })]));
+    });
+    var summary = document.getElementById("summary");
+    summary.replaceChildren.apply(summary, cards);
+  }
+
+  function renderDetectors(d) {
+    var schedule = [];
+    var cycles = [];
+    var multi = d.detectors.length > 1;
+    d.detectors.forEach(function (det) {
+      if (multi) { schedule.push(["Profile", det.profile]); }
+      schedule.push(
+        ["Schedule", det.schedule],
+        ["Next run", det.next_run ? fmtTime(det.next_run) + " (" + fmtAgo(det.next_run) + ")" : "-"],
+        ["Cycles tracking plans", det.inflight_cycles]
+      );
+
+      var c = det.last_cycle;
+      if (!c) { return; }
+      if (multi) { cycles.push(["Profile", det.profile]); }
+      cycles.push(
+        ["Started", fmtTime(c.started_at) + " (" + fmtAgo(c.started_at) + ")"],
+        ["Duration", fmtDuration(c.duration_seconds)]
+      );
+      if (c.skipped_reason) { cycles.push(["Skipped", c.skipped_reason]); }
+      if (c.overlapped_cycles) { cycles.push(["Overlapped cycles", c.overlapped_cycles]); }
+      cycles.push(["Selected", c.selected]);
+      Object.keys(statusLabels).forEach(function (s) {
+        if (c.processed[s]) { cycles.push([statusLabels[s], c.processed[s]]); }
+      });
+      if (c.error) { cycles.push(["Error", c.error]); }
+    });
+
+    if (schedule.length > 0) { kvRows(document.getElementById("schedule"), schedule); }
+    if (cycles.length > 0) { kvRows(document.getElementById("last-cycle"), cycles); }
+  }
+
+  function renderTags(d) {
+    var tags = {};
+    d.workspaces.forEach(function (wk) { wk.tags.forEach(function (t) { tags[t] = true; }); });
+    var facets = Object.keys(tags).sort().map(function (t) {
+      var f = el("span", { class: "facet" + (state.activeTags[t] ? " active" : ""), text: t });
+      f.addEventListener("click", function () {
+        if (state.activeTags[t]) { delete state.activeTags[t]; } else { state.activeTags[t] = true; }
+        render();
+      });
+      return f;
+    });
+    var container = document.getElementById("tags");
+    container.replaceChildren.apply(container, facets);
+  }
+
+  function renderWorkspaces(d) {
+    var query = document.getElementById("filter").value.toLowerCase();
+    var status = document.getElementById("status").value;
+    var activeTags = Object.keys(state.activeTags);
+
+    var rows = d.workspaces.filter(function (wk) {
+      if (query && wk.name.toLowerCase().indexOf(query) < 0) { return false; }
+      if (status && wk.status !== status) { return false; }
+      return activeTags.every(function (t) { return wk.tags.indexOf(t) >= 0; });
+    }).map(function (wk) {
+      var name = wk.url ? el("a", { href: wk.url, target: "_blank", rel: "noopener", text: wk.name }) : document.createTextNode(wk.name);
+      var run = wk.run_url ? el("a", { href: wk.run_url, target: "_blank", rel: "noopener", text: fmtAgo(wk.last_plan_at) }) : document.createTextNode(fmtAgo(wk.last_plan_at));
+      return el("tr", {}, [
+        el("td", {}, [name]),
+        el("td", {}, [el("span", { class: "status " + wk.status, text: statusLabels[wk.status] || wk.status })]),
+        el("td", {}, wk.tags.map(function (t) { return el("span", { class: "tag", text: t }); })),
+        el("td", {}, [run]),
+        el("td", { text: String(wk.resource_changes) }),
+        el("td", { text: fmtAgo(wk.checked_at) })
+      ]);
+    });
+
+    var tbody = document.getElementById("workspaces");
+    tbody.replaceChildren.apply(tbody, rows);
+    document.getElementById("no-workspaces").hidden = rows.length > 0;
+  }
+
+  function render() {
+    var d = state.data;
+    if (!d) { return; }
+    document.getElementById("generated-at").textContent = fmtTime(d.generated_at);
+    renderSummary(d);
+    renderDetectors(d);
+    renderTags(d);
+    renderWorkspaces(d);
+  }
+
+  function poll() {
+    var next = 15000;
+    fetch("state.json", { cache: "no-store" })
+      .then(function (r) {
+        if (!r.ok) { throw new Error("HTTP " + r.status); }
+        return r.json();
+      })
+      .then(function (d) {
+        state.data = d;
+        next = d.refresh_interval_seconds * 1000;
+        document.getElementById("error").textContent = "";
+        render();
+      })
+      .catch(function (err) {
+        document.getElementById("error").textContent = "(could not refresh: " + err.message + ")";
+      })
+      .finally(function () { setTimeout(poll, next); });
+  }
+
+  document.getElementById("filter").addEventListener("input", render);
+  document.getElementById("status").addEventListener("change", render);
+  poll();
+})();
@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+var (
+	//go:embed static
+	staticFS embed.FS
+)
+
+// WorkspaceStates knows how to get the latest known workspace states.
+type WorkspaceStates interface {
+	List() []controller.WorkspaceState
+}
+
+// DriftDetector knows how to get the drift detector status.
+type DriftDetector interface {
+	Status() controller.DriftDetectorStatus
+}
+
+type HandlerConfig struct {
+	Logger          log.Logger
+	WorkspaceStates WorkspaceStates
+	// DriftDetectors are used to show the drift detectors status by profile, optional.
+	DriftDetectors map[string]DriftDetector
+	// RefreshInterval is the interval used by the UI to refresh the data.
+	RefreshInterval time.Duration
+}
+
+func (c *HandlerConfig) defaults() error {
+	if c.WorkspaceStates == nil {
+		return fmt.Errorf("workspace states are required")
+	}
+
+	if c.RefreshInterval == 0 {
+		c.RefreshInterval = 15 * time.Second
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "http.UI"})
+
+	return nil
+}
+
+type handler struct {
+	logger          log.Logger
+	wkStates        WorkspaceStates
+	detectors       map[string]DriftDetector
+	refreshInterval time.Duration
+	mux             *http.ServeMux
+}
+
+// NewHandler returns the read-only web UI handler, must be served with the UI path prefix stripped.
+func NewHandler(config HandlerConfig) (http.Handler, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	static, err := fs.Sub(staticFS, "static")
+	if err != nil {
+		return nil, fmt.Errorf("could not load static files: %w", err)
+	}
+
+	h := handler{
+		logger:          config.Logger,
+		wkStates:        config.WorkspaceStates,
+		detectors:       config.DriftDetectors,
+		refreshInterval: config.RefreshInterval,
+		mux:             http.NewServeMux(),
+	}
+	h.mux.HandleFunc("/state.json", h.state())
+	h.mux.Handle("/", http.FileServer(http.FS(static)))
+
+	return h.mux, nil
+}
+
+type stateResponse struct {
+	GeneratedAt            time.Time           `json:"generated_at"`
+	RefreshIntervalSeconds float64             `json:"refresh_interval_seconds"`
+	Summary                map[string]int      `json:"summary"`
+	Detectors              []detectorResponse  `json:"detectors"`
+	Workspaces             []workspaceResponse `json:"workspaces"`
+}
+
+type detectorResponse struct {
+	Profile        string         `json:"profile"`
+	Schedule       string         `json:"schedule"`
+	NextRun        *time.Time     `json:"next_run"`
+	InflightCycles int            `json:"inflight_cycles"`
+	LastCycle      *cycleResponse `json:"last_cycle"`
+}
+
+type cycleResponse struct {
+	StartedAt        time.Time      `json:"started_at"`
+	FinishedAt       time.Time      `json:"finished_at"`
+	DurationSeconds  float64        `json:"duration_seconds"`
+	SkippedReason    string         `json:"skipped_reason"`
+	OverlappedCycles int            `json:"overlapped_cycles"`
+	Selected         int            `json:"selected"`
+	Processed        map[string]int `json:"processed"`
+	Error            string         `json:"error"`
+}
+
+type workspaceResponse struct {
+	Name            string     `json:"name"`
+	Tags            []string   `json:"tags"`
+	Status          string     `json:"status"`
+	URL             string     `json:"url"`
+	RunURL          string     `json:"run_url"`
+	LastPlanAt      *time.Time `json:"last_plan_at"`
+	ResourceChanges int        `json:"resource_changes"`
+	CheckedAt       time.Time  `json:"checked_at"`
+}
+
+// statusWeights are used to sort the workspaces by status relevance.
+var statusWeights = map[model.DriftState]int{
+	model.DriftStateDrift:   0,
+	model.DriftStateError:   1,
+	model.DriftStateWaiting: 2,
+	model.DriftStateUnknown: 3,
+	model.DriftStateOK:      4,
+}
+
+func (h handler) state() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := stateResponse{
+			GeneratedAt:            time.Now().UTC(),
+			RefreshIntervalSeconds: h.refreshInterval.Seconds(),
+			Summary:                map[string]int{},
+			Detectors:              []detectorResponse{},
+			Workspaces:             []workspaceResponse{},
+		}
+
+		// Sort by status relevance, then by name.
+		states := h.wkStates.List()
+		sort.SliceStable(states, func(i, j int) bool {
+			return statusWeights[states[i].Workspace.DriftState()] < statusWeights[states[j].Workspace.DriftState()]
+		})
+
+		for _, s := range states {
+			wk := s.Workspace
+			state := wk.DriftState()
+			resp.Summary[string(state)]++
+
+			rwk := workspaceResponse{
+				Name:      wk.Name,
+				Tags:      wk.Tags,
+				Status:    string(state),
+				URL:       wk.URL,
+				CheckedAt: s.CheckedAt,
+			}
+			if rwk.Tags == nil {
+				rwk.Tags = []string{}
+			}
+
+			if p := wk.LastDriftPlan; p != nil {
+				createdAt := p.CreatedAt
+				rwk.RunURL = p.URL
+				rwk.LastPlanAt = &createdAt
+				if res := p.Resources; res != nil {
+					rwk.ResourceChanges = res.Additions + res.Changes + res.Destructions + res.Imports
+				}
+			}
+
+			resp.Workspaces = append(resp.Workspaces, rwk)
+		}
+
+		for profile, d := range h.detectors {
+			resp.Detectors = append(resp.Detectors, newDetectorResponse(profile, d.Status()))
+		}
+		sort.Slice(resp.Detectors, func(i, j int) bool { return resp.Detectors[i].Profile < resp.Detectors[j].Profile })
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		err := json.NewEncoder(w).Encode(resp)
+		if err != nil {
+			h.logger.Errorf("Could not write response: %s", err)
+		}
+	}
+}
+
+func newDetectorResponse(profile string, st controller.DriftDetectorStatus) detectorResponse {
+	resp := detectorResponse{Profile: profile, Schedule: st.Schedule, InflightCycles: st.InflightCycles}
+	if !st.NextRun.IsZero() {
+		next := st.NextRun
+		resp.NextRun = &next
+	}
+
+	if c := st.LastCycle; c != nil {
+		resp.LastCycle = &cycleResponse{
+			StartedAt:        c.StartedAt,
+			FinishedAt:       c.FinishedAt,
+			DurationSeconds:  c.FinishedAt.Sub(c.StartedAt).Seconds(),
+			SkippedReason:    c.SkippedReason,
+			OverlappedCycles: c.OverlappedCycles,
+			Selected:         c.Selected,
+			Processed:        map[string]int{},
+		}
+		for state, n := range c.Processed {
+			resp.LastCycle.Processed[string(state)] = n
+		}
+		if c.Err != nil {
+			resp.LastCycle.Error = c.Err.Error()
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,156 @@
+package ui_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/http/ui"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+type testDriftDetector controller.DriftDetectorStatus
+
+func (t testDriftDetector) Status() controller.DriftDetectorStatus {
+	return controller.DriftDetectorStatus(t)
+}
+
+func TestHandlerStatic(t *testing.T) {
+	tests := map[string]struct {
+		path        string
+		expStatus   int
+		expContains string
+	}{
+		"The index should be served.": {
+			path:        "/",
+			expStatus:   http.StatusOK,
+			expContains: "<title>tfe-drift</title>",
+		},
+
+		"The app should be served.": {
+			path:        "/app.js",
+			expStatus:   http.StatusOK,
+			expContains: "state.json",
+		},
+
+		"Missing files should not be found.": {
+			path:      "/missing.js",
+			expStatus: http.StatusNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			h, err := ui.NewHandler(ui.HandlerConfig{WorkspaceStates: controller.NewWorkspaceStates()})
+			require.NoError(err)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+
+			assert.Equal(test.expStatus, rec.Code)
+			assert.Contains(rec.Body.String(), test.expContains)
+		})
+	}
+}
+
+func TestHandlerState(t *testing.T) {
+	t0 := time.Date(2023, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	tests := map[string]struct {
+		wks       []model.Workspace
+		detectors map[string]ui.DriftDetector
+		exp       func(checkedAt string) string
+	}{
+		"Without workspaces nor drift detector, it should return an empty state.": {
+			exp: func(string) string {
+				return `{"refresh_interval_seconds":15,"summary":{},"detectors":[],"workspaces":[]}`
+			},
+		},
+
+		"Workspaces should be sorted by status relevance and the drift detectors status returned by profile.": {
+			wks: []model.Workspace{
+				{Name: "wk-1", URL: "https://test/wk-1", LastDriftPlan: &model.Plan{URL: "https://test/wk-1/runs/r1", CreatedAt: t0, Status: model.PlanStatusFinishedOK}},
+				{Name: "wk-2", Tags: []string{"prod"}, LastDriftPlan: &model.Plan{URL: "https://test/wk-2/runs/r2", CreatedAt: t0, Status: model.PlanStatusFinishedOK, HasChanges: true, Resources: &model.PlanResources{Additions: 1, Changes: 2}}},
+				{Name: "wk-3"},
+			},
+			detectors: map[string]ui.DriftDetector{
+				"sandbox": testDriftDetector{Schedule: "@every 24h0m0s"},
+				"prod": testDriftDetector{
+					Schedule:       "@every 1h0m0s",
+					NextRun:        t0.Add(time.Hour),
+					InflightCycles: 2,
+					LastCycle: &controller.Cycle{
+						OverlappedCycles: 1,
+						StartedAt:        t0,
+						FinishedAt:       t0.Add(90 * time.Second),
+						Selected:         2,
+						Processed:        map[model.DriftState]int{model.DriftStateDrift: 1},
+						Err:              fmt.Errorf("something"),
+					},
+				},
+			},
+			exp: func(checkedAt string) string {
+				return `{
+"refresh_interval_seconds":15,
+"summary":{"ok":1,"drift":1,"unknown":1},
+"detectors":[
+  {
+    "profile":"prod",
+    "schedule":"@every 1h0m0s",
+    "next_run":"2023-05-06T08:08:09Z",
+    "inflight_cycles":2,
+    "last_cycle":{"started_at":"2023-05-06T07:08:09Z","finished_at":"2023-05-06T07:09:39Z","duration_seconds":90,"skipped_reason":"","overlapped_cycles":1,"selected":2,"processed":{"drift":1},"error":"something"}
+  },
+  {"profile":"sandbox","schedule":"@every 24h0m0s","next_run":null,"inflight_cycles":0,"last_cycle":null}
+],
+"workspaces":[
+  {"name":"wk-2","tags":["prod"],"status":"drift","url":"","run_url":"https://test/wk-2/runs/r2","last_plan_at":"2023-05-06T07:08:09Z","resource_changes":3,"checked_at":"` + checkedAt + `"},
+  {"name":"wk-3","tags":[],"status":"unknown","url":"","run_url":"","last_plan_at":null,"resource_changes":0,"checked_at":"` + checkedAt + `"},
+  {"name":"wk-1","tags":[],"status":"ok","url":"https://test/wk-1","run_url":"https://test/wk-1/runs/r1","last_plan_at":"2023-05-06T07:08:09Z","resource_changes":0,"checked_at":"` + checkedAt + `"}
+]}`
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			states := controller.NewWorkspaceStates()
+			_, err := states.SetProcessor().Process(context.TODO(), test.wks)
+			require.NoError(err)
+			checkedAt := ""
+			if l := states.List(); len(l) > 0 {
+				checkedAt = l[0].CheckedAt.Format(time.RFC3339Nano)
+			}
+
+			h, err := ui.NewHandler(ui.HandlerConfig{WorkspaceStates: states, DriftDetectors: test.detectors})
+			require.NoError(err)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/state.json", nil))
+			require.Equal(http.StatusOK, rec.Code)
+
+			// Remove dynamic fields.
+			got := map[string]any{}
+			require.NoError(json.Unmarshal(rec.Body.Bytes(), &got))
+			delete(got, "generated_at")
+			gotJSON, err := json.Marshal(got)
+			require.NoError(err)
+
+			assert.JSONEq(test.exp(checkedAt), string(gotJSON))
+		})
+	}
+}
@@ -4,6 +4,9 @@ var (
 	// Version is set in compile time.
 	Version = "dev"
 
+	// AppName is the name used to identify the app on external systems.
+	AppName = "tfe-drift"
+
 	// Prometheus namespace (prefix) used for prometheus metrics.
 	PrometheusNamespace = "tfe_drift"
 )
@@ -6,4 +6,19 @@ var (
 	ErrNotExist                 = fmt.Errorf("resource does not exist")
 	ErrDriftDetected            = fmt.Errorf("drift detected")
 	ErrDriftDetectionPlanFailed = fmt.Errorf("drift detection plan failed")
+	ErrAPI                      = fmt.Errorf("API errors")
+	ErrWaitTimeout              = fmt.Errorf("drift detection plan wait timeout")
+	ErrNoWorkspacesSelected     = fmt.Errorf("0 workspaces selected")
+	ErrNotValid                 = fmt.Errorf("not valid")
+	ErrNotLeader                = fmt.Errorf("not the leader")
+	ErrRestartRequired          = fmt.Errorf("restart required")
 )
+
+// ExitError is an error that should end the app with a specific exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string { return e.Err.Error() }
+func (e *ExitError) Unwrap() error { return e.Err }
@@ -0,0 +1,164 @@
+// Package jsonschema generates JSON schemas (draft 2020-12) from Go types.
+//
+// Only the subset of features required by the app types is supported, the struct fields
+// can be customized using these tags:
+//
+//   - `json`: The name of the property, `omitempty` properties will not be required.
+//   - `description`: The description of the property.
+//   - `enum`: Comma separated list of the allowed values.
+//   - `nullable`: If `true`, the property can also be `null` (e.g: nil slices).
+package jsonschema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON schema.
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	ID                   string             `json:"$id,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties any                `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Generate generates the JSON schema of the received value type.
+func Generate(v any, id, title, description string) (*Schema, error) {
+	s, err := generate(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	s.Schema = draft
+	s.ID = id
+	s.Title = title
+	s.Description = description
+
+	return s, nil
+}
+
+func generate(t reflect.Type) (*Schema, error) {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s, err := generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}, nil
+
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+
+	case reflect.Slice, reflect.Array:
+		items, err := generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported %s map key type", t.Key())
+		}
+		values, err := generate(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}, nil
+
+	case reflect.Struct:
+		return generateStruct(t)
+	}
+
+	return nil, fmt.Errorf("unsupported %s type", t)
+}
+
+func generateStruct(t reflect.Type) (*Schema, error) {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		Required:             []string{},
+		AdditionalProperties: false,
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs, err := generate(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %q field: %w", f.Name, err)
+		}
+		if f.Tag.Get("nullable") == "true" && f.Type.Kind() != reflect.Pointer {
+			fs = &Schema{AnyOf: []*Schema{fs, {Type: "null"}}}
+		}
+		fs.Description = f.Tag.Get("description")
+
+		if enum := f.Tag.Get("enum"); enum != "" {
+			for _, e := range strings.Split(enum, ",") {
+				v, err := enumValue(f.Type, e)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %q field enum: %w", f.Name, err)
+				}
+				fs.Enum = append(fs.Enum, v)
+			}
+		}
+
+		s.Properties[name] = fs
+		if !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+		}
+	}
+
+	return s, nil
+}
+
+func enumValue(t reflect.Type, v string) (any, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return v, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(v, 10, 64)
+	}
+
+	return nil, fmt.Errorf("unsupported %s enum type", t)
+}
@@ -0,0 +1,171 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/jsonschema"
+)
+
+type testChild struct {
+	Value float64 `json:"value"`
+}
+
+type testObj struct {
+	Version   int                  `json:"version" enum:"1,2" description:"The version."`
+	Name      string               `json:"name" enum:"a,b"`
+	Enabled   bool                 `json:"enabled,omitempty"`
+	Tags      []string             `json:"tags"`
+	Aliases   []string             `json:"aliases" nullable:"true" description:"The aliases."`
+	Labels    map[string]string    `json:"labels"`
+	Child     *testChild           `json:"child"`
+	Children  map[string]testChild `json:"children"`
+	CreatedAt time.Time            `json:"created_at"`
+	Ignored   string               `json:"-"`
+	internal  string
+}
+
+type testInvalid struct {
+	Fn func() `json:"fn"`
+}
+
+func TestGenerate(t *testing.T) {
+	tests := map[string]struct {
+		obj       any
+		expSchema string
+		expErr    bool
+	}{
+		"Unsupported types should fail.": {
+			obj:    testInvalid{},
+			expErr: true,
+		},
+
+		"A struct should generate the schema.": {
+			obj: testObj{internal: "test"},
+			expSchema: `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"$id": "https://test.dev/test.json",
+	"title": "Test",
+	"description": "Test schema.",
+	"type": "object",
+	"properties": {
+		"aliases": {
+			"description": "The aliases.",
+			"anyOf": [
+				{
+					"type": "array",
+					"items": {
+						"type": "string"
+					}
+				},
+				{
+					"type": "null"
+				}
+			]
+		},
+		"child": {
+			"anyOf": [
+				{
+					"type": "object",
+					"properties": {
+						"value": {
+							"type": "number"
+						}
+					},
+					"required": [
+						"value"
+					],
+					"additionalProperties": false
+				},
+				{
+					"type": "null"
+				}
+			]
+		},
+		"children": {
+			"type": "object",
+			"additionalProperties": {
+				"type": "object",
+				"properties": {
+					"value": {
+						"type": "number"
+					}
+				},
+				"required": [
+					"value"
+				],
+				"additionalProperties": false
+			}
+		},
+		"created_at": {
+			"type": "string",
+			"format": "date-time"
+		},
+		"enabled": {
+			"type": "boolean"
+		},
+		"labels": {
+			"type": "object",
+			"additionalProperties": {
+				"type": "string"
+			}
+		},
+		"name": {
+			"type": "string",
+			"enum": [
+				"a",
+				"b"
+			]
+		},
+		"tags": {
+			"type": "array",
+			"items": {
+				"type": "string"
+			}
+		},
+		"version": {
+			"description": "The version.",
+			"type": "integer",
+			"enum": [
+				1,
+				2
+			]
+		}
+	},
+	"required": [
+		"version",
+		"name",
+		"tags",
+		"aliases",
+		"labels",
+		"child",
+		"children",
+		"created_at"
+	],
+	"additionalProperties": false
+}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			s, err := jsonschema.Generate(test.obj, "https://test.dev/test.json", "Test", "Test schema.")
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := json.MarshalIndent(s, "", "\t")
+			require.NoError(t, err)
+			assert.Equal(test.expSchema, string(got))
+		})
+	}
+}
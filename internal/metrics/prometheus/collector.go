@@ -28,35 +28,27 @@ const (
 	stateDriftPlanError = "drift_plan_error"
 )
 
-type collector struct {
-	repo        WorkspaceRepository
+// Collector collects the workspaces drift detection metrics.
+type Collector struct {
+	repo        *asyncWorkspaceRepository
 	wkProcessor process.Processor
 	includeTags []string
 	excludeTags []string
 	logger      log.Logger
 	timeout     time.Duration
+	wkDescs     workspaceDescs
+}
 
+// workspaceDescs are the descriptions of the workspace drift detection metrics.
+type workspaceDescs struct {
 	stateDesc    *prometheus.Desc
 	infoDesc     *prometheus.Desc
 	createdDesc  *prometheus.Desc
 	finishedDesc *prometheus.Desc
 }
 
-func NewCollector(ctx context.Context, logger log.Logger, repo WorkspaceRepository, wkProcessor process.Processor, includeTags []string, excludeTags []string, timeout time.Duration) (prometheus.Collector, error) {
-	const paceSeconds = 75
-	asyncRepo, err := newAsyncWorkspaceRepository(ctx, logger, repo, paceSeconds*time.Second, includeTags, excludeTags)
-	if err != nil {
-		return nil, err
-	}
-
-	return collector{
-		repo:        asyncRepo,
-		wkProcessor: wkProcessor,
-		includeTags: includeTags,
-		excludeTags: excludeTags,
-		logger:      logger,
-		timeout:     timeout,
-
+func newWorkspaceDescs() workspaceDescs {
+	return workspaceDescs{
 		stateDesc: prometheus.NewDesc(
 			prometheus.BuildFQName(info.PrometheusNamespace, "workspace", "drift_detection_state"),
 			"The state of a workspaces drift detection.",
@@ -77,11 +69,40 @@ func NewCollector(ctx context.Context, logger log.Logger, repo WorkspaceReposito
 			"Unix epoch timestamp when the drift detection ended.",
 			[]string{"workspace_name"}, nil,
 		),
+	}
+}
+
+// NewCollector returns a new workspaces drift detection metrics collector. The workspaces are listed in
+// the background at regular intervals, and the refresh processor (optional) is run with the workspaces of
+// every successful listing (e.g: refresh other components with them), independently of the collections.
+func NewCollector(ctx context.Context, logger log.Logger, repo WorkspaceRepository, wkProcessor, refreshProcessor process.Processor, includeTags []string, excludeTags []string, timeout time.Duration) (*Collector, error) {
+	if refreshProcessor == nil {
+		refreshProcessor = process.NoopProcessor
+	}
+
+	const paceSeconds = 75
+	asyncRepo, err := newAsyncWorkspaceRepository(ctx, logger, repo, refreshProcessor, paceSeconds*time.Second, includeTags, excludeTags)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Collector{
+		repo:        asyncRepo,
+		wkProcessor: wkProcessor,
+		includeTags: includeTags,
+		excludeTags: excludeTags,
+		logger:      logger,
+		timeout:     timeout,
+
+		wkDescs: newWorkspaceDescs(),
 	}, nil
 }
 
-func (c collector) Describe(ch chan<- *prometheus.Desc) {}
-func (c collector) Collect(ch chan<- prometheus.Metric) {
+// LastRefresh returns when the workspaces cache was refreshed successfully for the last time.
+func (c *Collector) LastRefresh() time.Time { return c.repo.LastRefresh() }
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {}
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
 	c.logger.Debugf("Collection started")
 	defer c.logger.Debugf("Collection finished")
 
@@ -118,7 +139,7 @@ func (c collector) Collect(ch chan<- prometheus.Metric) {
 	}
 }
 
-func (c collector) collect(ctx context.Context) ([]prometheus.Metric, error) {
+func (c *Collector) collect(ctx context.Context) ([]prometheus.Metric, error) {
 	wks, err := c.repo.ListWorkspaces(ctx, c.includeTags, c.excludeTags)
 	if err != nil {
 		return nil, fmt.Errorf("could not list workspaces: %w", err)
@@ -129,6 +150,17 @@ func (c collector) collect(ctx context.Context) ([]prometheus.Metric, error) {
 		return nil, fmt.Errorf("could not process workspaces: %w", err)
 	}
 
+	return c.wkDescs.metrics(wks), nil
+}
+
+func (d workspaceDescs) describe(ch chan<- *prometheus.Desc) {
+	ch <- d.stateDesc
+	ch <- d.infoDesc
+	ch <- d.createdDesc
+	ch <- d.finishedDesc
+}
+
+func (d workspaceDescs) metrics(wks []model.Workspace) []prometheus.Metric {
 	metrics := []prometheus.Metric{}
 	for _, wk := range wks {
 		okValue := 0
@@ -154,20 +186,20 @@ func (c collector) collect(ctx context.Context) ([]prometheus.Metric, error) {
 
 		metrics = append(metrics,
 			// Write all state metrics setting 1 to the states we are in, 0 on the others.
-			prometheus.MustNewConstMetric(c.stateDesc, prometheus.GaugeValue, float64(okValue), wk.Name, stateOk),
-			prometheus.MustNewConstMetric(c.stateDesc, prometheus.GaugeValue, float64(driftValue), wk.Name, stateDrift),
-			prometheus.MustNewConstMetric(c.stateDesc, prometheus.GaugeValue, float64(driftPlanErrorValue), wk.Name, stateDriftPlanError),
+			prometheus.MustNewConstMetric(d.stateDesc, prometheus.GaugeValue, float64(okValue), wk.Name, stateOk),
+			prometheus.MustNewConstMetric(d.stateDesc, prometheus.GaugeValue, float64(driftValue), wk.Name, stateDrift),
+			prometheus.MustNewConstMetric(d.stateDesc, prometheus.GaugeValue, float64(driftPlanErrorValue), wk.Name, stateDriftPlanError),
 
 			// Info metric.
-			prometheus.MustNewConstMetric(c.infoDesc, prometheus.GaugeValue, 1, wk.Name, wk.ID, wk.LastDriftPlan.ID, wk.LastDriftPlan.URL, tagsLabel, wk.Org),
+			prometheus.MustNewConstMetric(d.infoDesc, prometheus.GaugeValue, 1, wk.Name, wk.ID, wk.LastDriftPlan.ID, wk.LastDriftPlan.URL, tagsLabel, wk.Org),
 
 			// Timestamps.
-			prometheus.MustNewConstMetric(c.createdDesc, prometheus.GaugeValue, float64(wk.LastDriftPlan.CreatedAt.Unix()), wk.Name),
-			prometheus.MustNewConstMetric(c.finishedDesc, prometheus.GaugeValue, float64(wk.LastDriftPlan.FinishedAt.Unix()), wk.Name),
+			prometheus.MustNewConstMetric(d.createdDesc, prometheus.GaugeValue, float64(wk.LastDriftPlan.CreatedAt.Unix()), wk.Name),
+			prometheus.MustNewConstMetric(d.finishedDesc, prometheus.GaugeValue, float64(wk.LastDriftPlan.FinishedAt.Unix()), wk.Name),
 		)
 	}
 
-	return metrics, nil
+	return metrics
 }
 
 // asyncWorkspaceRepository is a repository that will retrieve the workspaces asynchronously.
@@ -182,18 +214,21 @@ type asyncWorkspaceRepository struct {
 	excludeTagsIndex string
 	excludeTags      []string
 	r                WorkspaceRepository
+	refresh          process.Processor
 	logger           log.Logger
 	cache            []model.Workspace
+	lastRefresh      time.Time
 	mu               sync.RWMutex
 }
 
-func newAsyncWorkspaceRepository(ctx context.Context, logger log.Logger, r WorkspaceRepository, pace time.Duration, includeTags, excludeTags []string) (WorkspaceRepository, error) {
+func newAsyncWorkspaceRepository(ctx context.Context, logger log.Logger, r WorkspaceRepository, refresh process.Processor, pace time.Duration, includeTags, excludeTags []string) (*asyncWorkspaceRepository, error) {
 	ar := &asyncWorkspaceRepository{
 		includeTagsIndex: fmt.Sprintf("%v", includeTags),
 		includeTags:      includeTags,
 		excludeTagsIndex: fmt.Sprintf("%v", excludeTags),
 		excludeTags:      excludeTags,
 		r:                r,
+		refresh:          refresh,
 		logger:           logger,
 	}
 
@@ -203,6 +238,7 @@ func newAsyncWorkspaceRepository(ctx context.Context, logger log.Logger, r Works
 		return nil, fmt.Errorf("could not list workspaces to fill repository cache")
 	}
 	ar.cache = wks
+	ar.lastRefresh = time.Now().UTC()
 
 	// Start workspace async retrieval polling.
 	go ar.poll(ctx, pace)
@@ -214,6 +250,12 @@ func (a *asyncWorkspaceRepository) poll(ctx context.Context, pace time.Duration)
 	t := time.NewTicker(pace)
 	defer t.Stop()
 
+	// Refresh with the initial cache.
+	a.mu.RLock()
+	wks := a.cache
+	a.mu.RUnlock()
+	a.runRefresh(ctx, wks)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -221,17 +263,29 @@ func (a *asyncWorkspaceRepository) poll(ctx context.Context, pace time.Duration)
 		case <-t.C:
 			a.logger.Debugf("Async workspaces list triggered")
 			a.mu.Lock()
-			wk, err := a.r.ListWorkspaces(ctx, a.includeTags, a.excludeTags)
+			wks, err := a.r.ListWorkspaces(ctx, a.includeTags, a.excludeTags)
 			if err != nil {
 				a.logger.Errorf("Error retrieving async workspaces: %w", err)
 			} else {
-				a.cache = wk
+				a.cache = wks
+				a.lastRefresh = time.Now().UTC()
 			}
 			a.mu.Unlock()
+
+			if err == nil {
+				a.runRefresh(ctx, wks)
+			}
 		}
 	}
 }
 
+func (a *asyncWorkspaceRepository) runRefresh(ctx context.Context, wks []model.Workspace) {
+	_, err := a.refresh.Process(ctx, wks)
+	if err != nil {
+		a.logger.Errorf("Error refreshing with the async workspaces: %s", err)
+	}
+}
+
 func (a *asyncWorkspaceRepository) ListWorkspaces(ctx context.Context, includeTags, excludeTags []string) ([]model.Workspace, error) {
 	if a.includeTagsIndex != fmt.Sprintf("%v", includeTags) {
 		return nil, fmt.Errorf("the include tags are different from the ones used for the cache")
@@ -246,3 +300,11 @@ func (a *asyncWorkspaceRepository) ListWorkspaces(ctx context.Context, includeTa
 
 	return a.cache, nil
 }
+
+// LastRefresh returns when the cache was refreshed successfully for the last time.
+func (a *asyncWorkspaceRepository) LastRefresh() time.Time {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.lastRefresh
+}
@@ -112,7 +112,22 @@ tfe_drift_workspace_info{organization_name="test-org",run_id="test-run3",run_url
 			test.mock(mr)
 
 			// Create collector.
-			c, _ := internalprometheus.NewCollector(context.TODO(), log.Noop, mr, process.NoopProcessor, nil, nil, 1*time.Second)
+			refreshed := make(chan []model.Workspace, 1)
+			refresh := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+				refreshed <- wks
+				return wks, nil
+			})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c, _ := internalprometheus.NewCollector(ctx, log.Noop, mr, process.NoopProcessor, refresh, nil, nil, 1*time.Second)
+			assert.False(c.LastRefresh().IsZero())
+
+			// The listed workspaces should be refreshed without collections.
+			select {
+			case <-refreshed:
+			case <-time.After(time.Second):
+				assert.Fail("workspaces not refreshed")
+			}
 
 			// Register exporter.
 			reg := prometheus.NewRegistry()
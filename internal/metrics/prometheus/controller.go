@@ -0,0 +1,265 @@
+package prometheus
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	"github.com/slok/tfe-drift/internal/info"
+	tfestorage "github.com/slok/tfe-drift/internal/storage/tfe"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+// ControllerRecorder records the controller metrics on Prometheus, including the workspace
+// processors and TFE API metrics used by the controller.
+//
+// The drift detection metrics are labeled with the profile of the recorder (see WithProfile).
+type ControllerRecorder struct {
+	profile          string
+	nextRun          *prometheus.GaugeVec
+	missedSchedules  *prometheus.CounterVec
+	leader           prometheus.Gauge
+	cycleDuration    *prometheus.HistogramVec
+	lastSuccess      *prometheus.GaugeVec
+	inflightCycles   *prometheus.GaugeVec
+	overlapCycles    *prometheus.CounterVec
+	plansCreated     *prometheus.CounterVec
+	planCreateErrors *prometheus.CounterVec
+	planWaitTimeouts *prometheus.CounterVec
+	filteredWks      *prometheus.CounterVec
+	apiReqDuration   *prometheus.HistogramVec
+	configReloads    *prometheus.CounterVec
+	configReloadOK   prometheus.Gauge
+	configReloadAt   prometheus.Gauge
+}
+
+// NewControllerRecorder returns a new ControllerRecorder registering the metrics on the registerer.
+func NewControllerRecorder(reg prometheus.Registerer) (*ControllerRecorder, error) {
+	r := &ControllerRecorder{
+		nextRun: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detector_next_run_timestamp_seconds",
+			Help:      "Unix epoch timestamp when the next drift detection will be run.",
+		}, []string{"profile"}),
+		missedSchedules: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detector_missed_schedules_total",
+			Help:      "The number of drift detection schedules missed because the previous drift detection was still running.",
+		}, []string{"profile"}),
+		leader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "leader",
+			Help:      "If the controller replica is the leader (only the leader runs the drift detections).",
+		}),
+		cycleDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detector_cycle_duration_seconds",
+			Help:      "The duration of the drift detection cycles by profile and result.",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600, 7200},
+		}, []string{"profile", "result"}),
+		lastSuccess: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detector_last_success_timestamp_seconds",
+			Help:      "Unix epoch timestamp when the last successful drift detection cycle finished.",
+		}, []string{"profile"}),
+		inflightCycles: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detector_inflight_cycles",
+			Help:      "The number of drift detection cycles tracking their plans in the background.",
+		}, []string{"profile"}),
+		overlapCycles: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detector_overlapping_cycles_total",
+			Help:      "The number of drift detection cycles started while previous cycles were still tracking their plans.",
+		}, []string{"profile"}),
+		plansCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detection_plans_created_total",
+			Help:      "The number of drift detection plans created.",
+		}, []string{"profile"}),
+		planCreateErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detection_plan_create_errors_total",
+			Help:      "The number of drift detection plans that could not be created.",
+		}, []string{"profile"}),
+		planWaitTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "drift_detection_plan_wait_timeouts_total",
+			Help:      "The number of drift detection plans that didn't finish in time.",
+		}, []string{"profile"}),
+		filteredWks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "workspaces_filtered_total",
+			Help:      "The number of workspaces filtered by the workspace processors.",
+		}, []string{"profile", "processor"}),
+		apiReqDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "tfe",
+			Name:      "api_request_duration_seconds",
+			Help:      "The duration of the TFE API requests by operation and HTTP status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "status_code"}),
+		configReloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "config_reloads_total",
+			Help:      "The number of configuration reloads by result.",
+		}, []string{"result"}),
+		configReloadOK: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "config_last_reload_successful",
+			Help:      "If the last configuration reload was successful.",
+		}),
+		configReloadAt: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: info.PrometheusNamespace,
+			Subsystem: "controller",
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Unix epoch timestamp of the last successful configuration reload.",
+		}),
+	}
+
+	// The controller doesn't start without a valid configuration.
+	r.configReloadOK.Set(1)
+
+	for _, c := range []prometheus.Collector{
+		r.nextRun,
+		r.missedSchedules,
+		r.leader,
+		r.cycleDuration,
+		r.lastSuccess,
+		r.inflightCycles,
+		r.overlapCycles,
+		r.plansCreated,
+		r.planCreateErrors,
+		r.planWaitTimeouts,
+		r.filteredWks,
+		r.apiReqDuration,
+		r.configReloads,
+		r.configReloadOK,
+		r.configReloadAt,
+	} {
+		err := reg.Register(c)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+// WithProfile returns a recorder that labels the drift detection metrics with the profile.
+func (r *ControllerRecorder) WithProfile(profile string) *ControllerRecorder {
+	rp := *r
+	rp.profile = profile
+	return &rp
+}
+
+func (r *ControllerRecorder) SetDriftDetectorNextRun(ctx context.Context, t time.Time) {
+	r.nextRun.WithLabelValues(r.profile).Set(float64(t.Unix()))
+}
+
+func (r *ControllerRecorder) AddDriftDetectorMissedSchedules(ctx context.Context, n int) {
+	r.missedSchedules.WithLabelValues(r.profile).Add(float64(n))
+}
+
+func (r *ControllerRecorder) SetLeader(ctx context.Context, leader bool) {
+	r.leader.Set(boolFloat64(leader))
+}
+
+func (r *ControllerRecorder) ObserveDriftDetectorCycle(ctx context.Context, result controller.CycleResult, duration time.Duration) {
+	r.cycleDuration.WithLabelValues(r.profile, string(result)).Observe(duration.Seconds())
+}
+
+func (r *ControllerRecorder) SetDriftDetectorLastSuccess(ctx context.Context, t time.Time) {
+	r.lastSuccess.WithLabelValues(r.profile).Set(float64(t.Unix()))
+}
+
+func (r *ControllerRecorder) SetDriftDetectorInflightCycles(ctx context.Context, n int) {
+	r.inflightCycles.WithLabelValues(r.profile).Set(float64(n))
+}
+
+func (r *ControllerRecorder) AddDriftDetectorOverlappingCycles(ctx context.Context) {
+	r.overlapCycles.WithLabelValues(r.profile).Inc()
+}
+
+func (r *ControllerRecorder) AddConfigReload(ctx context.Context, result controller.ConfigReloadResult) {
+	r.configReloads.WithLabelValues(string(result)).Inc()
+	r.configReloadOK.Set(boolFloat64(result == controller.ConfigReloadResultSuccess))
+}
+
+func (r *ControllerRecorder) SetConfigLastReloadSuccess(ctx context.Context, t time.Time) {
+	r.configReloadAt.Set(float64(t.Unix()))
+}
+
+func (r *ControllerRecorder) AddDriftDetectionPlansCreated(ctx context.Context, n int) {
+	r.plansCreated.WithLabelValues(r.profile).Add(float64(n))
+}
+
+func (r *ControllerRecorder) AddDriftDetectionPlanCreateErrors(ctx context.Context, n int) {
+	r.planCreateErrors.WithLabelValues(r.profile).Add(float64(n))
+}
+
+func (r *ControllerRecorder) AddDriftDetectionPlanWaitTimeouts(ctx context.Context, n int) {
+	r.planWaitTimeouts.WithLabelValues(r.profile).Add(float64(n))
+}
+
+func (r *ControllerRecorder) AddFilteredWorkspaces(ctx context.Context, processor string, n int) {
+	r.filteredWks.WithLabelValues(r.profile, processor).Add(float64(n))
+}
+
+func (r *ControllerRecorder) ObserveAPIRequest(ctx context.Context, operation, statusCode string, duration time.Duration) {
+	r.apiReqDuration.WithLabelValues(operation, statusCode).Observe(duration.Seconds())
+}
+
+var (
+	_ controller.MetricsRecorder = &ControllerRecorder{}
+	_ process.MetricsRecorder    = &ControllerRecorder{}
+	_ tfestorage.MetricsRecorder = &ControllerRecorder{}
+)
+
+type blackoutsCollector struct {
+	blackouts  controller.Blackouts
+	activeDesc *prometheus.Desc
+}
+
+// NewBlackoutsCollector returns a collector of the blackout windows state, evaluated on every collection.
+func NewBlackoutsCollector(b controller.Blackouts) prometheus.Collector {
+	return blackoutsCollector{
+		blackouts: b,
+		activeDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(info.PrometheusNamespace, "controller", "blackout_window_active"),
+			"If the blackout window is active (drift detection plans are not created).",
+			[]string{"window", "scoped"}, nil,
+		),
+	}
+}
+
+func (c blackoutsCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.activeDesc }
+
+func (c blackoutsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, s := range c.blackouts.Status(time.Now()) {
+		ch <- prometheus.MustNewConstMetric(c.activeDesc, prometheus.GaugeValue, boolFloat64(s.Active), s.Window, strconv.FormatBool(s.Scoped))
+	}
+}
+
+func boolFloat64(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,253 @@
+package prometheus_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/controller"
+	internalprometheus "github.com/slok/tfe-drift/internal/metrics/prometheus"
+)
+
+func TestControllerRecorder(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2022-11-21T17:43:53+00:00")
+
+	tests := map[string]struct {
+		record         func(r *internalprometheus.ControllerRecorder)
+		expMetrics     string
+		expMetricNames []string
+	}{
+		"Drift detector schedule metrics should be recorded.": {
+			record: func(r *internalprometheus.ControllerRecorder) {
+				p := r.WithProfile("prod")
+				p.SetDriftDetectorNextRun(context.TODO(), t0.Add(-time.Hour))
+				p.SetDriftDetectorNextRun(context.TODO(), t0)
+				p.AddDriftDetectorMissedSchedules(context.TODO(), 2)
+				p.AddDriftDetectorMissedSchedules(context.TODO(), 1)
+				r.WithProfile("sandbox").AddDriftDetectorMissedSchedules(context.TODO(), 4)
+				r.SetLeader(context.TODO(), true)
+			},
+			expMetrics: `
+# HELP tfe_drift_controller_drift_detector_missed_schedules_total The number of drift detection schedules missed because the previous drift detection was still running.
+# TYPE tfe_drift_controller_drift_detector_missed_schedules_total counter
+tfe_drift_controller_drift_detector_missed_schedules_total{profile="prod"} 3
+tfe_drift_controller_drift_detector_missed_schedules_total{profile="sandbox"} 4
+# HELP tfe_drift_controller_drift_detector_next_run_timestamp_seconds Unix epoch timestamp when the next drift detection will be run.
+# TYPE tfe_drift_controller_drift_detector_next_run_timestamp_seconds gauge
+tfe_drift_controller_drift_detector_next_run_timestamp_seconds{profile="prod"} 1.669052633e+09
+# HELP tfe_drift_controller_leader If the controller replica is the leader (only the leader runs the drift detections).
+# TYPE tfe_drift_controller_leader gauge
+tfe_drift_controller_leader 1
+`,
+			expMetricNames: []string{
+				"tfe_drift_controller_drift_detector_missed_schedules_total",
+				"tfe_drift_controller_drift_detector_next_run_timestamp_seconds",
+				"tfe_drift_controller_leader",
+			},
+		},
+
+		"Drift detector cycle metrics should be recorded.": {
+			record: func(r *internalprometheus.ControllerRecorder) {
+				r = r.WithProfile("prod")
+				r.ObserveDriftDetectorCycle(context.TODO(), controller.CycleResultSuccess, 20*time.Second)
+				r.ObserveDriftDetectorCycle(context.TODO(), controller.CycleResultSuccess, 90*time.Second)
+				r.ObserveDriftDetectorCycle(context.TODO(), controller.CycleResultError, 2*time.Second)
+				r.SetDriftDetectorLastSuccess(context.TODO(), t0)
+				r.SetDriftDetectorInflightCycles(context.TODO(), 3)
+				r.SetDriftDetectorInflightCycles(context.TODO(), 2)
+				r.AddDriftDetectorOverlappingCycles(context.TODO())
+				r.AddDriftDetectorOverlappingCycles(context.TODO())
+			},
+			expMetrics: `
+# HELP tfe_drift_controller_drift_detector_cycle_duration_seconds The duration of the drift detection cycles by profile and result.
+# TYPE tfe_drift_controller_drift_detector_cycle_duration_seconds histogram
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="1"} 0
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="5"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="15"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="30"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="60"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="120"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="300"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="600"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="1200"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="1800"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="3600"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="7200"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="error",le="+Inf"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_sum{profile="prod",result="error"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_count{profile="prod",result="error"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="1"} 0
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="5"} 0
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="15"} 0
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="30"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="60"} 1
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="120"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="300"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="600"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="1200"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="1800"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="3600"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="7200"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_bucket{profile="prod",result="success",le="+Inf"} 2
+tfe_drift_controller_drift_detector_cycle_duration_seconds_sum{profile="prod",result="success"} 110
+tfe_drift_controller_drift_detector_cycle_duration_seconds_count{profile="prod",result="success"} 2
+# HELP tfe_drift_controller_drift_detector_last_success_timestamp_seconds Unix epoch timestamp when the last successful drift detection cycle finished.
+# TYPE tfe_drift_controller_drift_detector_last_success_timestamp_seconds gauge
+tfe_drift_controller_drift_detector_last_success_timestamp_seconds{profile="prod"} 1.669052633e+09
+# HELP tfe_drift_controller_drift_detector_inflight_cycles The number of drift detection cycles tracking their plans in the background.
+# TYPE tfe_drift_controller_drift_detector_inflight_cycles gauge
+tfe_drift_controller_drift_detector_inflight_cycles{profile="prod"} 2
+# HELP tfe_drift_controller_drift_detector_overlapping_cycles_total The number of drift detection cycles started while previous cycles were still tracking their plans.
+# TYPE tfe_drift_controller_drift_detector_overlapping_cycles_total counter
+tfe_drift_controller_drift_detector_overlapping_cycles_total{profile="prod"} 2
+`,
+			expMetricNames: []string{
+				"tfe_drift_controller_drift_detector_cycle_duration_seconds",
+				"tfe_drift_controller_drift_detector_last_success_timestamp_seconds",
+				"tfe_drift_controller_drift_detector_inflight_cycles",
+				"tfe_drift_controller_drift_detector_overlapping_cycles_total",
+			},
+		},
+
+		"Workspace processors metrics should be recorded.": {
+			record: func(r *internalprometheus.ControllerRecorder) {
+				r = r.WithProfile("prod")
+				r.AddDriftDetectionPlansCreated(context.TODO(), 3)
+				r.AddDriftDetectionPlansCreated(context.TODO(), 2)
+				r.AddDriftDetectionPlanCreateErrors(context.TODO(), 1)
+				r.AddDriftDetectionPlanWaitTimeouts(context.TODO(), 2)
+				r.AddFilteredWorkspaces(context.TODO(), "not_before", 10)
+				r.AddFilteredWorkspaces(context.TODO(), "limit_max", 4)
+				r.AddFilteredWorkspaces(context.TODO(), "not_before", 5)
+			},
+			expMetrics: `
+# HELP tfe_drift_controller_drift_detection_plan_create_errors_total The number of drift detection plans that could not be created.
+# TYPE tfe_drift_controller_drift_detection_plan_create_errors_total counter
+tfe_drift_controller_drift_detection_plan_create_errors_total{profile="prod"} 1
+# HELP tfe_drift_controller_drift_detection_plan_wait_timeouts_total The number of drift detection plans that didn't finish in time.
+# TYPE tfe_drift_controller_drift_detection_plan_wait_timeouts_total counter
+tfe_drift_controller_drift_detection_plan_wait_timeouts_total{profile="prod"} 2
+# HELP tfe_drift_controller_drift_detection_plans_created_total The number of drift detection plans created.
+# TYPE tfe_drift_controller_drift_detection_plans_created_total counter
+tfe_drift_controller_drift_detection_plans_created_total{profile="prod"} 5
+# HELP tfe_drift_controller_workspaces_filtered_total The number of workspaces filtered by the workspace processors.
+# TYPE tfe_drift_controller_workspaces_filtered_total counter
+tfe_drift_controller_workspaces_filtered_total{processor="limit_max",profile="prod"} 4
+tfe_drift_controller_workspaces_filtered_total{processor="not_before",profile="prod"} 15
+`,
+			expMetricNames: []string{
+				"tfe_drift_controller_drift_detection_plan_create_errors_total",
+				"tfe_drift_controller_drift_detection_plan_wait_timeouts_total",
+				"tfe_drift_controller_drift_detection_plans_created_total",
+				"tfe_drift_controller_workspaces_filtered_total",
+			},
+		},
+
+		"Config reload metrics should be recorded.": {
+			record: func(r *internalprometheus.ControllerRecorder) {
+				r.AddConfigReload(context.TODO(), controller.ConfigReloadResultSuccess)
+				r.SetConfigLastReloadSuccess(context.TODO(), time.Unix(1700000000, 0))
+				r.AddConfigReload(context.TODO(), controller.ConfigReloadResultRestartRequired)
+				r.AddConfigReload(context.TODO(), controller.ConfigReloadResultError)
+			},
+			expMetrics: `
+# HELP tfe_drift_controller_config_last_reload_success_timestamp_seconds Unix epoch timestamp of the last successful configuration reload.
+# TYPE tfe_drift_controller_config_last_reload_success_timestamp_seconds gauge
+tfe_drift_controller_config_last_reload_success_timestamp_seconds 1.7e+09
+# HELP tfe_drift_controller_config_last_reload_successful If the last configuration reload was successful.
+# TYPE tfe_drift_controller_config_last_reload_successful gauge
+tfe_drift_controller_config_last_reload_successful 0
+# HELP tfe_drift_controller_config_reloads_total The number of configuration reloads by result.
+# TYPE tfe_drift_controller_config_reloads_total counter
+tfe_drift_controller_config_reloads_total{result="error"} 1
+tfe_drift_controller_config_reloads_total{result="restart_required"} 1
+tfe_drift_controller_config_reloads_total{result="success"} 1
+`,
+			expMetricNames: []string{
+				"tfe_drift_controller_config_last_reload_success_timestamp_seconds",
+				"tfe_drift_controller_config_last_reload_successful",
+				"tfe_drift_controller_config_reloads_total",
+			},
+		},
+
+		"TFE API metrics should be recorded.": {
+			record: func(r *internalprometheus.ControllerRecorder) {
+				r.ObserveAPIRequest(context.TODO(), "list_workspaces", "200", 200*time.Millisecond)
+				r.ObserveAPIRequest(context.TODO(), "create_run", "429", 3*time.Second)
+			},
+			expMetrics: `
+# HELP tfe_drift_tfe_api_request_duration_seconds The duration of the TFE API requests by operation and HTTP status code.
+# TYPE tfe_drift_tfe_api_request_duration_seconds histogram
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="0.005"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="0.01"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="0.025"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="0.05"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="0.1"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="0.25"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="0.5"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="1"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="2.5"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="5"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="10"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="create_run",status_code="429",le="+Inf"} 1
+tfe_drift_tfe_api_request_duration_seconds_sum{operation="create_run",status_code="429"} 3
+tfe_drift_tfe_api_request_duration_seconds_count{operation="create_run",status_code="429"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="0.005"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="0.01"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="0.025"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="0.05"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="0.1"} 0
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="0.25"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="0.5"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="1"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="2.5"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="5"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="10"} 1
+tfe_drift_tfe_api_request_duration_seconds_bucket{operation="list_workspaces",status_code="200",le="+Inf"} 1
+tfe_drift_tfe_api_request_duration_seconds_sum{operation="list_workspaces",status_code="200"} 0.2
+tfe_drift_tfe_api_request_duration_seconds_count{operation="list_workspaces",status_code="200"} 1
+`,
+			expMetricNames: []string{
+				"tfe_drift_tfe_api_request_duration_seconds",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			r, err := internalprometheus.NewControllerRecorder(reg)
+			require.NoError(t, err)
+
+			test.record(r)
+
+			err = testutil.GatherAndCompare(reg, strings.NewReader(test.expMetrics), test.expMetricNames...)
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestBlackoutsCollector(t *testing.T) {
+	b1, err := controller.ParseBlackoutWindow("2000-01-01T00:00:00Z/2100-01-01T00:00:00Z")
+	require.NoError(t, err)
+	b2, err := controller.ParseBlackoutWindow("tag:sandbox=2000-01-01T00:00:00Z/2000-01-02T00:00:00Z")
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(internalprometheus.NewBlackoutsCollector(controller.Blackouts{b1, b2}))
+
+	expMetrics := `
+# HELP tfe_drift_controller_blackout_window_active If the blackout window is active (drift detection plans are not created).
+# TYPE tfe_drift_controller_blackout_window_active gauge
+tfe_drift_controller_blackout_window_active{scoped="false",window="2000-01-01T00:00:00Z/2100-01-01T00:00:00Z"} 1
+tfe_drift_controller_blackout_window_active{scoped="true",window="tag:sandbox=2000-01-01T00:00:00Z/2000-01-02T00:00:00Z"} 0
+`
+	err = testutil.GatherAndCompare(reg, strings.NewReader(expMetrics))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,170 @@
+package prometheus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+
+	"github.com/slok/tfe-drift/internal/info"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+// RunMetricsExporterConfig is the configuration of the run metrics exporter.
+type RunMetricsExporterConfig struct {
+	Logger log.Logger
+	// TextfilePath is the path of the node exporter textfile collector file where the metrics will be written.
+	TextfilePath string
+	// PushgatewayURL is the Prometheus Pushgateway URL where the metrics will be pushed.
+	PushgatewayURL string
+	// PushgatewayJob is the job used when pushing the metrics to the Pushgateway.
+	PushgatewayJob string
+	// PushgatewayGrouping are the grouping labels used when pushing the metrics to the Pushgateway.
+	PushgatewayGrouping map[string]string
+}
+
+func (c *RunMetricsExporterConfig) defaults() error {
+	if c.TextfilePath == "" && c.PushgatewayURL == "" {
+		return fmt.Errorf("textfile path or pushgateway URL is required")
+	}
+
+	if c.PushgatewayJob == "" {
+		c.PushgatewayJob = info.PrometheusNamespace
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "prometheus.RunMetricsExporter"})
+
+	return nil
+}
+
+// RunMetricsExporter exports the metrics of a `run` execution, the same workspace metrics as the controller
+// exporter plus the run metrics, using a node exporter textfile collector file or a Prometheus Pushgateway.
+type RunMetricsExporter struct {
+	logger              log.Logger
+	textfilePath        string
+	pushgatewayURL      string
+	pushgatewayJob      string
+	pushgatewayGrouping map[string]string
+}
+
+// NewRunMetricsExporter returns a new RunMetricsExporter.
+func NewRunMetricsExporter(config RunMetricsExporterConfig) (*RunMetricsExporter, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &RunMetricsExporter{
+		logger:              config.Logger,
+		textfilePath:        config.TextfilePath,
+		pushgatewayURL:      config.PushgatewayURL,
+		pushgatewayJob:      config.PushgatewayJob,
+		pushgatewayGrouping: config.PushgatewayGrouping,
+	}, nil
+}
+
+// ExportRunMetrics exports the workspaces and run metrics.
+func (r RunMetricsExporter) ExportRunMetrics(ctx context.Context, wks []model.Workspace, stats process.RunStats) error {
+	reg := prometheus.NewRegistry()
+	err := reg.Register(newRunCollector(wks, stats))
+	if err != nil {
+		return fmt.Errorf("could not register run metrics: %w", err)
+	}
+
+	if r.textfilePath != "" {
+		// WriteToTextfile writes to a temporary file and renames it, so it's atomic.
+		err := prometheus.WriteToTextfile(r.textfilePath, reg)
+		if err != nil {
+			return fmt.Errorf("could not write metrics textfile: %w", err)
+		}
+		r.logger.WithValues(log.Kv{"path": r.textfilePath}).Debugf("Metrics textfile written")
+	}
+
+	if r.pushgatewayURL != "" {
+		p := push.New(r.pushgatewayURL, r.pushgatewayJob).Gatherer(reg)
+		for k, v := range r.pushgatewayGrouping {
+			p = p.Grouping(k, v)
+		}
+
+		err := p.PushContext(ctx)
+		if err != nil {
+			return fmt.Errorf("could not push metrics to pushgateway: %w", err)
+		}
+		r.logger.WithValues(log.Kv{"url": r.pushgatewayURL}).Debugf("Metrics pushed")
+	}
+
+	return nil
+}
+
+// runCollector is a collector with static metrics of a run execution.
+type runCollector struct {
+	wks     []model.Workspace
+	stats   process.RunStats
+	wkDescs workspaceDescs
+
+	durationDesc     *prometheus.Desc
+	lastRunDesc      *prometheus.Desc
+	plansCreatedDesc *prometheus.Desc
+	errorsDesc       *prometheus.Desc
+}
+
+func newRunCollector(wks []model.Workspace, stats process.RunStats) prometheus.Collector {
+	return runCollector{
+		wks:     wks,
+		stats:   stats,
+		wkDescs: newWorkspaceDescs(),
+
+		durationDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(info.PrometheusNamespace, "run", "duration_seconds"),
+			"The duration of the drift detection run by result.",
+			[]string{"result"}, nil,
+		),
+		lastRunDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(info.PrometheusNamespace, "run", "last_timestamp_seconds"),
+			"Unix epoch timestamp when the drift detection run started.",
+			nil, nil,
+		),
+		plansCreatedDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(info.PrometheusNamespace, "run", "drift_detection_plans_created"),
+			"The number of drift detection plans created on the run.",
+			nil, nil,
+		),
+		errorsDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(info.PrometheusNamespace, "run", "errors"),
+			"The number of errors on the run by type.",
+			[]string{"type"}, nil,
+		),
+	}
+}
+
+func (c runCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.wkDescs.describe(ch)
+	ch <- c.durationDesc
+	ch <- c.lastRunDesc
+	ch <- c.plansCreatedDesc
+	ch <- c.errorsDesc
+}
+
+func (c runCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.wkDescs.metrics(c.wks) {
+		ch <- m
+	}
+
+	result := "success"
+	if c.stats.Failed {
+		result = "error"
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.durationDesc, prometheus.GaugeValue, c.stats.Duration.Seconds(), result)
+	ch <- prometheus.MustNewConstMetric(c.lastRunDesc, prometheus.GaugeValue, float64(c.stats.StartedAt.Unix()))
+	ch <- prometheus.MustNewConstMetric(c.plansCreatedDesc, prometheus.GaugeValue, float64(c.stats.PlansCreated))
+	ch <- prometheus.MustNewConstMetric(c.errorsDesc, prometheus.GaugeValue, float64(c.stats.PlanCreateErrors), "plan_create")
+	ch <- prometheus.MustNewConstMetric(c.errorsDesc, prometheus.GaugeValue, float64(c.stats.PlanWaitErrors), "plan_wait")
+	ch <- prometheus.MustNewConstMetric(c.errorsDesc, prometheus.GaugeValue, float64(c.stats.DriftPlanErrors), "drift_plan_error")
+}
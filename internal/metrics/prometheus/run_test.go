@@ -0,0 +1,154 @@
+package prometheus_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/log"
+	internalprometheus "github.com/slok/tfe-drift/internal/metrics/prometheus"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+)
+
+func testRunMetricsData() ([]model.Workspace, process.RunStats) {
+	t0, _ := time.Parse(time.RFC3339, "2022-11-21T17:43:53+00:00")
+
+	wks := []model.Workspace{
+		{Name: "test1", ID: "test-id-1", Tags: []string{"t1"}, Org: "test-org", LastDriftPlan: &model.Plan{
+			ID:         "test-run1",
+			URL:        "https://test-run1.dev",
+			Status:     model.PlanStatusFinishedOK,
+			HasChanges: true,
+			CreatedAt:  t0,
+			FinishedAt: t0.Add(10 * time.Second),
+		}},
+	}
+
+	stats := process.RunStats{
+		StartedAt:        t0,
+		Duration:         90 * time.Second,
+		PlansCreated:     1,
+		PlanCreateErrors: 2,
+		PlanWaitErrors:   3,
+		DriftPlanErrors:  4,
+	}
+
+	return wks, stats
+}
+
+const expRunMetrics = `# HELP tfe_drift_run_drift_detection_plans_created The number of drift detection plans created on the run.
+# TYPE tfe_drift_run_drift_detection_plans_created gauge
+tfe_drift_run_drift_detection_plans_created 1
+# HELP tfe_drift_run_duration_seconds The duration of the drift detection run by result.
+# TYPE tfe_drift_run_duration_seconds gauge
+tfe_drift_run_duration_seconds{result="success"} 90
+# HELP tfe_drift_run_errors The number of errors on the run by type.
+# TYPE tfe_drift_run_errors gauge
+tfe_drift_run_errors{type="drift_plan_error"} 4
+tfe_drift_run_errors{type="plan_create"} 2
+tfe_drift_run_errors{type="plan_wait"} 3
+# HELP tfe_drift_run_last_timestamp_seconds Unix epoch timestamp when the drift detection run started.
+# TYPE tfe_drift_run_last_timestamp_seconds gauge
+tfe_drift_run_last_timestamp_seconds 1.669052633e+09
+# HELP tfe_drift_workspace_drift_detection_create Unix epoch timestamp when the drift detection was created.
+# TYPE tfe_drift_workspace_drift_detection_create gauge
+tfe_drift_workspace_drift_detection_create{workspace_name="test1"} 1.669052633e+09
+# HELP tfe_drift_workspace_drift_detection_finish Unix epoch timestamp when the drift detection ended.
+# TYPE tfe_drift_workspace_drift_detection_finish gauge
+tfe_drift_workspace_drift_detection_finish{workspace_name="test1"} 1.669052643e+09
+# HELP tfe_drift_workspace_drift_detection_state The state of a workspaces drift detection.
+# TYPE tfe_drift_workspace_drift_detection_state gauge
+tfe_drift_workspace_drift_detection_state{state="drift",workspace_name="test1"} 1
+tfe_drift_workspace_drift_detection_state{state="drift_plan_error",workspace_name="test1"} 0
+tfe_drift_workspace_drift_detection_state{state="ok",workspace_name="test1"} 0
+# HELP tfe_drift_workspace_info Information of the workspace.
+# TYPE tfe_drift_workspace_info gauge
+tfe_drift_workspace_info{organization_name="test-org",run_id="test-run1",run_url="https://test-run1.dev",tags="t1",workspace_id="test-id-1",workspace_name="test1"} 1
+`
+
+func TestRunMetricsExporterTextfile(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "tfe-drift.prom")
+	e, err := internalprometheus.NewRunMetricsExporter(internalprometheus.RunMetricsExporterConfig{
+		Logger:       log.Noop,
+		TextfilePath: path,
+	})
+	require.NoError(err)
+
+	wks, stats := testRunMetricsData()
+	err = e.ExportRunMetrics(context.TODO(), wks, stats)
+	require.NoError(err)
+
+	got, err := os.ReadFile(path)
+	require.NoError(err)
+	assert.Equal(expRunMetrics, string(got))
+}
+
+func TestRunMetricsExporterTextfileFailedRun(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	path := filepath.Join(t.TempDir(), "tfe-drift.prom")
+	e, err := internalprometheus.NewRunMetricsExporter(internalprometheus.RunMetricsExporterConfig{
+		Logger:       log.Noop,
+		TextfilePath: path,
+	})
+	require.NoError(err)
+
+	_, stats := testRunMetricsData()
+	stats.Failed = true
+	err = e.ExportRunMetrics(context.TODO(), nil, stats)
+	require.NoError(err)
+
+	got, err := os.ReadFile(path)
+	require.NoError(err)
+	assert.Contains(string(got), `tfe_drift_run_duration_seconds{result="error"} 90`)
+	assert.Contains(string(got), `tfe_drift_run_errors{type="plan_create"} 2`)
+}
+
+func TestRunMetricsExporterPushgateway(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var gotMethod, gotPath string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	e, err := internalprometheus.NewRunMetricsExporter(internalprometheus.RunMetricsExporterConfig{
+		Logger:              log.Noop,
+		PushgatewayURL:      srv.URL,
+		PushgatewayJob:      "test-job",
+		PushgatewayGrouping: map[string]string{"env": "prod"},
+	})
+	require.NoError(err)
+
+	wks, stats := testRunMetricsData()
+	err = e.ExportRunMetrics(context.TODO(), wks, stats)
+	require.NoError(err)
+
+	assert.Equal(http.MethodPut, gotMethod)
+	assert.Equal("/metrics/job/test-job/env/prod", gotPath)
+	assert.NotEmpty(gotBody)
+}
+
+func TestRunMetricsExporterInvalidConfig(t *testing.T) {
+	_, err := internalprometheus.NewRunMetricsExporter(internalprometheus.RunMetricsExporterConfig{})
+	assert.Error(t, err)
+}
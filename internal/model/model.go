@@ -7,16 +7,45 @@ import (
 )
 
 type Workspace struct {
-	Name          string
-	ID            string
-	Org           string
+	Name string
+	ID   string
+	Org  string
+	// URL is the workspace web URL.
+	URL           string
 	Tags          []string
 	LastDriftPlan *Plan
+	// ProcessErrors are the errors that happened while processing the workspace.
+	ProcessErrors []ProcessError
 
 	// OriginalObject is the object from the original APIs (e.g go-tfe).
 	OriginalObject *tfe.Workspace
 }
 
+// HasProcessError returns true if the workspace had a processing error of the kind.
+func (w Workspace) HasProcessError(kind ProcessErrorKind) bool {
+	for _, e := range w.ProcessErrors {
+		if e.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessErrorKind is the kind of a workspace processing error.
+type ProcessErrorKind string
+
+const (
+	ProcessErrorKindAPI         ProcessErrorKind = "api"
+	ProcessErrorKindWaitTimeout ProcessErrorKind = "wait_timeout"
+)
+
+// ProcessError is an error that happened while processing a workspace, these errors
+// don't stop the processing of the other workspaces.
+type ProcessError struct {
+	Kind ProcessErrorKind
+	Err  error
+}
+
 // Plan is a run plan used for drift checks.
 type Plan struct {
 	ID              string
@@ -27,11 +56,21 @@ type Plan struct {
 	HasChanges      bool
 	Status          PlanStatus
 	URL             string
+	// Resources are the resource changes of the plan, nil if not available.
+	Resources *PlanResources
 
 	// OriginalObject is the object from the original APIs (e.g go-tfe).
 	OriginalObject *tfe.Run
 }
 
+// PlanResources are the number of resources changed by a plan.
+type PlanResources struct {
+	Additions    int
+	Changes      int
+	Destructions int
+	Imports      int
+}
+
 // PlanStatus are the simplified status that this app is interested when we
 // talk about a TFE run plan used to drift checks.
 type PlanStatus int
@@ -42,3 +81,43 @@ const (
 	PlanStatusFinishedOK
 	PlanStatusFinishedNotOK
 )
+
+// DriftState is the simplified state of a workspace based on its latest drift detection plan.
+type DriftState string
+
+const (
+	DriftStateUnknown DriftState = "unknown"
+	DriftStateWaiting DriftState = "waiting"
+	DriftStateOK      DriftState = "ok"
+	DriftStateDrift   DriftState = "drift"
+	DriftStateError   DriftState = "drift_plan_error"
+)
+
+// DriftState returns the drift state of the workspace based on the latest drift detection plan.
+func (w Workspace) DriftState() DriftState {
+	p := w.LastDriftPlan
+	switch {
+	case p == nil:
+		return DriftStateUnknown
+	case p.HasChanges:
+		return DriftStateDrift
+	case p.Status == PlanStatusFinishedNotOK:
+		return DriftStateError
+	case p.Status == PlanStatusWaiting:
+		return DriftStateWaiting
+	case p.Status == PlanStatusFinishedOK:
+		return DriftStateOK
+	default:
+		return DriftStateUnknown
+	}
+}
+
+// Verdict is the drift detection result of a workspace on a specific drift detection plan.
+type Verdict struct {
+	State  DriftState
+	PlanID string
+	// CreatedAt is when the drift detection plan of the verdict was created.
+	CreatedAt time.Time
+	// NotifiedAt is the last time the verdict was notified.
+	NotifiedAt time.Time
+}
@@ -0,0 +1,239 @@
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+const (
+	alertNameDrift          = "TerraformWorkspaceDrift"
+	alertNameDriftPlanError = "TerraformWorkspaceDriftPlanError"
+)
+
+// NotifierConfig is the configuration of the Alertmanager notifier.
+type NotifierConfig struct {
+	Logger log.Logger
+	// URL is the Alertmanager base URL (e.g: http://alertmanager:9093).
+	URL string
+	// AlertTTL is the time that a firing alert will be active if it's not refreshed
+	// (used to set `endsAt` on firing alerts), 0 will use Alertmanager `resolve_timeout`.
+	AlertTTL time.Duration
+	// ExtraLabels are labels that will be added to all the alerts.
+	ExtraLabels map[string]string
+	HTTPClient  *http.Client
+	TimeNow     func() time.Time
+}
+
+func (c *NotifierConfig) defaults() error {
+	if c.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	c.URL = strings.TrimSuffix(c.URL, "/")
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	if c.TimeNow == nil {
+		c.TimeNow = time.Now
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "alertmanager.Notifier"})
+
+	return nil
+}
+
+// Notifier pushes drift alerts directly to Alertmanager.
+//
+// On each notification, drifted and errored workspaces alerts will be sent as firing (refreshing them),
+// and the ones that were firing in previous notifications that are not anymore will be sent as resolved.
+type Notifier struct {
+	logger      log.Logger
+	url         string
+	alertTTL    time.Duration
+	extraLabels map[string]string
+	client      *http.Client
+	timeNow     func() time.Time
+
+	// firing are the alerts sent as firing on the latest notifications indexed by workspace ID.
+	firing map[string]alert
+	mu     sync.Mutex
+}
+
+// NewNotifier returns a new Alertmanager notifier.
+func NewNotifier(config NotifierConfig) (*Notifier, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Notifier{
+		logger:      config.Logger,
+		url:         config.URL,
+		alertTTL:    config.AlertTTL,
+		extraLabels: config.ExtraLabels,
+		client:      config.HTTPClient,
+		timeNow:     config.TimeNow,
+		firing:      map[string]alert{},
+	}, nil
+}
+
+type alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     *time.Time        `json:"startsAt,omitempty"`
+	EndsAt       *time.Time        `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// Notify sends the workspaces alerts to Alertmanager.
+func (n *Notifier) Notify(ctx context.Context, wks []model.Workspace) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.timeNow().UTC()
+	alerts := []alert{}
+	newFiring := map[string]alert{}
+	seen := map[string]bool{}
+	for _, wk := range wks {
+		seen[wk.ID] = true
+
+		var a *alert
+		switch wk.DriftState() {
+		case model.DriftStateDrift:
+			a = n.newAlert(wk, alertNameDrift, "Drift detected on Terraform workspace %s/%s", now)
+		case model.DriftStateError:
+			a = n.newAlert(wk, alertNameDriftPlanError, "Drift detection plan failed on Terraform workspace %s/%s", now)
+		case model.DriftStateWaiting, model.DriftStateUnknown:
+			// We don't know the state, maintain the firing ones until next refresh.
+			if prev, ok := n.firing[wk.ID]; ok {
+				newFiring[wk.ID] = prev
+			}
+			continue
+		}
+
+		prev, wasFiring := n.firing[wk.ID]
+
+		// If the alert changed (e.g new run), the old one needs to be resolved.
+		if wasFiring && (a == nil || !sameLabels(prev.Labels, a.Labels)) {
+			prev.EndsAt = &now
+			alerts = append(alerts, prev)
+		}
+
+		if a != nil {
+			if wasFiring && sameLabels(prev.Labels, a.Labels) {
+				a.StartsAt = prev.StartsAt
+			}
+			alerts = append(alerts, *a)
+			newFiring[wk.ID] = *a
+		}
+	}
+
+	// Resolve the alerts of the workspaces that are gone.
+	for id, prev := range n.firing {
+		if !seen[id] {
+			prev.EndsAt = &now
+			alerts = append(alerts, prev)
+		}
+	}
+
+	if len(alerts) > 0 {
+		err := n.send(ctx, alerts)
+		if err != nil {
+			return fmt.Errorf("could not send alerts: %w", err)
+		}
+	}
+
+	n.firing = newFiring
+	n.logger.WithValues(log.Kv{"firing": len(newFiring), "sent": len(alerts)}).Debugf("Alerts sent")
+
+	return nil
+}
+
+func (n *Notifier) newAlert(wk model.Workspace, name, summaryFmt string, now time.Time) *alert {
+	tags := append([]string{}, wk.Tags...)
+	sort.Strings(tags)
+
+	labels := map[string]string{}
+	for k, v := range n.extraLabels {
+		labels[k] = v
+	}
+	labels["alertname"] = name
+	labels["workspace_name"] = wk.Name
+	labels["workspace_id"] = wk.ID
+	labels["organization_name"] = wk.Org
+	labels["tags"] = strings.Join(tags, ",")
+	labels["run_url"] = wk.LastDriftPlan.URL
+
+	a := &alert{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf(summaryFmt, wk.Org, wk.Name),
+			"run_id":  wk.LastDriftPlan.ID,
+			"run_url": wk.LastDriftPlan.URL,
+		},
+		StartsAt:     &now,
+		GeneratorURL: wk.LastDriftPlan.URL,
+	}
+
+	if n.alertTTL > 0 {
+		endsAt := now.Add(n.alertTTL)
+		a.EndsAt = &endsAt
+	}
+
+	return a
+}
+
+func (n *Notifier) send(ctx context.Context, alerts []alert) error {
+	data, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("could not marshal alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url+"/api/v2/alerts", bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("alertmanager returned %d status code: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
+
+func sameLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,125 @@
+package alertmanager_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/notify/alertmanager"
+)
+
+type fakeAlert struct {
+	Labels   map[string]string `json:"labels"`
+	StartsAt time.Time         `json:"startsAt"`
+	EndsAt   time.Time         `json:"endsAt"`
+}
+
+func TestNotifier(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t0, _ := time.Parse(time.RFC3339, "2023-01-10T10:00:00Z")
+	now := t0
+
+	var got []fakeAlert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/alerts" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		got = nil
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n, err := alertmanager.NewNotifier(alertmanager.NotifierConfig{
+		Logger:      log.Noop,
+		URL:         srv.URL,
+		AlertTTL:    time.Hour,
+		ExtraLabels: map[string]string{"team": "platform"},
+		TimeNow:     func() time.Time { return now },
+	})
+	require.NoError(err)
+
+	wk1 := model.Workspace{ID: "ws-1", Name: "wk1", Org: "org", Tags: []string{"b", "a"}, LastDriftPlan: &model.Plan{ID: "run-1", URL: "https://tfe.test/run-1", Status: model.PlanStatusFinishedOK, HasChanges: true}}
+	wk2 := model.Workspace{ID: "ws-2", Name: "wk2", Org: "org", LastDriftPlan: &model.Plan{ID: "run-2", URL: "https://tfe.test/run-2", Status: model.PlanStatusFinishedNotOK}}
+	wk3 := model.Workspace{ID: "ws-3", Name: "wk3", Org: "org", LastDriftPlan: &model.Plan{ID: "run-3", URL: "https://tfe.test/run-3", Status: model.PlanStatusFinishedOK}}
+
+	// First cycle should fire drifted and errored workspaces.
+	err = n.Notify(context.TODO(), []model.Workspace{wk1, wk2, wk3})
+	require.NoError(err)
+	require.Len(got, 2)
+	assert.Equal(map[string]string{
+		"alertname":         "TerraformWorkspaceDrift",
+		"workspace_name":    "wk1",
+		"workspace_id":      "ws-1",
+		"organization_name": "org",
+		"tags":              "a,b",
+		"run_url":           "https://tfe.test/run-1",
+		"team":              "platform",
+	}, got[0].Labels)
+	assert.Equal(t0, got[0].StartsAt)
+	assert.Equal(t0.Add(time.Hour), got[0].EndsAt)
+	assert.Equal("TerraformWorkspaceDriftPlanError", got[1].Labels["alertname"])
+
+	// Second cycle should refresh the firing ones maintaining the start.
+	now = t0.Add(5 * time.Minute)
+	err = n.Notify(context.TODO(), []model.Workspace{wk1, wk2, wk3})
+	require.NoError(err)
+	require.Len(got, 2)
+	assert.Equal(t0, got[0].StartsAt)
+	assert.Equal(now.Add(time.Hour), got[0].EndsAt)
+
+	// Third cycle should resolve the fixed and the gone ones.
+	now = t0.Add(10 * time.Minute)
+	wk1.LastDriftPlan = &model.Plan{ID: "run-4", URL: "https://tfe.test/run-4", Status: model.PlanStatusFinishedOK}
+	err = n.Notify(context.TODO(), []model.Workspace{wk1, wk3})
+	require.NoError(err)
+	require.Len(got, 2)
+	assert.Equal("wk1", got[0].Labels["workspace_name"])
+	assert.Equal(now, got[0].EndsAt)
+	assert.Equal("wk2", got[1].Labels["workspace_name"])
+	assert.Equal(now, got[1].EndsAt)
+
+	// A new drift run should resolve the old alert and fire a new one.
+	wk1.LastDriftPlan = &model.Plan{ID: "run-5", URL: "https://tfe.test/run-5", Status: model.PlanStatusFinishedOK, HasChanges: true}
+	err = n.Notify(context.TODO(), []model.Workspace{wk1})
+	require.NoError(err)
+	wk1.LastDriftPlan = &model.Plan{ID: "run-6", URL: "https://tfe.test/run-6", Status: model.PlanStatusFinishedOK, HasChanges: true}
+	err = n.Notify(context.TODO(), []model.Workspace{wk1})
+	require.NoError(err)
+	require.Len(got, 2)
+	assert.Equal("https://tfe.test/run-5", got[0].Labels["run_url"])
+	assert.Equal(now, got[0].EndsAt)
+	assert.Equal("https://tfe.test/run-6", got[1].Labels["run_url"])
+}
+
+func TestNotifierWithoutAlertTTL(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	var got []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	n, err := alertmanager.NewNotifier(alertmanager.NotifierConfig{Logger: log.Noop, URL: srv.URL})
+	require.NoError(err)
+
+	// Firing alerts without TTL should not set the end, so Alertmanager uses its resolve timeout.
+	wk := model.Workspace{ID: "ws-1", Name: "wk1", Org: "org", LastDriftPlan: &model.Plan{ID: "run-1", Status: model.PlanStatusFinishedOK, HasChanges: true}}
+	err = n.Notify(context.TODO(), []model.Workspace{wk})
+	require.NoError(err)
+	require.Len(got, 1)
+	assert.Contains(got[0], "startsAt")
+	assert.NotContains(got[0], "endsAt")
+}
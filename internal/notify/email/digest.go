@@ -0,0 +1,114 @@
+package email
+
+import (
+	htmltemplate "html/template"
+	"sort"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+type digestWorkspace struct {
+	Name   string
+	Org    string
+	Tags   []string
+	RunID  string
+	RunURL string
+}
+
+type digestTotals struct {
+	Total   int
+	OK      int
+	Drift   int
+	Error   int
+	Unknown int
+}
+
+type digest struct {
+	Drifted   []digestWorkspace
+	Errored   []digestWorkspace
+	Totals    digestTotals
+	CreatedAt time.Time
+}
+
+func newDigest(wks []model.Workspace, now time.Time) digest {
+	d := digest{CreatedAt: now.UTC()}
+	for _, wk := range wks {
+		dwk := digestWorkspace{
+			Name: wk.Name,
+			Org:  wk.Org,
+			Tags: wk.Tags,
+		}
+		if wk.LastDriftPlan != nil {
+			dwk.RunID = wk.LastDriftPlan.ID
+			dwk.RunURL = wk.LastDriftPlan.URL
+		}
+
+		d.Totals.Total++
+		switch wk.DriftState() {
+		case model.DriftStateDrift:
+			d.Totals.Drift++
+			d.Drifted = append(d.Drifted, dwk)
+		case model.DriftStateError:
+			d.Totals.Error++
+			d.Errored = append(d.Errored, dwk)
+		case model.DriftStateOK:
+			d.Totals.OK++
+		default:
+			d.Totals.Unknown++
+		}
+	}
+
+	sort.SliceStable(d.Drifted, func(i, j int) bool { return d.Drifted[i].Name < d.Drifted[j].Name })
+	sort.SliceStable(d.Errored, func(i, j int) bool { return d.Errored[i].Name < d.Errored[j].Name })
+
+	return d
+}
+
+var textTpl = texttemplate.Must(texttemplate.New("text").Parse(`Terraform drift detection digest ({{ .CreatedAt.Format "2006-01-02 15:04 MST" }})
+
+Workspaces: {{ .Totals.Total }} | OK: {{ .Totals.OK }} | Drift: {{ .Totals.Drift }} | Errors: {{ .Totals.Error }} | Unknown: {{ .Totals.Unknown }}
+{{ if .Drifted }}
+Drifted workspaces:
+{{ range .Drifted }}
+- {{ .Name }}{{ if .RunURL }}: {{ .RunURL }}{{ end }}{{ end }}
+{{ end }}{{ if .Errored }}
+Failed drift detection plans:
+{{ range .Errored }}
+- {{ .Name }}{{ if .RunURL }}: {{ .RunURL }}{{ end }}{{ end }}
+{{ end }}{{ if and (not .Drifted) (not .Errored) }}
+No drift detected.
+{{ end }}`))
+
+var htmlTpl = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif;">
+<h2>Terraform drift detection digest</h2>
+<p>{{ .CreatedAt.Format "2006-01-02 15:04 MST" }}</p>
+<table cellpadding="6" style="border-collapse: collapse;">
+<tr><th align="left">Workspaces</th><th align="left">OK</th><th align="left">Drift</th><th align="left">Errors</th><th align="left">Unknown</th></tr>
+<tr><td>{{ .Totals.Total }}</td><td>{{ .Totals.OK }}</td><td>{{ .Totals.Drift }}</td><td>{{ .Totals.Error }}</td><td>{{ .Totals.Unknown }}</td></tr>
+</table>
+{{- if .Drifted }}
+<h3>Drifted workspaces</h3>
+<ul>
+{{- range .Drifted }}
+<li>{{ if .RunURL }}<a href="{{ .RunURL }}">{{ .Name }}</a>{{ else }}{{ .Name }}{{ end }}{{ if .Tags }} <small>({{ range $i, $t := .Tags }}{{ if $i }}, {{ end }}{{ $t }}{{ end }})</small>{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if .Errored }}
+<h3>Failed drift detection plans</h3>
+<ul>
+{{- range .Errored }}
+<li>{{ if .RunURL }}<a href="{{ .RunURL }}">{{ .Name }}</a>{{ else }}{{ .Name }}{{ end }}{{ if .Tags }} <small>({{ range $i, $t := .Tags }}{{ if $i }}, {{ end }}{{ $t }}{{ end }})</small>{{ end }}</li>
+{{- end }}
+</ul>
+{{- end }}
+{{- if and (not .Drifted) (not .Errored) }}
+<p>No drift detected.</p>
+{{- end }}
+</body>
+</html>
+`))
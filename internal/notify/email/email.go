@@ -0,0 +1,257 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+// NotifierConfig is the configuration of the SMTP email notifier.
+type NotifierConfig struct {
+	Logger log.Logger
+	// Address is the SMTP server address in `host:port` form.
+	Address  string
+	Username string
+	Password string
+	From     string
+	To       []string
+	Subject  string
+	// DisableStartTLS will send the email without STARTTLS, mainly used with local SMTP servers.
+	DisableStartTLS bool
+	// TLSInsecureSkipVerify will not verify the SMTP server certificate.
+	TLSInsecureSkipVerify bool
+	Timeout               time.Duration
+}
+
+func (c *NotifierConfig) defaults() error {
+	if c.Address == "" {
+		return fmt.Errorf("smtp address is required")
+	}
+
+	if _, _, err := net.SplitHostPort(c.Address); err != nil {
+		return fmt.Errorf("invalid smtp address: %w", err)
+	}
+
+	if c.From == "" {
+		return fmt.Errorf("from address is required")
+	}
+
+	if len(c.To) == 0 {
+		return fmt.Errorf("at least one destination address is required")
+	}
+
+	if c.Subject == "" {
+		c.Subject = "Terraform drift detection digest"
+	}
+
+	if c.Timeout == 0 {
+		c.Timeout = 30 * time.Second
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "email.Notifier"})
+
+	return nil
+}
+
+// Notifier knows how to send a drift digest by email using SMTP.
+type Notifier struct {
+	logger                log.Logger
+	address               string
+	host                  string
+	username              string
+	password              string
+	from                  string
+	to                    []string
+	subject               string
+	disableStartTLS       bool
+	tlsInsecureSkipVerify bool
+	timeout               time.Duration
+	timeNow               func() time.Time
+}
+
+// NewNotifier returns a new email notifier.
+func NewNotifier(config NotifierConfig) (*Notifier, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	host, _, _ := net.SplitHostPort(config.Address)
+
+	return &Notifier{
+		logger:                config.Logger,
+		address:               config.Address,
+		host:                  host,
+		username:              config.Username,
+		password:              config.Password,
+		from:                  config.From,
+		to:                    config.To,
+		subject:               config.Subject,
+		disableStartTLS:       config.DisableStartTLS,
+		tlsInsecureSkipVerify: config.TLSInsecureSkipVerify,
+		timeout:               config.Timeout,
+		timeNow:               time.Now,
+	}, nil
+}
+
+// Notify will render the digest of the workspaces and send it by email.
+func (n Notifier) Notify(ctx context.Context, wks []model.Workspace) error {
+	d := newDigest(wks, n.timeNow())
+
+	msg, err := n.renderMessage(d)
+	if err != nil {
+		return fmt.Errorf("could not render email: %w", err)
+	}
+
+	err = n.send(ctx, msg)
+	if err != nil {
+		return fmt.Errorf("could not send email: %w", err)
+	}
+
+	n.logger.WithValues(log.Kv{"drift": len(d.Drifted), "errored": len(d.Errored)}).Infof("Drift digest email sent")
+
+	return nil
+}
+
+func (n Notifier) renderMessage(d digest) ([]byte, error) {
+	var text, html bytes.Buffer
+	if err := textTpl.Execute(&text, d); err != nil {
+		return nil, fmt.Errorf("could not render plain text body: %w", err)
+	}
+	if err := htmlTpl.Execute(&html, d); err != nil {
+		return nil, fmt.Errorf("could not render HTML body: %w", err)
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	subject := n.subject
+	if d.Totals.Drift > 0 || d.Totals.Error > 0 {
+		subject = fmt.Sprintf("%s (%d drift, %d errors)", subject, d.Totals.Drift, d.Totals.Error)
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", d.CreatedAt.Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n", boundary)
+	fmt.Fprintf(&b, "\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		body        []byte
+	}{
+		{contentType: "text/plain; charset=utf-8", body: text.Bytes()},
+		{contentType: "text/html; charset=utf-8", body: html.Bytes()},
+	} {
+		fmt.Fprintf(&b, "--%s\r\n", boundary)
+		fmt.Fprintf(&b, "Content-Type: %s\r\n", part.contentType)
+		fmt.Fprintf(&b, "Content-Transfer-Encoding: quoted-printable\r\n")
+		fmt.Fprintf(&b, "\r\n")
+		qp := quotedprintable.NewWriter(&b)
+		if _, err := qp.Write(part.body); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\r\n")
+	}
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+
+	return b.Bytes(), nil
+}
+
+func (n Notifier) send(ctx context.Context, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", n.address)
+	if err != nil {
+		return fmt.Errorf("could not connect to smtp server: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("could not create smtp client: %w", err)
+	}
+	defer c.Close()
+
+	if !n.disableStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server doesn't support STARTTLS")
+		}
+
+		// #nosec G402 -- Skipping verification is an explicit user option.
+		err := c.StartTLS(&tls.Config{ServerName: n.host, InsecureSkipVerify: n.tlsInsecureSkipVerify})
+		if err != nil {
+			return fmt.Errorf("could not start TLS: %w", err)
+		}
+	}
+
+	if n.username != "" {
+		err := c.Auth(smtp.PlainAuth("", n.username, n.password, n.host))
+		if err != nil {
+			return fmt.Errorf("could not authenticate: %w", err)
+		}
+	}
+
+	if err := c.Mail(n.from); err != nil {
+		return fmt.Errorf("could not set sender: %w", err)
+	}
+
+	for _, to := range n.to {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("could not set recipient %q: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("could not start data: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("could not write data: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("could not finish data: %w", err)
+	}
+
+	return c.Quit()
+}
+
+func randomBoundary() (string, error) {
+	var buf [16]byte
+	_, err := rand.Read(buf[:])
+	if err != nil {
+		return "", fmt.Errorf("could not generate mime boundary: %w", err)
+	}
+
+	return "tfe-drift-" + hex.EncodeToString(buf[:]), nil
+}
@@ -0,0 +1,191 @@
+package email_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime/quotedprintable"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/notify/email"
+)
+
+// fakeSMTPServer is a minimal SMTP server that stores the received messages.
+type fakeSMTPServer struct {
+	ln       net.Listener
+	messages chan string
+	auths    chan string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeSMTPServer{ln: ln, messages: make(chan string, 10), auths: make(chan string, 10)}
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeSMTPServer) addr() string { return s.ln.Addr().String() }
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	write := func(l string) { _, _ = conn.Write([]byte(l + "\r\n")) }
+
+	write("220 localhost fake SMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			write("250-localhost")
+			write("250 AUTH PLAIN")
+		case strings.HasPrefix(cmd, "AUTH"):
+			s.auths <- strings.TrimSpace(line)
+			write("235 Authentication successful")
+		case strings.HasPrefix(cmd, "MAIL"), strings.HasPrefix(cmd, "RCPT"):
+			write("250 OK")
+		case cmd == "DATA":
+			write("354 Go ahead")
+			var msg strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			s.messages <- msg.String()
+			write("250 OK")
+		case cmd == "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}
+
+func TestNotifier(t *testing.T) {
+	wks := []model.Workspace{
+		{Name: "wk1", LastDriftPlan: &model.Plan{ID: "run-1", URL: "https://tfe.test/run-1", Status: model.PlanStatusFinishedOK, HasChanges: true}},
+		{Name: "wk2", LastDriftPlan: &model.Plan{ID: "run-2", URL: "https://tfe.test/run-2", Status: model.PlanStatusFinishedNotOK}},
+		{Name: "wk3", LastDriftPlan: &model.Plan{ID: "run-3", URL: "https://tfe.test/run-3", Status: model.PlanStatusFinishedOK}},
+	}
+
+	tests := map[string]struct {
+		config     func(addr string) email.NotifierConfig
+		workspaces []model.Workspace
+		expAuth    bool
+		expHeaders []string
+		expContain []string
+		expErr     bool
+	}{
+		"Requiring STARTTLS on a server without it should fail.": {
+			config: func(addr string) email.NotifierConfig {
+				return email.NotifierConfig{Address: addr, From: "a@test.dev", To: []string{"b@test.dev"}}
+			},
+			workspaces: wks,
+			expErr:     true,
+		},
+
+		"Sending a digest should render the drifted and errored workspaces.": {
+			config: func(addr string) email.NotifierConfig {
+				return email.NotifierConfig{Address: addr, From: "a@test.dev", To: []string{"b@test.dev"}, DisableStartTLS: true}
+			},
+			workspaces: wks,
+			expContain: []string{
+				"Subject: Terraform drift detection digest (1 drift, 1 errors)",
+				"Content-Type: text/plain; charset=utf-8",
+				"Content-Type: text/html; charset=utf-8",
+				"- wk1: https://tfe.test/run-1",
+				"- wk2: https://tfe.test/run-2",
+				`<a href="https://tfe.test/run-1">wk1</a>`,
+				`<a href="https://tfe.test/run-2">wk2</a>`,
+			},
+		},
+
+		"Sending a digest with credentials should authenticate.": {
+			config: func(addr string) email.NotifierConfig {
+				return email.NotifierConfig{Address: strings.Replace(addr, "127.0.0.1", "localhost", 1), Username: "user", Password: "pass", From: "a@test.dev", To: []string{"b@test.dev"}, DisableStartTLS: true}
+			},
+			workspaces: []model.Workspace{wks[2]},
+			expAuth:    true,
+			expContain: []string{
+				"Subject: Terraform drift detection digest\r\n",
+				"No drift detected.",
+			},
+		},
+
+		"Sending a digest with a non ASCII subject should encode it.": {
+			config: func(addr string) email.NotifierConfig {
+				return email.NotifierConfig{Address: addr, From: "a@test.dev", To: []string{"b@test.dev"}, Subject: "Detección de drift", DisableStartTLS: true}
+			},
+			workspaces: []model.Workspace{wks[2]},
+			expHeaders: []string{
+				"Subject: =?utf-8?q?Detecci=C3=B3n_de_drift?=\r\n",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			s := newFakeSMTPServer(t)
+			config := test.config(s.addr())
+			config.Logger = log.Noop
+			n, err := email.NewNotifier(config)
+			require.NoError(err)
+
+			err = n.Notify(context.TODO(), test.workspaces)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			if test.expAuth {
+				assert.Contains(<-s.auths, "AUTH PLAIN")
+			}
+
+			msg := <-s.messages
+			for _, exp := range test.expHeaders {
+				assert.Contains(msg, exp)
+			}
+
+			decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(msg)))
+			require.NoError(err)
+			for _, exp := range test.expContain {
+				assert.Contains(string(decoded), exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+const (
+	// DefaultAPIURL is the GitHub public API URL.
+	DefaultAPIURL = "https://api.github.com"
+
+	markerFmt   = "<!-- tfe-drift:workspace:%s/%s -->"
+	issueTitleF = "Drift detected on Terraform workspace %s/%s"
+)
+
+// NotifierConfig is the configuration of the GitHub issues notifier.
+type NotifierConfig struct {
+	Logger log.Logger
+	// APIURL is the GitHub API base URL, used for GitHub Enterprise or testing.
+	APIURL string
+	Token  string
+	// RepositoryRules are the rules used to select the repository (`owner/repo`) of the workspace issue,
+	// first rule that matches will be used, if none matches, the workspace will be ignored.
+	RepositoryRules []selector.Rule
+	// LabelRules are the rules used to set labels (comma separated) on the issues, all matching rules will be used.
+	LabelRules []selector.Rule
+	// MarkerLabel is the label that all the issues managed by tfe-drift will have.
+	MarkerLabel string
+	HTTPClient  *http.Client
+}
+
+func (c *NotifierConfig) defaults() error {
+	if c.Token == "" {
+		return fmt.Errorf("token is required")
+	}
+
+	if len(c.RepositoryRules) == 0 {
+		return fmt.Errorf("at least one repository rule is required")
+	}
+
+	for _, r := range c.RepositoryRules {
+		if len(strings.Split(r.Value, "/")) != 2 {
+			return fmt.Errorf("invalid repository %q, must be `owner/repo`", r.Value)
+		}
+	}
+
+	if c.APIURL == "" {
+		c.APIURL = DefaultAPIURL
+	}
+	c.APIURL = strings.TrimSuffix(c.APIURL, "/")
+
+	if c.MarkerLabel == "" {
+		c.MarkerLabel = "tfe-drift"
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "github.Notifier"})
+
+	return nil
+}
+
+// Notifier manages the lifecycle of GitHub issues for drifted workspaces:
+//
+//   - Opens an issue when a workspace has drift.
+//   - Comments on the open issue when the drift persists.
+//   - Closes the issue when the drift detection plan is clean.
+type Notifier struct {
+	logger          log.Logger
+	apiURL          string
+	token           string
+	repositoryRules []selector.Rule
+	labelRules      []selector.Rule
+	markerLabel     string
+	client          *http.Client
+}
+
+// NewNotifier returns a new GitHub issues notifier.
+func NewNotifier(config NotifierConfig) (*Notifier, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Notifier{
+		logger:          config.Logger,
+		apiURL:          config.APIURL,
+		token:           config.Token,
+		repositoryRules: config.RepositoryRules,
+		labelRules:      config.LabelRules,
+		markerLabel:     config.MarkerLabel,
+		client:          config.HTTPClient,
+	}, nil
+}
+
+type issue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	URL    string `json:"html_url"`
+}
+
+// Notify will open, comment or close the workspace issues based on the workspace drift state.
+func (n Notifier) Notify(ctx context.Context, wks []model.Workspace) error {
+	// Cache the open issues per repository so we only list them once.
+	openIssues := map[string][]issue{}
+
+	var errs []string
+	for _, wk := range wks {
+		state := wk.DriftState()
+		if state != model.DriftStateDrift && state != model.DriftStateOK {
+			continue
+		}
+
+		rule, ok := selector.FirstMatch(n.repositoryRules, wk)
+		if !ok {
+			continue
+		}
+		repo := rule.Value
+		logger := n.logger.WithValues(log.Kv{"workspace": wk.Name, "repository": repo})
+
+		issues, ok := openIssues[repo]
+		if !ok {
+			var err error
+			issues, err = n.listOpenIssues(ctx, repo)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("could not list %q issues: %s", repo, err))
+				continue
+			}
+			openIssues[repo] = issues
+		}
+
+		marker := fmt.Sprintf(markerFmt, wk.Org, wk.ID)
+		var current *issue
+		for i := range issues {
+			if strings.Contains(issues[i].Body, marker) {
+				current = &issues[i]
+				break
+			}
+		}
+
+		switch {
+		case state == model.DriftStateDrift && current == nil:
+			is, err := n.createIssue(ctx, repo, wk, marker)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("could not create %q workspace issue: %s", wk.Name, err))
+				continue
+			}
+			openIssues[repo] = append(openIssues[repo], *is)
+			logger.WithValues(log.Kv{"issue": is.Number}).Infof("Drift issue opened")
+
+		case state == model.DriftStateDrift:
+			body := fmt.Sprintf("Drift still detected on workspace `%s`: %s", wk.Name, wk.LastDriftPlan.URL)
+			err := n.comment(ctx, repo, current.Number, body)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("could not comment %q workspace issue: %s", wk.Name, err))
+				continue
+			}
+			logger.WithValues(log.Kv{"issue": current.Number}).Infof("Drift issue updated")
+
+		case state == model.DriftStateOK && current != nil:
+			body := fmt.Sprintf("Drift resolved on workspace `%s`, latest drift detection plan is clean: %s", wk.Name, wk.LastDriftPlan.URL)
+			err := n.comment(ctx, repo, current.Number, body)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("could not comment %q workspace issue: %s", wk.Name, err))
+				continue
+			}
+
+			err = n.closeIssue(ctx, repo, current.Number)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("could not close %q workspace issue: %s", wk.Name, err))
+				continue
+			}
+			logger.WithValues(log.Kv{"issue": current.Number}).Infof("Drift issue closed")
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (n Notifier) listOpenIssues(ctx context.Context, repo string) ([]issue, error) {
+	all := []issue{}
+	for page := 1; ; page++ {
+		path := fmt.Sprintf("/repos/%s/issues?state=open&labels=%s&per_page=100&page=%d", repo, url.QueryEscape(n.markerLabel), page)
+		issues := []issue{}
+		err := n.do(ctx, http.MethodGet, path, nil, &issues)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, issues...)
+		if len(issues) < 100 {
+			return all, nil
+		}
+	}
+}
+
+func (n Notifier) createIssue(ctx context.Context, repo string, wk model.Workspace, marker string) (*issue, error) {
+	labels := map[string]struct{}{n.markerLabel: {}}
+	for _, r := range selector.AllMatches(n.labelRules, wk) {
+		for _, l := range strings.Split(r.Value, ",") {
+			if l = strings.TrimSpace(l); l != "" {
+				labels[l] = struct{}{}
+			}
+		}
+	}
+	labelList := make([]string, 0, len(labels))
+	for l := range labels {
+		labelList = append(labelList, l)
+	}
+	sort.Strings(labelList)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Drift has been detected on Terraform workspace `%s` (organization `%s`).\n\n", wk.Name, wk.Org)
+	fmt.Fprintf(&b, "- Drift detection run: %s\n", wk.LastDriftPlan.URL)
+	if len(wk.Tags) > 0 {
+		fmt.Fprintf(&b, "- Tags: `%s`\n", strings.Join(wk.Tags, "`, `"))
+	}
+	fmt.Fprintf(&b, "\nThis issue will be closed automatically when a drift detection plan comes back clean.\n\n%s\n", marker)
+
+	req := map[string]any{
+		"title":  fmt.Sprintf(issueTitleF, wk.Org, wk.Name),
+		"body":   b.String(),
+		"labels": labelList,
+	}
+
+	is := &issue{}
+	err := n.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues", repo), req, is)
+	if err != nil {
+		return nil, err
+	}
+
+	return is, nil
+}
+
+func (n Notifier) comment(ctx context.Context, repo string, number int, body string) error {
+	return n.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number), map[string]any{"body": body}, nil)
+}
+
+func (n Notifier) closeIssue(ctx context.Context, repo string, number int) error {
+	req := map[string]any{"state": "closed", "state_reason": "completed"}
+	return n.do(ctx, http.MethodPatch, fmt.Sprintf("/repos/%s/issues/%d", repo, number), req, nil)
+}
+
+func (n Notifier) do(ctx context.Context, method, path string, body any, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("could not marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, n.apiURL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+n.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s returned %d status code: %s", method, path, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(out)
+	if err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+
+	return nil
+}
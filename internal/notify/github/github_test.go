@@ -0,0 +1,234 @@
+package github_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/notify/github"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+type fakeIssue struct {
+	Number   int      `json:"number"`
+	Title    string   `json:"title"`
+	Body     string   `json:"body"`
+	Labels   []string `json:"-"`
+	State    string   `json:"-"`
+	Comments []string `json:"-"`
+}
+
+// fakeGitHub is a minimal in-memory GitHub issues API.
+type fakeGitHub struct {
+	mu     sync.Mutex
+	issues map[string][]*fakeIssue
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	// /repos/{owner}/{repo}/issues[/{number}[/comments]]
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 4 || parts[0] != "repos" || parts[3] != "issues" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	repo := parts[1] + "/" + parts[2]
+
+	var req struct {
+		Title  string   `json:"title"`
+		Body   string   `json:"body"`
+		Labels []string `json:"labels"`
+		State  string   `json:"state"`
+	}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&req)
+	}
+
+	switch {
+	case len(parts) == 4 && r.Method == http.MethodGet:
+		res := []*fakeIssue{}
+		for _, is := range f.issues[repo] {
+			if is.State == "open" && contains(is.Labels, r.URL.Query().Get("labels")) {
+				res = append(res, is)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(res)
+
+	case len(parts) == 4 && r.Method == http.MethodPost:
+		is := &fakeIssue{Number: len(f.issues[repo]) + 1, Title: req.Title, Body: req.Body, Labels: req.Labels, State: "open"}
+		f.issues[repo] = append(f.issues[repo], is)
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(is)
+
+	case len(parts) == 5 && r.Method == http.MethodPatch:
+		is := f.issue(repo, parts[4])
+		is.State = req.State
+		_ = json.NewEncoder(w).Encode(is)
+
+	case len(parts) == 6 && r.Method == http.MethodPost:
+		is := f.issue(repo, parts[4])
+		is.Comments = append(is.Comments, req.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (f *fakeGitHub) issue(repo, number string) *fakeIssue {
+	for _, is := range f.issues[repo] {
+		if fmt.Sprintf("%d", is.Number) == number {
+			return is
+		}
+	}
+	return &fakeIssue{}
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func newPlan(id string, drift bool) *model.Plan {
+	return &model.Plan{ID: id, URL: "https://tfe.test/" + id, Status: model.PlanStatusFinishedOK, HasChanges: drift}
+}
+
+func TestNotifierIssueLifecycle(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	gh := &fakeGitHub{issues: map[string][]*fakeIssue{}}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	repoRules, err := selector.ParseRules([]string{"tag:team-a=org/repo-a", "org/repo-default"})
+	require.NoError(err)
+	labelRules, err := selector.ParseRules([]string{"tag:prod=severity/high,env/prod", "name:^wk=drift"})
+	require.NoError(err)
+
+	n, err := github.NewNotifier(github.NotifierConfig{
+		Logger:          log.Noop,
+		APIURL:          srv.URL,
+		Token:           "test-token",
+		RepositoryRules: repoRules,
+		LabelRules:      labelRules,
+	})
+	require.NoError(err)
+
+	wk1 := model.Workspace{ID: "ws-1", Name: "wk1", Org: "org", Tags: []string{"team-a", "prod"}}
+	wk2 := model.Workspace{ID: "ws-2", Name: "wk2", Org: "org"}
+
+	// Drift on both should open issues on the correct repositories.
+	wk1.LastDriftPlan = newPlan("run-1", true)
+	wk2.LastDriftPlan = newPlan("run-2", true)
+	err = n.Notify(context.TODO(), []model.Workspace{wk1, wk2})
+	require.NoError(err)
+
+	require.Len(gh.issues["org/repo-a"], 1)
+	require.Len(gh.issues["org/repo-default"], 1)
+	is1 := gh.issues["org/repo-a"][0]
+	assert.Equal("Drift detected on Terraform workspace org/wk1", is1.Title)
+	assert.Contains(is1.Body, "<!-- tfe-drift:workspace:org/ws-1 -->")
+	assert.Contains(is1.Body, "https://tfe.test/run-1")
+	assert.Equal([]string{"drift", "env/prod", "severity/high", "tfe-drift"}, is1.Labels)
+	is2 := gh.issues["org/repo-default"][0]
+	assert.Equal([]string{"drift", "tfe-drift"}, is2.Labels)
+
+	// Persisting drift should comment, clean plan should close.
+	wk1.LastDriftPlan = newPlan("run-3", true)
+	wk2.LastDriftPlan = newPlan("run-4", false)
+	err = n.Notify(context.TODO(), []model.Workspace{wk1, wk2})
+	require.NoError(err)
+
+	require.Len(gh.issues["org/repo-a"], 1)
+	assert.Equal("open", is1.State)
+	require.Len(is1.Comments, 1)
+	assert.Contains(is1.Comments[0], "https://tfe.test/run-3")
+	assert.Equal("closed", is2.State)
+	require.Len(is2.Comments, 1)
+	assert.Contains(is2.Comments[0], "https://tfe.test/run-4")
+
+	// Drift again after closed should open a new issue, errors should be ignored.
+	wk2.LastDriftPlan = newPlan("run-5", true)
+	wk1.LastDriftPlan = &model.Plan{ID: "run-6", Status: model.PlanStatusFinishedNotOK}
+	err = n.Notify(context.TODO(), []model.Workspace{wk1, wk2})
+	require.NoError(err)
+
+	assert.Len(gh.issues["org/repo-default"], 2)
+	assert.Len(is1.Comments, 1)
+}
+
+func TestNotifierMarkerLabelWithSpecialChars(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	gh := &fakeGitHub{issues: map[string][]*fakeIssue{}}
+	srv := httptest.NewServer(gh)
+	defer srv.Close()
+
+	repoRules, err := selector.ParseRules([]string{"org/repo"})
+	require.NoError(err)
+
+	n, err := github.NewNotifier(github.NotifierConfig{
+		Logger:          log.Noop,
+		APIURL:          srv.URL,
+		Token:           "test-token",
+		RepositoryRules: repoRules,
+		MarkerLabel:     "tfe drift&managed",
+	})
+	require.NoError(err)
+
+	// Persisting drift should find the issue by the marker label and comment on it.
+	wk := model.Workspace{ID: "ws-1", Name: "wk1", Org: "org", LastDriftPlan: newPlan("run-1", true)}
+	err = n.Notify(context.TODO(), []model.Workspace{wk})
+	require.NoError(err)
+	wk.LastDriftPlan = newPlan("run-2", true)
+	err = n.Notify(context.TODO(), []model.Workspace{wk})
+	require.NoError(err)
+
+	require.Len(gh.issues["org/repo"], 1)
+	assert.Equal([]string{"tfe drift&managed"}, gh.issues["org/repo"][0].Labels)
+	assert.Len(gh.issues["org/repo"][0].Comments, 1)
+}
+
+func TestNotifierErrors(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	srv := httptest.NewServer(&fakeGitHub{issues: map[string][]*fakeIssue{}})
+	defer srv.Close()
+
+	repoRules, err := selector.ParseRules([]string{"org/repo"})
+	require.NoError(err)
+
+	n, err := github.NewNotifier(github.NotifierConfig{APIURL: srv.URL, Token: "wrong", RepositoryRules: repoRules})
+	require.NoError(err)
+
+	err = n.Notify(context.TODO(), []model.Workspace{{ID: "ws-1", Name: "wk1", LastDriftPlan: newPlan("run-1", true)}})
+	assert.Error(err)
+
+	_, err = github.NewNotifier(github.NotifierConfig{Token: "test", RepositoryRules: []selector.Rule{{Value: "invalid"}}})
+	assert.Error(err)
+}
@@ -0,0 +1,217 @@
+package pagerduty
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/info"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+const (
+	// DefaultEventsURL is the PagerDuty Events API v2 URL.
+	DefaultEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+	dedupKeyFmt = "tfe-drift/%s/%s"
+)
+
+var validSeverities = map[string]bool{"critical": true, "error": true, "warning": true, "info": true}
+
+// NotifierConfig is the configuration of the PagerDuty notifier.
+type NotifierConfig struct {
+	Logger log.Logger
+	// EventsURL is the PagerDuty Events API v2 URL, used for testing.
+	EventsURL  string
+	RoutingKey string
+	// SeverityRules are the rules used to select the severity of the workspace events,
+	// first rule that matches will be used.
+	SeverityRules   []selector.Rule
+	DefaultSeverity string
+	HTTPClient      *http.Client
+}
+
+func (c *NotifierConfig) defaults() error {
+	if c.RoutingKey == "" {
+		return fmt.Errorf("routing key is required")
+	}
+
+	if c.EventsURL == "" {
+		c.EventsURL = DefaultEventsURL
+	}
+
+	if c.DefaultSeverity == "" {
+		c.DefaultSeverity = "error"
+	}
+
+	if !validSeverities[c.DefaultSeverity] {
+		return fmt.Errorf("invalid default severity %q", c.DefaultSeverity)
+	}
+
+	for _, r := range c.SeverityRules {
+		if !validSeverities[r.Value] {
+			return fmt.Errorf("invalid severity %q on %q rule", r.Value, r)
+		}
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	if c.Logger == nil {
+		c.Logger = log.Noop
+	}
+	c.Logger = c.Logger.WithValues(log.Kv{"svc": "pagerduty.Notifier"})
+
+	return nil
+}
+
+// Notifier sends PagerDuty Events v2 for the workspaces, triggering an event
+// per drifted workspace and resolving it when the drift detection plan is clean.
+type Notifier struct {
+	logger          log.Logger
+	eventsURL       string
+	routingKey      string
+	severityRules   []selector.Rule
+	defaultSeverity string
+	client          *http.Client
+}
+
+// NewNotifier returns a new PagerDuty notifier.
+func NewNotifier(config NotifierConfig) (*Notifier, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	return &Notifier{
+		logger:          config.Logger,
+		eventsURL:       config.EventsURL,
+		routingKey:      config.RoutingKey,
+		severityRules:   config.SeverityRules,
+		defaultSeverity: config.DefaultSeverity,
+		client:          config.HTTPClient,
+	}, nil
+}
+
+type eventPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Component     string         `json:"component,omitempty"`
+	Group         string         `json:"group,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+type eventLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+type event struct {
+	RoutingKey  string        `json:"routing_key"`
+	EventAction string        `json:"event_action"`
+	DedupKey    string        `json:"dedup_key"`
+	Client      string        `json:"client,omitempty"`
+	Payload     *eventPayload `json:"payload,omitempty"`
+	Links       []eventLink   `json:"links,omitempty"`
+}
+
+// Notify will trigger events for the drifted workspaces and resolve them for the clean ones.
+func (n Notifier) Notify(ctx context.Context, wks []model.Workspace) error {
+	var errs []string
+	for _, wk := range wks {
+		var ev event
+		switch wk.DriftState() {
+		case model.DriftStateDrift:
+			ev = n.newTriggerEvent(wk)
+		case model.DriftStateOK:
+			ev = event{RoutingKey: n.routingKey, EventAction: "resolve", DedupKey: DedupKey(wk)}
+		default:
+			continue
+		}
+
+		err := n.send(ctx, ev)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("could not %s %q workspace event: %s", ev.EventAction, wk.Name, err))
+			continue
+		}
+
+		n.logger.WithValues(log.Kv{"workspace": wk.Name, "action": ev.EventAction, "dedup-key": ev.DedupKey}).Debugf("PagerDuty event sent")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors: %s", len(errs), strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// DedupKey returns the deterministic PagerDuty deduplication key of a workspace.
+func DedupKey(wk model.Workspace) string {
+	return fmt.Sprintf(dedupKeyFmt, wk.Org, wk.ID)
+}
+
+func (n Notifier) newTriggerEvent(wk model.Workspace) event {
+	severity := n.defaultSeverity
+	if r, ok := selector.FirstMatch(n.severityRules, wk); ok {
+		severity = r.Value
+	}
+
+	return event{
+		RoutingKey:  n.routingKey,
+		EventAction: "trigger",
+		DedupKey:    DedupKey(wk),
+		Client:      info.AppName,
+		Payload: &eventPayload{
+			Summary:   fmt.Sprintf("Drift detected on Terraform workspace %s/%s", wk.Org, wk.Name),
+			Source:    wk.Org,
+			Severity:  severity,
+			Component: wk.Name,
+			Group:     wk.Org,
+			Class:     "terraform-drift",
+			CustomDetails: map[string]any{
+				"workspace_name": wk.Name,
+				"workspace_id":   wk.ID,
+				"tags":           wk.Tags,
+				"run_id":         wk.LastDriftPlan.ID,
+				"run_url":        wk.LastDriftPlan.URL,
+			},
+		},
+		Links: []eventLink{{Href: wk.LastDriftPlan.URL, Text: "Drift detection run"}},
+	}
+}
+
+func (n Notifier) send(ctx context.Context, ev event) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("could not marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.eventsURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("events API returned %d status code: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package pagerduty_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/notify/pagerduty"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+type fakePayload struct {
+	Summary  string `json:"summary"`
+	Severity string `json:"severity"`
+}
+
+type fakeEvent struct {
+	RoutingKey  string       `json:"routing_key"`
+	EventAction string       `json:"event_action"`
+	DedupKey    string       `json:"dedup_key"`
+	Client      string       `json:"client"`
+	Payload     *fakePayload `json:"payload"`
+}
+
+func TestNotifier(t *testing.T) {
+	tests := map[string]struct {
+		severityRules []string
+		statusCode    int
+		workspaces    []model.Workspace
+		expEvents     []fakeEvent
+		expErr        bool
+	}{
+		"Drifted workspaces should trigger and clean workspaces resolve, the rest should be ignored.": {
+			severityRules: []string{"tag:prod=critical", "name:^sandbox=info"},
+			statusCode:    http.StatusAccepted,
+			workspaces: []model.Workspace{
+				{ID: "ws-1", Name: "wk1", Org: "org", Tags: []string{"prod"}, LastDriftPlan: &model.Plan{ID: "run-1", Status: model.PlanStatusFinishedOK, HasChanges: true}},
+				{ID: "ws-2", Name: "sandbox-wk2", Org: "org", LastDriftPlan: &model.Plan{ID: "run-2", Status: model.PlanStatusFinishedOK, HasChanges: true}},
+				{ID: "ws-3", Name: "wk3", Org: "org", LastDriftPlan: &model.Plan{ID: "run-3", Status: model.PlanStatusFinishedOK, HasChanges: true}},
+				{ID: "ws-4", Name: "wk4", Org: "org", LastDriftPlan: &model.Plan{ID: "run-4", Status: model.PlanStatusFinishedOK}},
+				{ID: "ws-5", Name: "wk5", Org: "org", LastDriftPlan: &model.Plan{ID: "run-5", Status: model.PlanStatusFinishedNotOK}},
+				{ID: "ws-6", Name: "wk6", Org: "org"},
+			},
+			expEvents: []fakeEvent{
+				{RoutingKey: "test-key", EventAction: "trigger", DedupKey: "tfe-drift/org/ws-1", Client: "tfe-drift", Payload: &fakePayload{Summary: "Drift detected on Terraform workspace org/wk1", Severity: "critical"}},
+				{RoutingKey: "test-key", EventAction: "trigger", DedupKey: "tfe-drift/org/ws-2", Client: "tfe-drift", Payload: &fakePayload{Summary: "Drift detected on Terraform workspace org/sandbox-wk2", Severity: "info"}},
+				{RoutingKey: "test-key", EventAction: "trigger", DedupKey: "tfe-drift/org/ws-3", Client: "tfe-drift", Payload: &fakePayload{Summary: "Drift detected on Terraform workspace org/wk3", Severity: "error"}},
+				{RoutingKey: "test-key", EventAction: "resolve", DedupKey: "tfe-drift/org/ws-4"},
+			},
+		},
+
+		"API errors should return an error.": {
+			statusCode: http.StatusBadRequest,
+			workspaces: []model.Workspace{
+				{ID: "ws-1", Name: "wk1", Org: "org", LastDriftPlan: &model.Plan{ID: "run-1", Status: model.PlanStatusFinishedOK, HasChanges: true}},
+			},
+			expEvents: []fakeEvent{
+				{RoutingKey: "test-key", EventAction: "trigger", DedupKey: "tfe-drift/org/ws-1", Client: "tfe-drift", Payload: &fakePayload{Summary: "Drift detected on Terraform workspace org/wk1", Severity: "error"}},
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			var mu sync.Mutex
+			gotEvents := []fakeEvent{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				ev := fakeEvent{}
+				_ = json.NewDecoder(r.Body).Decode(&ev)
+				gotEvents = append(gotEvents, ev)
+				w.WriteHeader(test.statusCode)
+			}))
+			defer srv.Close()
+
+			rules, err := selector.ParseRules(test.severityRules)
+			require.NoError(err)
+
+			n, err := pagerduty.NewNotifier(pagerduty.NotifierConfig{
+				Logger:        log.Noop,
+				EventsURL:     srv.URL,
+				RoutingKey:    "test-key",
+				SeverityRules: rules,
+			})
+			require.NoError(err)
+
+			err = n.Notify(context.TODO(), test.workspaces)
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expEvents, gotEvents)
+		})
+	}
+}
+
+func TestNotifierInvalidSeverity(t *testing.T) {
+	rules, err := selector.ParseRules([]string{"tag:prod=very-high"})
+	require.NoError(t, err)
+
+	_, err = pagerduty.NewNotifier(pagerduty.NotifierConfig{RoutingKey: "test", SeverityRules: rules})
+	assert.Error(t, err)
+}
@@ -0,0 +1,58 @@
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+)
+
+var (
+	//go:embed html.tmpl
+	htmlTplData string
+
+	htmlTpl = template.Must(template.New("html").Funcs(template.FuncMap{
+		"workspaces": func(r Report) []Workspace { return append(append([]Workspace{}, r.Workspaces...), r.Skipped...) },
+		"tagFacets":  htmlTagFacets,
+		"priority":   func(s Status) int { return statusPriority[s] },
+		"duration":   markdownDuration,
+		"join":       strings.Join,
+	}).Parse(htmlTplData))
+)
+
+// RenderHTML renders the report as a single self-contained HTML page with summary cards,
+// a sortable and filterable workspaces table, tag facets and the drift detection plan links.
+func RenderHTML(w io.Writer, r Report) error {
+	err := htmlTpl.Execute(w, r)
+	if err != nil {
+		return fmt.Errorf("could not render HTML: %w", err)
+	}
+
+	return nil
+}
+
+type htmlTagFacet struct {
+	Tag   string
+	Count int
+}
+
+func htmlTagFacets(r Report) []htmlTagFacet {
+	counts := map[string]int{}
+	for _, wks := range [][]Workspace{r.Workspaces, r.Skipped} {
+		for _, wk := range wks {
+			for _, t := range wk.Tags {
+				counts[t]++
+			}
+		}
+	}
+
+	facets := make([]htmlTagFacet, 0, len(counts))
+	for t, c := range counts {
+		facets = append(facets, htmlTagFacet{Tag: t, Count: c})
+	}
+	sort.Slice(facets, func(i, j int) bool { return facets[i].Tag < facets[j].Tag })
+
+	return facets
+}
@@ -0,0 +1,82 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+)
+
+func TestRenderHTML(t *testing.T) {
+	processed, all := testWorkspaces()
+
+	tests := map[string]struct {
+		report      report.Report
+		expContains []string
+		expMissing  []string
+	}{
+		"An empty report should render the summary.": {
+			report: report.New([]model.Workspace{}, []model.Workspace{}, t0),
+			expContains: []string{
+				`<div class="generated">Generated at 2023-01-10T10:00:00Z</div>`,
+				`<div class="card"><div class="value">0</div><div class="label">Total</div></div>`,
+			},
+			expMissing: []string{
+				`<div class="facets">`,
+				`<tr data-status=`,
+			},
+		},
+
+		"A report with workspaces should render the cards, the tag facets, the workspaces and the details.": {
+			report: report.New(processed, all, t0),
+			expContains: []string{
+				`<div class="card"><div class="value">5</div><div class="label">Total</div></div>`,
+				`<div class="card drift"><div class="value">1</div><div class="label">Drift</div></div>`,
+				`<button class="facet" type="button" data-tag="t1">t1 (2)</button>`,
+				`<button class="facet" type="button" data-tag="t2">t2 (1)</button>`,
+				`<tr data-status="drift" data-tags="">`,
+				`<tr data-status="drift_plan_error" data-tags="t1,t2">`,
+				`<tr data-status="skipped" data-tags="">`,
+				`<summary>wk2</summary>`,
+				`<tr><td>Destructions</td><td>3</td></tr>`,
+				`<a href="https://tfe.test/run-1" target="_blank" rel="noopener">run-1</a>`,
+				`<td data-value="42">42s</td>`,
+			},
+			expMissing: []string{
+				`<summary>wk1</summary>`,
+			},
+		},
+
+		"Workspace data should be escaped.": {
+			report: report.New([]model.Workspace{{ID: "ws-1", Name: "<script>alert(1)</script>", Org: "org"}}, nil, t0),
+			expContains: []string{
+				`&lt;script&gt;alert(1)&lt;/script&gt;`,
+			},
+			expMissing: []string{
+				`<script>alert(1)</script>`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var b bytes.Buffer
+			err := report.RenderHTML(&b, test.report)
+			require.NoError(t, err)
+
+			got := b.String()
+			for _, exp := range test.expContains {
+				assert.Contains(got, exp)
+			}
+			for _, exp := range test.expMissing {
+				assert.NotContains(got, exp)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const junitSuiteName = "tfe-drift"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// RenderJUnit renders the report in JUnit XML format, each workspace will be a test case:
+//
+//   - Drift: Failure.
+//   - Drift detection plan error: Error.
+//   - Skipped or unknown: Skipped.
+//
+// The drift detection plan duration will be used as the test case time.
+func RenderJUnit(w io.Writer, r Report) error {
+	suite := junitTestSuite{
+		Name:      junitSuiteName,
+		Timestamp: r.CreatedAt.Format("2006-01-02T15:04:05"),
+		TestCases: []junitTestCase{},
+	}
+
+	var total time.Duration
+	wks := append(append([]Workspace{}, r.Workspaces...), r.Skipped...)
+	for _, wk := range wks {
+		tc := junitTestCase{
+			Name:      wk.Name,
+			Classname: strings.Trim(junitSuiteName+"."+wk.Org, "."),
+			Time:      junitSeconds(wk.Duration),
+			SystemOut: &junitOutput{Text: junitSystemOut(wk)},
+		}
+
+		switch wk.Status {
+		case StatusDrift:
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("Drift detected on workspace %s: %s", wk.Name, wk.RunURL),
+				Type:    "drift",
+				Text:    wk.RunURL,
+			}
+			suite.Failures++
+		case StatusError:
+			tc.Error = &junitMessage{
+				Message: fmt.Sprintf("Drift detection plan failed on workspace %s: %s", wk.Name, wk.RunURL),
+				Type:    "drift_plan_error",
+				Text:    wk.RunURL,
+			}
+			suite.Errors++
+		case StatusSkipped:
+			// Skipped workspaces didn't run on this execution.
+			tc.Time = junitSeconds(0)
+			tc.Skipped = &junitMessage{Message: "Drift detection skipped"}
+			suite.Skipped++
+		case StatusUnknown:
+			tc.Skipped = &junitMessage{Message: "Drift detection state unknown"}
+			suite.Skipped++
+		}
+
+		if wk.Status != StatusSkipped {
+			total += wk.Duration
+		}
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Time = junitSeconds(total)
+
+	suites := junitTestSuites{
+		Name:     junitSuiteName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return fmt.Errorf("could not write XML header: %w", err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(suites)
+	if err != nil {
+		return fmt.Errorf("could not encode JUnit XML: %w", err)
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+func junitSystemOut(wk Workspace) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "workspace_id: %s\n", wk.ID)
+	if len(wk.Tags) > 0 {
+		fmt.Fprintf(&b, "tags: %s\n", strings.Join(wk.Tags, ","))
+	}
+	if wk.RunURL != "" {
+		fmt.Fprintf(&b, "run_url: %s\n", wk.RunURL)
+	}
+
+	return b.String()
+}
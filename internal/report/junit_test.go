@@ -0,0 +1,79 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+)
+
+func TestRenderJUnit(t *testing.T) {
+	processed, all := testWorkspaces()
+
+	tests := map[string]struct {
+		report report.Report
+		exp    string
+	}{
+		"An empty report should render an empty test suite.": {
+			report: report.New([]model.Workspace{}, []model.Workspace{}, t0),
+			exp: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="tfe-drift" tests="0" failures="0" errors="0" skipped="0" time="0.000">
+  <testsuite name="tfe-drift" tests="0" failures="0" errors="0" skipped="0" time="0.000" timestamp="2023-01-10T10:00:00"></testsuite>
+</testsuites>
+`,
+		},
+
+		"A report with workspaces should map drift to failures, plan errors to errors and skipped to skipped.": {
+			report: report.New(processed, all, t0),
+			exp: `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites name="tfe-drift" tests="5" failures="1" errors="1" skipped="2" time="132.000">
+  <testsuite name="tfe-drift" tests="5" failures="1" errors="1" skipped="2" time="132.000" timestamp="2023-01-10T10:00:00">
+    <testcase name="wk2" classname="tfe-drift.org" time="90.000">
+      <failure message="Drift detected on workspace wk2: https://tfe.test/run-2" type="drift">https://tfe.test/run-2</failure>
+      <system-out><![CDATA[workspace_id: ws-2
+run_url: https://tfe.test/run-2
+]]></system-out>
+    </testcase>
+    <testcase name="wk3" classname="tfe-drift.org" time="0.000">
+      <error message="Drift detection plan failed on workspace wk3: https://tfe.test/run-3" type="drift_plan_error">https://tfe.test/run-3</error>
+      <system-out><![CDATA[workspace_id: ws-3
+tags: t1,t2
+run_url: https://tfe.test/run-3
+]]></system-out>
+    </testcase>
+    <testcase name="wk4" classname="tfe-drift.org" time="0.000">
+      <skipped message="Drift detection state unknown"></skipped>
+      <system-out><![CDATA[workspace_id: ws-4
+]]></system-out>
+    </testcase>
+    <testcase name="wk1" classname="tfe-drift.org" time="42.000">
+      <system-out><![CDATA[workspace_id: ws-1
+tags: t1
+run_url: https://tfe.test/run-1
+]]></system-out>
+    </testcase>
+    <testcase name="wk5" classname="tfe-drift.org" time="0.000">
+      <skipped message="Drift detection skipped"></skipped>
+      <system-out><![CDATA[workspace_id: ws-5
+run_url: https://tfe.test/run-5
+]]></system-out>
+    </testcase>
+  </testsuite>
+</testsuites>
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := report.RenderJUnit(&b, test.report)
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, b.String())
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var statusEmoji = map[Status]string{
+	StatusOK:      "✅",
+	StatusDrift:   "⚠️",
+	StatusError:   "❌",
+	StatusUnknown: "❔",
+	StatusSkipped: "⏭️",
+}
+
+var markdownTpl = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"emoji":    func(s Status) string { return statusEmoji[s] },
+	"tags":     markdownTags,
+	"duration": markdownDuration,
+	"since":    func(t, now time.Time) string { return markdownDuration(now.Sub(t).Truncate(time.Minute)) },
+	"escape":   markdownEscape,
+}).Parse(`## Terraform drift detection
+
+| Drift | Errors | OK | Unknown | Skipped |
+| :---: | :----: | :-: | :-----: | :-----: |
+| {{ .Totals.Drift }} | {{ .Totals.Error }} | {{ .Totals.OK }} | {{ .Totals.Unknown }} | {{ .Totals.Skipped }} |
+{{ if .Workspaces }}
+| | Workspace | Tags | Duration | Run |
+| - | --------- | ---- | -------- | --- |
+{{- range .Workspaces }}
+| {{ emoji .Status }} | {{ escape .Name }} | {{ tags .Tags }} | {{ duration .Duration }} | {{ if .RunURL }}[{{ .RunID }}]({{ .RunURL }}){{ else }}-{{ end }} |
+{{- end }}
+{{ else }}
+No drift detections executed.
+{{ end }}
+{{- if .Skipped }}
+<details>
+<summary>Skipped workspaces ({{ len .Skipped }})</summary>
+
+| Workspace | Tags | Last drift detection |
+| --------- | ---- | -------------------- |
+{{- $now := .CreatedAt }}
+{{- range .Skipped }}
+| {{ escape .Name }} | {{ tags .Tags }} | {{ if .RunURL }}[{{ since .CreatedAt $now }} ago]({{ .RunURL }}){{ else }}-{{ end }} |
+{{- end }}
+
+</details>
+{{ end }}
+_Generated at {{ .CreatedAt.Format "2006-01-02T15:04:05Z07:00" }}_
+`))
+
+// RenderMarkdown renders the report in markdown format (e.g: CI job summaries).
+func RenderMarkdown(w io.Writer, r Report) error {
+	err := markdownTpl.Execute(w, r)
+	if err != nil {
+		return fmt.Errorf("could not render markdown: %w", err)
+	}
+
+	return nil
+}
+
+func markdownTags(tags []string) string {
+	if len(tags) == 0 {
+		return "-"
+	}
+
+	ts := make([]string, 0, len(tags))
+	for _, t := range tags {
+		ts = append(ts, "`"+t+"`")
+	}
+
+	return strings.Join(ts, " ")
+}
+
+func markdownDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+
+	return d.Round(time.Second).String()
+}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "_", `\_`, "*", `\*`).Replace(s)
+}
@@ -0,0 +1,72 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	processed, all := testWorkspaces()
+
+	tests := map[string]struct {
+		report report.Report
+		exp    string
+	}{
+		"An empty report should render the summary.": {
+			report: report.New([]model.Workspace{}, []model.Workspace{}, t0),
+			exp: `## Terraform drift detection
+
+| Drift | Errors | OK | Unknown | Skipped |
+| :---: | :----: | :-: | :-----: | :-----: |
+| 0 | 0 | 0 | 0 | 0 |
+
+No drift detections executed.
+
+_Generated at 2023-01-10T10:00:00Z_
+`,
+		},
+
+		"A report with workspaces should render the summary, the workspaces and the skipped ones.": {
+			report: report.New(processed, all, t0),
+			exp: `## Terraform drift detection
+
+| Drift | Errors | OK | Unknown | Skipped |
+| :---: | :----: | :-: | :-----: | :-----: |
+| 1 | 1 | 1 | 1 | 1 |
+
+| | Workspace | Tags | Duration | Run |
+| - | --------- | ---- | -------- | --- |
+| ⚠️ | wk2 | - | 1m30s | [run-2](https://tfe.test/run-2) |
+| ❌ | wk3 | ` + "`t1` `t2`" + ` | - | [run-3](https://tfe.test/run-3) |
+| ❔ | wk4 | - | - | - |
+| ✅ | wk1 | ` + "`t1`" + ` | 42s | [run-1](https://tfe.test/run-1) |
+
+<details>
+<summary>Skipped workspaces (1)</summary>
+
+| Workspace | Tags | Last drift detection |
+| --------- | ---- | -------------------- |
+| wk5 | - | [2h0m0s ago](https://tfe.test/run-5) |
+
+</details>
+
+_Generated at 2023-01-10T10:00:00Z_
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			err := report.RenderMarkdown(&b, test.report)
+			require.NoError(t, err)
+			assert.Equal(t, test.exp, b.String())
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+// Status is the status of a workspace on the report.
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusDrift   Status = "drift"
+	StatusError   Status = "drift_plan_error"
+	StatusUnknown Status = "unknown"
+	StatusSkipped Status = "skipped"
+)
+
+// Workspace is the report information of a workspace.
+type Workspace struct {
+	Name      string
+	ID        string
+	Org       string
+	Tags      []string
+	Status    Status
+	RunID     string
+	RunURL    string
+	CreatedAt time.Time
+	Duration  time.Duration
+	// Resources are the resource changes of the drift detection plan, nil if not available.
+	Resources *Resources
+}
+
+// Resources are the number of resources changed by a drift detection plan.
+type Resources struct {
+	Additions    int
+	Changes      int
+	Destructions int
+	Imports      int
+}
+
+// Totals are the number of workspaces by status.
+type Totals struct {
+	Total   int
+	OK      int
+	Drift   int
+	Error   int
+	Unknown int
+	Skipped int
+}
+
+// Report is the result of a drift detection execution.
+type Report struct {
+	// Workspaces are the workspaces that have been processed (had a drift detection).
+	Workspaces []Workspace
+	// Skipped are the workspaces that have been skipped (e.g: drift detection recently executed, limits...).
+	Skipped   []Workspace
+	Totals    Totals
+	CreatedAt time.Time
+}
+
+// New returns a new report based on the processed workspaces, all the workspaces that
+// could have been processed are required to know the skipped ones.
+func New(processed, all []model.Workspace, now time.Time) Report {
+	r := Report{
+		Workspaces: []Workspace{},
+		Skipped:    []Workspace{},
+		CreatedAt:  now.UTC(),
+	}
+
+	processedIDs := map[string]bool{}
+	for _, wk := range processed {
+		processedIDs[wk.ID] = true
+		rwk := newWorkspace(wk)
+
+		switch wk.DriftState() {
+		case model.DriftStateOK:
+			rwk.Status = StatusOK
+			r.Totals.OK++
+		case model.DriftStateDrift:
+			rwk.Status = StatusDrift
+			r.Totals.Drift++
+		case model.DriftStateError:
+			rwk.Status = StatusError
+			r.Totals.Error++
+		default:
+			rwk.Status = StatusUnknown
+			r.Totals.Unknown++
+		}
+
+		r.Workspaces = append(r.Workspaces, rwk)
+	}
+
+	for _, wk := range all {
+		if processedIDs[wk.ID] {
+			continue
+		}
+
+		rwk := newWorkspace(wk)
+		rwk.Status = StatusSkipped
+		r.Skipped = append(r.Skipped, rwk)
+		r.Totals.Skipped++
+	}
+
+	r.Totals.Total = len(r.Workspaces) + len(r.Skipped)
+
+	// Sort by relevance and name.
+	sort.SliceStable(r.Workspaces, func(i, j int) bool {
+		si, sj := statusPriority[r.Workspaces[i].Status], statusPriority[r.Workspaces[j].Status]
+		if si != sj {
+			return si < sj
+		}
+		return r.Workspaces[i].Name < r.Workspaces[j].Name
+	})
+	sort.SliceStable(r.Skipped, func(i, j int) bool { return r.Skipped[i].Name < r.Skipped[j].Name })
+
+	return r
+}
+
+var statusPriority = map[Status]int{
+	StatusDrift:   0,
+	StatusError:   1,
+	StatusUnknown: 2,
+	StatusOK:      3,
+	StatusSkipped: 4,
+}
+
+func newWorkspace(wk model.Workspace) Workspace {
+	rwk := Workspace{
+		Name: wk.Name,
+		ID:   wk.ID,
+		Org:  wk.Org,
+		Tags: wk.Tags,
+	}
+
+	if wk.LastDriftPlan != nil {
+		rwk.RunID = wk.LastDriftPlan.ID
+		rwk.RunURL = wk.LastDriftPlan.URL
+		rwk.CreatedAt = wk.LastDriftPlan.CreatedAt
+		rwk.Duration = wk.LastDriftPlan.PlanRunDuration
+
+		if res := wk.LastDriftPlan.Resources; res != nil {
+			rwk.Resources = &Resources{
+				Additions:    res.Additions,
+				Changes:      res.Changes,
+				Destructions: res.Destructions,
+				Imports:      res.Imports,
+			}
+		}
+	}
+
+	return rwk
+}
+
+// Renderer knows how to render a report.
+type Renderer interface {
+	Render(w io.Writer, r Report) error
+}
+
+// RendererFunc is a helper to use functions as Renderer.
+type RendererFunc func(w io.Writer, r Report) error
+
+func (f RendererFunc) Render(w io.Writer, r Report) error { return f(w, r) }
@@ -0,0 +1,48 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+)
+
+var t0, _ = time.Parse(time.RFC3339, "2023-01-10T10:00:00Z")
+
+func testWorkspaces() (processed, all []model.Workspace) {
+	processed = []model.Workspace{
+		{ID: "ws-1", Name: "wk1", Org: "org", Tags: []string{"t1"}, LastDriftPlan: &model.Plan{ID: "run-1", URL: "https://tfe.test/run-1", Status: model.PlanStatusFinishedOK, CreatedAt: t0, PlanRunDuration: 42 * time.Second}},
+		{ID: "ws-2", Name: "wk2", Org: "org", LastDriftPlan: &model.Plan{ID: "run-2", URL: "https://tfe.test/run-2", Status: model.PlanStatusFinishedOK, HasChanges: true, CreatedAt: t0, PlanRunDuration: 90 * time.Second, Resources: &model.PlanResources{Additions: 1, Changes: 2, Destructions: 3}}},
+		{ID: "ws-3", Name: "wk3", Org: "org", Tags: []string{"t1", "t2"}, LastDriftPlan: &model.Plan{ID: "run-3", URL: "https://tfe.test/run-3", Status: model.PlanStatusFinishedNotOK, CreatedAt: t0}},
+		{ID: "ws-4", Name: "wk4", Org: "org"},
+	}
+	all = append([]model.Workspace{
+		{ID: "ws-5", Name: "wk5", Org: "org", LastDriftPlan: &model.Plan{ID: "run-5", URL: "https://tfe.test/run-5", Status: model.PlanStatusFinishedOK, CreatedAt: t0.Add(-2 * time.Hour)}},
+	}, processed...)
+
+	return processed, all
+}
+
+func TestNew(t *testing.T) {
+	processed, all := testWorkspaces()
+
+	exp := report.Report{
+		Workspaces: []report.Workspace{
+			{ID: "ws-2", Name: "wk2", Org: "org", Status: report.StatusDrift, RunID: "run-2", RunURL: "https://tfe.test/run-2", CreatedAt: t0, Duration: 90 * time.Second, Resources: &report.Resources{Additions: 1, Changes: 2, Destructions: 3}},
+			{ID: "ws-3", Name: "wk3", Org: "org", Tags: []string{"t1", "t2"}, Status: report.StatusError, RunID: "run-3", RunURL: "https://tfe.test/run-3", CreatedAt: t0},
+			{ID: "ws-4", Name: "wk4", Org: "org", Status: report.StatusUnknown},
+			{ID: "ws-1", Name: "wk1", Org: "org", Tags: []string{"t1"}, Status: report.StatusOK, RunID: "run-1", RunURL: "https://tfe.test/run-1", CreatedAt: t0, Duration: 42 * time.Second},
+		},
+		Skipped: []report.Workspace{
+			{ID: "ws-5", Name: "wk5", Org: "org", Status: report.StatusSkipped, RunID: "run-5", RunURL: "https://tfe.test/run-5", CreatedAt: t0.Add(-2 * time.Hour)},
+		},
+		Totals:    report.Totals{Total: 5, OK: 1, Drift: 1, Error: 1, Unknown: 1, Skipped: 1},
+		CreatedAt: t0,
+	}
+
+	got := report.New(processed, all, t0)
+	assert.Equal(t, exp, got)
+}
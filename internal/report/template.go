@@ -0,0 +1,133 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateDataVersion is the version of the data model received by the custom templates,
+// it will be increased on breaking changes of the model.
+const TemplateDataVersion = 1
+
+// TemplateData is the data model that custom templates receive.
+//
+//   - `.Version`: The version of the data model (TemplateDataVersion).
+//   - `.Workspaces`: The processed workspaces (Name, ID, Org, Tags, Status, RunID, RunURL, CreatedAt, Duration and Resources).
+//   - `.Skipped`: The skipped workspaces, same fields as the processed ones.
+//   - `.Totals`: The number of workspaces by status (Total, OK, Drift, Error, Unknown and Skipped).
+//   - `.CreatedAt`: When the report has been created.
+type TemplateData struct {
+	Version int
+	Report
+}
+
+// TagGroup are the workspaces that have a tag.
+type TagGroup struct {
+	Tag        string
+	Workspaces []Workspace
+}
+
+var templateFuncs = template.FuncMap{
+	"duration":     markdownDuration,
+	"formatTime":   func(layout string, t time.Time) string { return t.Format(layout) },
+	"filterStatus": templateFilterStatus,
+	"groupByTag":   templateGroupByTag,
+	"join":         func(sep string, s []string) string { return strings.Join(s, sep) },
+	"json":         templateJSON,
+	"csv":          templateCSV,
+	"lower":        strings.ToLower,
+	"upper":        strings.ToUpper,
+}
+
+// NewTemplateRenderer returns a renderer that renders the report using a custom Go template.
+//
+// Apart from the Go template builtin functions, these are available:
+//
+//   - `duration`: Formats a duration rounded to seconds (`-` if 0).
+//   - `formatTime`: Formats a time with a Go layout (e.g: `formatTime "2006-01-02" .CreatedAt`).
+//   - `filterStatus`: Returns the workspaces with the comma separated statuses (e.g: `filterStatus "drift,drift_plan_error" .Workspaces`).
+//   - `groupByTag`: Groups the workspaces by tag sorted by tag, workspaces without tags are omitted.
+//   - `join`: Joins strings with a separator (e.g: `join "," .Tags`).
+//   - `json`: Encodes the value in JSON.
+//   - `csv`: Encodes the values as a CSV record (e.g: `csv .Name .Status`).
+//   - `lower` and `upper`: Change the case of a string.
+func NewTemplateRenderer(tpl string) (Renderer, error) {
+	t, err := template.New("custom").Funcs(templateFuncs).Parse(tpl)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse template: %w", err)
+	}
+
+	return RendererFunc(func(w io.Writer, r Report) error {
+		err := t.Execute(w, TemplateData{Version: TemplateDataVersion, Report: r})
+		if err != nil {
+			return fmt.Errorf("could not render template: %w", err)
+		}
+
+		return nil
+	}), nil
+}
+
+func templateFilterStatus(statuses string, wks []Workspace) []Workspace {
+	want := map[Status]bool{}
+	for _, s := range strings.Split(statuses, ",") {
+		want[Status(strings.TrimSpace(s))] = true
+	}
+
+	res := []Workspace{}
+	for _, wk := range wks {
+		if want[wk.Status] {
+			res = append(res, wk)
+		}
+	}
+
+	return res
+}
+
+func templateGroupByTag(wks []Workspace) []TagGroup {
+	groups := map[string][]Workspace{}
+	for _, wk := range wks {
+		for _, t := range wk.Tags {
+			groups[t] = append(groups[t], wk)
+		}
+	}
+
+	res := make([]TagGroup, 0, len(groups))
+	for t, wks := range groups {
+		res = append(res, TagGroup{Tag: t, Workspaces: wks})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Tag < res[j].Tag })
+
+	return res
+}
+
+func templateJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func templateCSV(fields ...any) (string, error) {
+	record := make([]string, 0, len(fields))
+	for _, f := range fields {
+		record = append(record, fmt.Sprint(f))
+	}
+
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	err := w.Write(record)
+	if err != nil {
+		return "", err
+	}
+	w.Flush()
+
+	return strings.TrimSuffix(b.String(), "\n"), w.Error()
+}
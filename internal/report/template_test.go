@@ -0,0 +1,84 @@
+package report_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/report"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	processed, all := testWorkspaces()
+
+	tests := map[string]struct {
+		tpl       string
+		expTplErr bool
+		expErr    bool
+		exp       string
+	}{
+		"An invalid template should fail.": {
+			tpl:       `{{ .Workspaces `,
+			expTplErr: true,
+		},
+
+		"An error while executing the template should fail.": {
+			tpl:    `{{ .Missing }}`,
+			expErr: true,
+		},
+
+		"The model version, totals and timestamps should be available.": {
+			tpl: `v{{ .Version }} {{ formatTime "2006-01-02" .CreatedAt }} total={{ .Totals.Total }} drift={{ .Totals.Drift }}`,
+			exp: `v1 2023-01-10 total=5 drift=1`,
+		},
+
+		"Filtering by status should return only the workspaces with those statuses.": {
+			tpl: `{{ range filterStatus "drift,drift_plan_error" .Workspaces }}{{ .Name }}:{{ .Status }}:{{ .RunURL }};{{ end }}`,
+			exp: `wk2:drift:https://tfe.test/run-2;wk3:drift_plan_error:https://tfe.test/run-3;`,
+		},
+
+		"Grouping by tag should return the workspaces by tag.": {
+			tpl: `{{ range groupByTag .Workspaces }}{{ .Tag }}={{ range .Workspaces }}{{ .Name }},{{ end }};{{ end }}`,
+			exp: `t1=wk3,wk1,;t2=wk3,;`,
+		},
+
+		"CSV and duration helpers should format the workspaces.": {
+			tpl: `{{ range .Workspaces }}{{ csv .Name .Status (duration .Duration) (join " " .Tags) }}
+{{ end }}`,
+			exp: `wk2,drift,1m30s,
+wk3,drift_plan_error,-,t1 t2
+wk4,unknown,-,
+wk1,ok,42s,t1
+`,
+		},
+
+		"JSON helper should encode the data.": {
+			tpl: `{{ range .Skipped }}{{ json .Resources }} {{ json .Tags }}{{ end }}`,
+			exp: `null null`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			r, err := report.NewTemplateRenderer(test.tpl)
+			if test.expTplErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(t, err)
+
+			var b bytes.Buffer
+			err = r.Render(&b, report.New(processed, all, t0))
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.exp, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Package result has the versioned JSON result document of the drift detections.
+//
+// The result document shape is versioned using the `schema_version` field, breaking changes
+// (e.g: removing or renaming fields) will create a new version, old versions will be maintained
+// so consumers can pin them while they migrate.
+package result
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/exitpolicy"
+	"github.com/slok/tfe-drift/internal/jsonschema"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+)
+
+const (
+	// VersionV1 is the original result shape with the workspaces indexed by name.
+	VersionV1 = 1
+	// VersionV2 is the result shape with the workspaces status, summary and skipped workspaces.
+	VersionV2 = 2
+
+	// LatestVersion is the latest result schema version.
+	LatestVersion = VersionV2
+	// DefaultVersion is the result schema version used by default, the original shape so the existing
+	// consumers don't break, newer versions are opt-in.
+	DefaultVersion = VersionV1
+
+	schemaIDFmt = "https://raw.githubusercontent.com/slok/tfe-drift/main/docs/schema/result-v%d.json"
+)
+
+// Versions are the supported result schema versions.
+var Versions = []int{VersionV1, VersionV2}
+
+// V1 is the version 1 of the result.
+type V1 struct {
+	SchemaVersion           int                    `json:"schema_version" enum:"1" description:"The version of the result schema."`
+	Workspaces              map[string]V1Workspace `json:"workspaces" description:"The processed workspaces indexed by name."`
+	Drift                   bool                   `json:"drift" description:"If any of the workspaces has drift."`
+	DriftDetectionPlanError bool                   `json:"drift_detection_plan_error" description:"If any of the workspaces drift detection plans failed."`
+	OK                      bool                   `json:"ok" description:"If all the workspaces are without drift and errors."`
+	CreatedAt               time.Time              `json:"created_at" description:"When the result was created."`
+	Exit                    *V1Exit                `json:"exit,omitempty" description:"The exit code decision of the run, missing if not available."`
+}
+
+// V1Exit is the version 1 of a result exit code decision, it has been added after the original
+// shape so it has the same shape as the version 2.
+type V1Exit = V2Exit
+
+// V1Workspace is the version 1 of a result workspace.
+type V1Workspace struct {
+	Name                    string   `json:"name"`
+	ID                      string   `json:"id"`
+	Tags                    []string `json:"tags" nullable:"true"`
+	DriftDetectionRunID     string   `json:"drift_detection_run_id"`
+	DriftDetectionRunURL    string   `json:"drift_detection_run_url"`
+	Drift                   bool     `json:"drift"`
+	DriftDetectionPlanError bool     `json:"drift_detection_plan_error"`
+	OK                      bool     `json:"ok"`
+	RunDuration             string   `json:"run_duration" description:"Go duration format (e.g: 1m30s)."`
+}
+
+// NewV1 returns a version 1 result, the exit decision is optional.
+func NewV1(wks []model.Workspace, now time.Time, exit *exitpolicy.Decision) V1 {
+	res := V1{
+		SchemaVersion: VersionV1,
+		Workspaces:    map[string]V1Workspace{},
+		CreatedAt:     now.UTC(),
+	}
+
+	for _, wk := range wks {
+		var driftPlan model.Plan
+		if wk.LastDriftPlan != nil {
+			driftPlan = *wk.LastDriftPlan
+		}
+
+		hasDrift := driftPlan.HasChanges
+		hasDriftDetectionError := driftPlan.Status == model.PlanStatusFinishedNotOK
+
+		res.Workspaces[wk.Name] = V1Workspace{
+			Name: wk.Name,
+			ID:   wk.ID,
+			// The original shape had nil tags as `null`.
+			Tags:                    wk.Tags,
+			DriftDetectionRunID:     driftPlan.ID,
+			DriftDetectionRunURL:    driftPlan.URL,
+			Drift:                   hasDrift,
+			DriftDetectionPlanError: hasDriftDetectionError,
+			OK:                      !hasDrift && !hasDriftDetectionError,
+			RunDuration:             driftPlan.PlanRunDuration.String(),
+		}
+
+		res.Drift = res.Drift || hasDrift
+		res.DriftDetectionPlanError = res.DriftDetectionPlanError || hasDriftDetectionError
+	}
+	res.OK = !res.Drift && !res.DriftDetectionPlanError
+	res.Exit = newExit(exit)
+
+	return res
+}
+
+// V2 is the version 2 of the result.
+type V2 struct {
+	SchemaVersion           int           `json:"schema_version" enum:"2" description:"The version of the result schema."`
+	Workspaces              []V2Workspace `json:"workspaces" description:"The processed workspaces sorted by status relevance and name."`
+	Skipped                 []V2Workspace `json:"skipped" description:"The skipped workspaces (e.g: recent drift detection, limits...) sorted by name."`
+	Summary                 V2Summary     `json:"summary" description:"The number of workspaces by status."`
+	Drift                   bool          `json:"drift" description:"If any of the workspaces has drift."`
+	DriftDetectionPlanError bool          `json:"drift_detection_plan_error" description:"If any of the workspaces drift detection plans failed."`
+	OK                      bool          `json:"ok" description:"If all the workspaces are without drift and errors."`
+	Exit                    *V2Exit       `json:"exit" description:"The exit code decision of the run, null if not available."`
+	CreatedAt               time.Time     `json:"created_at" description:"When the result was created."`
+}
+
+// V2Exit is the version 2 of a result exit code decision.
+type V2Exit struct {
+	Code       int      `json:"code" description:"The exit code of the run."`
+	Reason     string   `json:"reason" enum:"ok,no_workspaces_selected,drift,drift_plan_error,wait_timeout,api_error" description:"The reason of the exit code."`
+	Rule       string   `json:"rule" description:"The severity rule of the first workspace that determined the exit code (default if no rule matched), empty if not determined by a workspace."`
+	Workspaces []string `json:"workspaces" description:"The error severity workspaces affected by the reason."`
+}
+
+// V2Workspace is the version 2 of a result workspace.
+type V2Workspace struct {
+	Name              string   `json:"name"`
+	ID                string   `json:"id"`
+	Organization      string   `json:"organization"`
+	Tags              []string `json:"tags"`
+	Status            string   `json:"status" enum:"ok,drift,drift_plan_error,unknown,skipped"`
+	DriftDetectionRun *V2Run   `json:"drift_detection_run" description:"The latest drift detection run, null if none."`
+}
+
+// V2Run is the version 2 of a result drift detection run.
+type V2Run struct {
+	ID              string       `json:"id"`
+	URL             string       `json:"url"`
+	CreatedAt       time.Time    `json:"created_at"`
+	DurationSeconds float64      `json:"duration_seconds" description:"The duration of the plan."`
+	Resources       *V2Resources `json:"resources" description:"The resource changes of the plan, null if not available."`
+}
+
+// V2Resources is the version 2 of a result drift detection plan resource changes.
+type V2Resources struct {
+	Additions    int `json:"additions"`
+	Changes      int `json:"changes"`
+	Destructions int `json:"destructions"`
+	Imports      int `json:"imports"`
+}
+
+// V2Summary is the version 2 of a result summary.
+type V2Summary struct {
+	Total                   int `json:"total"`
+	OK                      int `json:"ok"`
+	Drift                   int `json:"drift"`
+	DriftDetectionPlanError int `json:"drift_detection_plan_error"`
+	Unknown                 int `json:"unknown"`
+	Skipped                 int `json:"skipped"`
+}
+
+// NewV2 returns a version 2 result, the exit decision is optional.
+func NewV2(r report.Report, exit *exitpolicy.Decision) V2 {
+	res := V2{
+		SchemaVersion: VersionV2,
+		Workspaces:    newV2Workspaces(r.Workspaces),
+		Skipped:       newV2Workspaces(r.Skipped),
+		Summary: V2Summary{
+			Total:                   r.Totals.Total,
+			OK:                      r.Totals.OK,
+			Drift:                   r.Totals.Drift,
+			DriftDetectionPlanError: r.Totals.Error,
+			Unknown:                 r.Totals.Unknown,
+			Skipped:                 r.Totals.Skipped,
+		},
+		Drift:                   r.Totals.Drift > 0,
+		DriftDetectionPlanError: r.Totals.Error > 0,
+		CreatedAt:               r.CreatedAt,
+	}
+	res.OK = !res.Drift && !res.DriftDetectionPlanError
+	res.Exit = newExit(exit)
+
+	return res
+}
+
+func newExit(exit *exitpolicy.Decision) *V2Exit {
+	if exit == nil {
+		return nil
+	}
+
+	res := &V2Exit{
+		Code:       exit.Code,
+		Reason:     string(exit.Reason),
+		Rule:       exit.Rule,
+		Workspaces: exit.Workspaces,
+	}
+	if res.Workspaces == nil {
+		res.Workspaces = []string{}
+	}
+
+	return res
+}
+
+func newV2Workspaces(wks []report.Workspace) []V2Workspace {
+	res := []V2Workspace{}
+	for _, wk := range wks {
+		rwk := V2Workspace{
+			Name:         wk.Name,
+			ID:           wk.ID,
+			Organization: wk.Org,
+			Tags:         wk.Tags,
+			Status:       string(wk.Status),
+		}
+		if rwk.Tags == nil {
+			rwk.Tags = []string{}
+		}
+
+		if wk.RunID != "" {
+			rwk.DriftDetectionRun = &V2Run{
+				ID:              wk.RunID,
+				URL:             wk.RunURL,
+				CreatedAt:       wk.CreatedAt,
+				DurationSeconds: wk.Duration.Seconds(),
+			}
+
+			if res := wk.Resources; res != nil {
+				rwk.DriftDetectionRun.Resources = &V2Resources{
+					Additions:    res.Additions,
+					Changes:      res.Changes,
+					Destructions: res.Destructions,
+					Imports:      res.Imports,
+				}
+			}
+		}
+
+		res = append(res, rwk)
+	}
+
+	return res
+}
+
+// New returns the result of the received schema version. All the workspaces that could have
+// been processed are required to know the skipped ones. The exit decision is optional.
+func New(version int, processed, all []model.Workspace, now time.Time, exit *exitpolicy.Decision) (any, error) {
+	switch version {
+	case VersionV1:
+		return NewV1(processed, now, exit), nil
+	case VersionV2:
+		return NewV2(report.New(processed, all, now), exit), nil
+	}
+
+	return nil, fmt.Errorf("unknown result schema version %d", version)
+}
+
+// Schema returns the JSON schema of the received result schema version.
+func Schema(version int) ([]byte, error) {
+	var obj any
+	switch version {
+	case VersionV1:
+		obj = V1{}
+	case VersionV2:
+		obj = V2{}
+	default:
+		return nil, fmt.Errorf("unknown result schema version %d", version)
+	}
+
+	s, err := jsonschema.Generate(obj, fmt.Sprintf(schemaIDFmt, version), fmt.Sprintf("tfe-drift result v%d", version), "The result of a tfe-drift drift detection run.")
+	if err != nil {
+		return nil, fmt.Errorf("could not generate schema: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal schema: %w", err)
+	}
+
+	return append(data, '\n'), nil
+}
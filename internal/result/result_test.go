@@ -0,0 +1,104 @@
+package result_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/exitpolicy"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/result"
+)
+
+var updateSchemas = flag.Bool("update-schemas", false, "Updates the published result JSON schemas.")
+
+func TestNew(t *testing.T) {
+	t0, _ := time.Parse(time.RFC3339, "2023-01-10T10:00:00Z")
+
+	processed := []model.Workspace{
+		{ID: "ws-1", Name: "wk1", Org: "org", Tags: []string{"t1"}, LastDriftPlan: &model.Plan{ID: "run-1", URL: "https://tfe.test/run-1", Status: model.PlanStatusFinishedOK, CreatedAt: t0, PlanRunDuration: 42 * time.Second}},
+		{ID: "ws-2", Name: "wk2", Org: "org", LastDriftPlan: &model.Plan{ID: "run-2", URL: "https://tfe.test/run-2", Status: model.PlanStatusFinishedOK, HasChanges: true, CreatedAt: t0, PlanRunDuration: 90 * time.Second, Resources: &model.PlanResources{Additions: 1}}},
+	}
+	all := append([]model.Workspace{{ID: "ws-3", Name: "wk3", Org: "org"}}, processed...)
+
+	tests := map[string]struct {
+		version   int
+		exit      *exitpolicy.Decision
+		expResult string
+		expErr    bool
+	}{
+		"An unknown version should fail.": {
+			version: 99,
+			expErr:  true,
+		},
+
+		"Version 1 should return the v1 result.": {
+			version:   result.VersionV1,
+			expResult: `{"schema_version":1,"workspaces":{"wk1":{"name":"wk1","id":"ws-1","tags":["t1"],"drift_detection_run_id":"run-1","drift_detection_run_url":"https://tfe.test/run-1","drift":false,"drift_detection_plan_error":false,"ok":true,"run_duration":"42s"},"wk2":{"name":"wk2","id":"ws-2","tags":null,"drift_detection_run_id":"run-2","drift_detection_run_url":"https://tfe.test/run-2","drift":true,"drift_detection_plan_error":false,"ok":false,"run_duration":"1m30s"}},"drift":true,"drift_detection_plan_error":false,"ok":false,"created_at":"2023-01-10T10:00:00Z"}`,
+		},
+
+		"Version 1 with an exit decision should return the v1 result with the exit decision.": {
+			version:   result.VersionV1,
+			exit:      &exitpolicy.Decision{Code: 2, Reason: exitpolicy.ReasonDrift, Rule: "name:^wk=error", Workspaces: []string{"wk2"}},
+			expResult: `{"schema_version":1,"workspaces":{"wk1":{"name":"wk1","id":"ws-1","tags":["t1"],"drift_detection_run_id":"run-1","drift_detection_run_url":"https://tfe.test/run-1","drift":false,"drift_detection_plan_error":false,"ok":true,"run_duration":"42s"},"wk2":{"name":"wk2","id":"ws-2","tags":null,"drift_detection_run_id":"run-2","drift_detection_run_url":"https://tfe.test/run-2","drift":true,"drift_detection_plan_error":false,"ok":false,"run_duration":"1m30s"}},"drift":true,"drift_detection_plan_error":false,"ok":false,"created_at":"2023-01-10T10:00:00Z","exit":{"code":2,"reason":"drift","rule":"name:^wk=error","workspaces":["wk2"]}}`,
+		},
+
+		"Version 2 should return the v2 result.": {
+			version:   result.VersionV2,
+			expResult: `{"schema_version":2,"workspaces":[{"name":"wk2","id":"ws-2","organization":"org","tags":[],"status":"drift","drift_detection_run":{"id":"run-2","url":"https://tfe.test/run-2","created_at":"2023-01-10T10:00:00Z","duration_seconds":90,"resources":{"additions":1,"changes":0,"destructions":0,"imports":0}}},{"name":"wk1","id":"ws-1","organization":"org","tags":["t1"],"status":"ok","drift_detection_run":{"id":"run-1","url":"https://tfe.test/run-1","created_at":"2023-01-10T10:00:00Z","duration_seconds":42,"resources":null}}],"skipped":[{"name":"wk3","id":"ws-3","organization":"org","tags":[],"status":"skipped","drift_detection_run":null}],"summary":{"total":3,"ok":1,"drift":1,"drift_detection_plan_error":0,"unknown":0,"skipped":1},"drift":true,"drift_detection_plan_error":false,"ok":false,"exit":null,"created_at":"2023-01-10T10:00:00Z"}`,
+		},
+
+		"Version 2 with an exit decision should return the v2 result with the exit decision.": {
+			version:   result.VersionV2,
+			exit:      &exitpolicy.Decision{Code: 2, Reason: exitpolicy.ReasonDrift, Rule: "name:^wk=error", Workspaces: []string{"wk2"}},
+			expResult: `{"schema_version":2,"workspaces":[{"name":"wk2","id":"ws-2","organization":"org","tags":[],"status":"drift","drift_detection_run":{"id":"run-2","url":"https://tfe.test/run-2","created_at":"2023-01-10T10:00:00Z","duration_seconds":90,"resources":{"additions":1,"changes":0,"destructions":0,"imports":0}}},{"name":"wk1","id":"ws-1","organization":"org","tags":["t1"],"status":"ok","drift_detection_run":{"id":"run-1","url":"https://tfe.test/run-1","created_at":"2023-01-10T10:00:00Z","duration_seconds":42,"resources":null}}],"skipped":[{"name":"wk3","id":"ws-3","organization":"org","tags":[],"status":"skipped","drift_detection_run":null}],"summary":{"total":3,"ok":1,"drift":1,"drift_detection_plan_error":0,"unknown":0,"skipped":1},"drift":true,"drift_detection_plan_error":false,"ok":false,"exit":{"code":2,"reason":"drift","rule":"name:^wk=error","workspaces":["wk2"]},"created_at":"2023-01-10T10:00:00Z"}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			res, err := result.New(test.version, processed, all, t0, test.exit)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(t, err)
+
+			got, err := json.Marshal(res)
+			require.NoError(t, err)
+			assert.Equal(test.expResult, string(got))
+		})
+	}
+}
+
+// TestPublishedSchemas checks the published schemas are up to date, use `-update-schemas` to update them.
+func TestPublishedSchemas(t *testing.T) {
+	for _, v := range result.Versions {
+		t.Run(fmt.Sprintf("v%d", v), func(t *testing.T) {
+			require := require.New(t)
+
+			schema, err := result.Schema(v)
+			require.NoError(err)
+
+			path := filepath.Join("..", "..", "docs", "schema", fmt.Sprintf("result-v%d.json", v))
+			if *updateSchemas {
+				err := os.WriteFile(path, schema, 0o644)
+				require.NoError(err)
+			}
+
+			published, err := os.ReadFile(path)
+			require.NoError(err)
+			assert.Equal(t, string(published), string(schema), "published schema is outdated, use `-update-schemas` to update it")
+		})
+	}
+}
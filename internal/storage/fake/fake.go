@@ -73,3 +73,19 @@ func (r repository) GetLatestCheckPlan(ctx context.Context, w model.Workspace) (
 		OriginalObject:  nil,
 	}, nil
 }
+
+func (r repository) GetPreviousCheckPlan(ctx context.Context, w model.Workspace, planID string) (*model.Plan, error) {
+	t1 := time.Now().Add(-1 * time.Hour)
+	t0 := t1.Add(-25 * time.Second)
+
+	return &model.Plan{
+		ID:              fmt.Sprintf("plan-%s-%v", w.Name, t0),
+		Message:         "This is a fake plan",
+		CreatedAt:       t0,
+		FinishedAt:      t1,
+		PlanRunDuration: t1.Sub(t0),
+		HasChanges:      t0.Second()/2 == 0,
+		Status:          model.PlanStatusFinishedOK,
+		OriginalObject:  nil,
+	}, nil
+}
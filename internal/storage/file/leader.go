@@ -0,0 +1,140 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	leaderLockRetries    = 20
+	leaderLockRetryPause = 50 * time.Millisecond
+)
+
+type jsonLease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewLeaderLock returns a leader election lock that stores the leadership lease on a file, the file can be
+// on a shared file system between the controller replicas. The lease updates are protected with an exclusive
+// OS file lock on the `<path>.lock` file, released by the OS when a replica crashes, so there are no stale locks.
+func NewLeaderLock(path string) *LeaderLock {
+	return &LeaderLock{path: path, now: time.Now}
+}
+
+type LeaderLock struct {
+	path string
+	now  func() time.Time
+}
+
+func (l *LeaderLock) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	unlock, err := l.lock(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	lease, err := l.readLease()
+	if err != nil {
+		return false, err
+	}
+
+	now := l.now()
+	if lease.Holder != "" && lease.Holder != holder && now.Before(lease.ExpiresAt) {
+		return false, nil
+	}
+
+	data, err := json.Marshal(jsonLease{Holder: holder, ExpiresAt: now.Add(ttl)})
+	if err != nil {
+		return false, fmt.Errorf("could not marshal lease: %w", err)
+	}
+
+	err = WriteFileAtomic(l.path, data)
+	if err != nil {
+		return false, fmt.Errorf("could not write lease file: %w", err)
+	}
+
+	return true, nil
+}
+
+func (l *LeaderLock) Release(ctx context.Context, holder string) error {
+	unlock, err := l.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	lease, err := l.readLease()
+	if err != nil {
+		return err
+	}
+
+	if lease.Holder != holder {
+		return nil
+	}
+
+	err = os.Remove(l.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove lease file: %w", err)
+	}
+
+	return nil
+}
+
+func (l *LeaderLock) readLease() (jsonLease, error) {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return jsonLease{}, nil
+		}
+		return jsonLease{}, fmt.Errorf("could not read lease file: %w", err)
+	}
+
+	lease := jsonLease{}
+	err = json.Unmarshal(data, &lease)
+	if err != nil {
+		return jsonLease{}, fmt.Errorf("could not unmarshal lease file: %w", err)
+	}
+
+	return lease, nil
+}
+
+// lock takes the exclusive file lock of the lock file. The lock file is never removed, removing it would let
+// other replicas lock a new file while the previous one is still locked.
+func (l *LeaderLock) lock(ctx context.Context) (unlock func(), err error) {
+	lockPath := l.path + ".lock"
+
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	for i := 0; i < leaderLockRetries; i++ {
+		locked, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not lock file: %w", err)
+		}
+
+		if locked {
+			return func() {
+				_ = unlockFile(f)
+				f.Close()
+			}, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(leaderLockRetryPause):
+		}
+	}
+
+	f.Close()
+	return nil, fmt.Errorf("lock file %q is busy", lockPath)
+}
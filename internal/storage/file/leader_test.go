@@ -0,0 +1,123 @@
+package file_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/storage/file"
+)
+
+func TestLeaderLock(t *testing.T) {
+	tests := map[string]struct {
+		lease     string
+		holder    string
+		expLeader bool
+		expErr    bool
+	}{
+		"Without lease, the lock should be acquired.": {
+			holder:    "h1",
+			expLeader: true,
+		},
+
+		"With a lease of the same holder, the lock should be renewed.": {
+			lease:     `{"holder":"h1","expires_at":"2100-01-01T00:00:00Z"}`,
+			holder:    "h1",
+			expLeader: true,
+		},
+
+		"With a valid lease of another holder, the lock should not be acquired.": {
+			lease:     `{"holder":"h2","expires_at":"2100-01-01T00:00:00Z"}`,
+			holder:    "h1",
+			expLeader: false,
+		},
+
+		"With an expired lease of another holder, the lock should be acquired.": {
+			lease:     `{"holder":"h2","expires_at":"2000-01-01T00:00:00Z"}`,
+			holder:    "h1",
+			expLeader: true,
+		},
+
+		"With an invalid lease, it should fail.": {
+			lease:  `{`,
+			holder: "h1",
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			path := filepath.Join(t.TempDir(), "leader.json")
+			if test.lease != "" {
+				require.NoError(os.WriteFile(path, []byte(test.lease), 0o644))
+			}
+
+			l := file.NewLeaderLock(path)
+			leader, err := l.Acquire(context.TODO(), test.holder, time.Minute)
+
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+			assert.Equal(test.expLeader, leader)
+
+			// Other holders should not get the lock if we are the leader.
+			if leader {
+				leader, err := l.Acquire(context.TODO(), "other", time.Minute)
+				require.NoError(err)
+				assert.False(leader)
+
+				// After releasing, other holders should get it.
+				require.NoError(l.Release(context.TODO(), test.holder))
+				leader, err = l.Acquire(context.TODO(), "other", time.Minute)
+				require.NoError(err)
+				assert.True(leader)
+			}
+		})
+	}
+}
+
+func TestLeaderLockConcurrentAcquire(t *testing.T) {
+	require := require.New(t)
+
+	// A stale lock file left by a crashed replica.
+	path := filepath.Join(t.TempDir(), "leader.json")
+	require.NoError(os.WriteFile(path+".lock", nil, 0o644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(os.Chtimes(path+".lock", old, old))
+
+	// Only one of the holders acquiring the lock at the same time should be the leader.
+	const holders = 10
+	var wg sync.WaitGroup
+	results := make(chan bool, holders)
+	for i := 0; i < holders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			leader, err := file.NewLeaderLock(path).Acquire(context.TODO(), fmt.Sprintf("h%d", i), time.Minute)
+			assert.NoError(t, err)
+			results <- leader
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	leaders := 0
+	for leader := range results {
+		if leader {
+			leaders++
+		}
+	}
+	assert.Equal(t, 1, leaders)
+}
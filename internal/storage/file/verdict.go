@@ -0,0 +1,139 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+const verdictsFileVersion = 1
+
+type jsonVerdict struct {
+	State      string    `json:"state"`
+	PlanID     string    `json:"plan_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	NotifiedAt time.Time `json:"notified_at"`
+}
+
+type jsonVerdicts struct {
+	Version    int                    `json:"version"`
+	Workspaces map[string]jsonVerdict `json:"workspaces"`
+}
+
+// NewVerdictRepository returns a verdict repository that stores the workspace verdicts on a local JSON file.
+func NewVerdictRepository(path string) (*VerdictRepository, error) {
+	r := &VerdictRepository{
+		path:     path,
+		verdicts: map[string]jsonVerdict{},
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return r, nil
+		}
+		return nil, fmt.Errorf("could not read verdicts file: %w", err)
+	}
+
+	jv := jsonVerdicts{}
+	err = json.Unmarshal(data, &jv)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal verdicts file: %w", err)
+	}
+
+	if jv.Version != verdictsFileVersion {
+		return nil, fmt.Errorf("unsupported verdicts file version %d", jv.Version)
+	}
+
+	if jv.Workspaces != nil {
+		r.verdicts = jv.Workspaces
+	}
+
+	return r, nil
+}
+
+type VerdictRepository struct {
+	path     string
+	verdicts map[string]jsonVerdict
+	mu       sync.Mutex
+}
+
+func (r *VerdictRepository) GetPreviousVerdict(ctx context.Context, w model.Workspace) (*model.Verdict, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	jv, ok := r.verdicts[verdictKey(w)]
+	if !ok {
+		return nil, fmt.Errorf("verdict missing: %w", internalerrors.ErrNotExist)
+	}
+
+	return &model.Verdict{
+		State:      model.DriftState(jv.State),
+		PlanID:     jv.PlanID,
+		CreatedAt:  jv.CreatedAt,
+		NotifiedAt: jv.NotifiedAt,
+	}, nil
+}
+
+func (r *VerdictRepository) StoreVerdict(ctx context.Context, w model.Workspace, v model.Verdict) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.verdicts[verdictKey(w)] = jsonVerdict{
+		State:      string(v.State),
+		PlanID:     v.PlanID,
+		CreatedAt:  v.CreatedAt,
+		NotifiedAt: v.NotifiedAt,
+	}
+
+	data, err := json.MarshalIndent(jsonVerdicts{Version: verdictsFileVersion, Workspaces: r.verdicts}, "", "\t")
+	if err != nil {
+		return fmt.Errorf("could not marshal verdicts: %w", err)
+	}
+
+	err = WriteFileAtomic(r.path, data)
+	if err != nil {
+		return fmt.Errorf("could not write verdicts file: %w", err)
+	}
+
+	return nil
+}
+
+func verdictKey(w model.Workspace) string { return w.Org + "/" + w.ID }
+
+// WriteFileAtomic writes a file atomically by writing on a temporary file in the same directory
+// and renaming it to the final path.
+func WriteFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := f.Name()
+	defer os.Remove(tmpPath) // Noop if renamed.
+
+	_, err = f.Write(data)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmpPath, 0o644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
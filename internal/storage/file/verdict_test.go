@@ -0,0 +1,54 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/storage/file"
+)
+
+func TestVerdictRepository(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t0, _ := time.Parse(time.RFC3339, "2023-01-10T10:00:00Z")
+	path := filepath.Join(t.TempDir(), "state.json")
+	wk1 := model.Workspace{ID: "ws-1", Org: "org"}
+	wk2 := model.Workspace{ID: "ws-2", Org: "org"}
+
+	r, err := file.NewVerdictRepository(path)
+	require.NoError(err)
+
+	// Missing verdicts.
+	_, err = r.GetPreviousVerdict(context.TODO(), wk1)
+	assert.True(errors.Is(err, internalerrors.ErrNotExist))
+
+	// Store and get from a new repository.
+	v := model.Verdict{State: model.DriftStateDrift, PlanID: "run-1", CreatedAt: t0, NotifiedAt: t0.Add(time.Minute)}
+	err = r.StoreVerdict(context.TODO(), wk1, v)
+	require.NoError(err)
+
+	r, err = file.NewVerdictRepository(path)
+	require.NoError(err)
+	gotV, err := r.GetPreviousVerdict(context.TODO(), wk1)
+	require.NoError(err)
+	assert.Equal(v, *gotV)
+
+	_, err = r.GetPreviousVerdict(context.TODO(), wk2)
+	assert.True(errors.Is(err, internalerrors.ErrNotExist))
+
+	// Invalid files.
+	err = os.WriteFile(path, []byte(`{"version": 42}`), 0o644)
+	require.NoError(err)
+	_, err = file.NewVerdictRepository(path)
+	assert.Error(err)
+}
@@ -25,18 +25,22 @@ type tfeClient struct {
 }
 
 func (t tfeClient) ListWorkspaces(ctx context.Context, organization string, options *tfe.WorkspaceListOptions) (*tfe.WorkspaceList, error) {
-
+	ctx = withOperation(ctx, "list_workspaces")
 	return t.c.Workspaces.List(ctx, organization, options)
 }
 
 func (t tfeClient) CreateRun(ctx context.Context, options tfe.RunCreateOptions) (*tfe.Run, error) {
+	ctx = withOperation(ctx, "create_run")
 	return t.c.Runs.Create(ctx, options)
 }
 
 func (t tfeClient) ReadRun(ctx context.Context, runID string) (*tfe.Run, error) {
-	return t.c.Runs.Read(ctx, runID)
+	ctx = withOperation(ctx, "read_run")
+	// Include the plan so we have the plan resource changes.
+	return t.c.Runs.ReadWithOptions(ctx, runID, &tfe.RunReadOptions{Include: []tfe.RunIncludeOpt{tfe.RunPlan}})
 }
 
 func (t tfeClient) ListRuns(ctx context.Context, workspaceID string, options *tfe.RunListOptions) (*tfe.RunList, error) {
+	ctx = withOperation(ctx, "list_runs")
 	return t.c.Runs.List(ctx, workspaceID, options)
 }
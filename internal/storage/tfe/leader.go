@@ -0,0 +1,304 @@
+package tfe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+const (
+	leaderLeaseVariableKey = "TFE_DRIFT_LEADER_LEASE"
+	leaderLockReason       = "tfe-drift leader election"
+)
+
+// LeaderClient is a helper interface with the TFE official client operations used for the leader election.
+type LeaderClient interface {
+	ReadWorkspace(ctx context.Context, organization, workspace string) (*tfe.Workspace, error)
+	LockWorkspace(ctx context.Context, workspaceID, reason string) (*tfe.Workspace, error)
+	UnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error)
+	ForceUnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error)
+	ListVariables(ctx context.Context, workspaceID string, options *tfe.VariableListOptions) (*tfe.VariableList, error)
+	CreateVariable(ctx context.Context, workspaceID string, options tfe.VariableCreateOptions) (*tfe.Variable, error)
+	UpdateVariable(ctx context.Context, workspaceID, variableID string, options tfe.VariableUpdateOptions) (*tfe.Variable, error)
+}
+
+//go:generate mockery --case underscore --output tfemock --outpkg tfemock --name LeaderClient
+
+func NewLeaderClient(c *tfe.Client) LeaderClient {
+	return tfeClient{c: c}
+}
+
+func (t tfeClient) ReadWorkspace(ctx context.Context, organization, workspace string) (*tfe.Workspace, error) {
+	ctx = withOperation(ctx, "read_workspace")
+	return t.c.Workspaces.Read(ctx, organization, workspace)
+}
+
+func (t tfeClient) LockWorkspace(ctx context.Context, workspaceID, reason string) (*tfe.Workspace, error) {
+	ctx = withOperation(ctx, "lock_workspace")
+	return t.c.Workspaces.Lock(ctx, workspaceID, tfe.WorkspaceLockOptions{Reason: &reason})
+}
+
+func (t tfeClient) UnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error) {
+	ctx = withOperation(ctx, "unlock_workspace")
+	return t.c.Workspaces.Unlock(ctx, workspaceID)
+}
+
+func (t tfeClient) ForceUnlockWorkspace(ctx context.Context, workspaceID string) (*tfe.Workspace, error) {
+	ctx = withOperation(ctx, "force_unlock_workspace")
+	return t.c.Workspaces.ForceUnlock(ctx, workspaceID)
+}
+
+func (t tfeClient) ListVariables(ctx context.Context, workspaceID string, options *tfe.VariableListOptions) (*tfe.VariableList, error) {
+	ctx = withOperation(ctx, "list_variables")
+	return t.c.Variables.List(ctx, workspaceID, options)
+}
+
+func (t tfeClient) CreateVariable(ctx context.Context, workspaceID string, options tfe.VariableCreateOptions) (*tfe.Variable, error) {
+	ctx = withOperation(ctx, "create_variable")
+	return t.c.Variables.Create(ctx, workspaceID, options)
+}
+
+func (t tfeClient) UpdateVariable(ctx context.Context, workspaceID, variableID string, options tfe.VariableUpdateOptions) (*tfe.Variable, error) {
+	ctx = withOperation(ctx, "update_variable")
+	return t.c.Variables.Update(ctx, workspaceID, variableID, options)
+}
+
+type jsonLease struct {
+	Holder    string    `json:"holder"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewLeaderLock returns a leader election lock backed by TFE. The leadership lease is stored in a variable
+// of a dedicated workspace, and the workspace lock is used to protect the lease updates between replicas.
+func NewLeaderLock(c LeaderClient, tfeOrg, workspace string) *LeaderLock {
+	return &LeaderLock{
+		c:         c,
+		org:       tfeOrg,
+		workspace: workspace,
+		now:       time.Now,
+	}
+}
+
+type LeaderLock struct {
+	c         LeaderClient
+	org       string
+	workspace string
+	now       func() time.Time
+
+	mu sync.Mutex
+	// staleLock is the stale leader workspace lock seen on the previous election, if any.
+	staleLock string
+}
+
+func (l *LeaderLock) Acquire(ctx context.Context, holder string, ttl time.Duration) (bool, error) {
+	wk, err := l.c.ReadWorkspace(ctx, l.org, l.workspace)
+	if err != nil {
+		return false, fmt.Errorf("could not read leader workspace: %w", err)
+	}
+
+	_, err = l.c.LockWorkspace(ctx, wk.ID, leaderLockReason)
+	if err != nil {
+		if !errors.Is(err, tfe.ErrWorkspaceLocked) {
+			return false, fmt.Errorf("could not lock leader workspace: %w", err)
+		}
+		return l.contended(ctx, wk.ID, holder, ttl)
+	}
+	defer l.unlock(wk.ID)
+	l.setStaleLock("")
+
+	v, lease, err := l.readLease(ctx, wk.ID)
+	if err != nil {
+		return false, err
+	}
+
+	now := l.now()
+	if lease.Holder != "" && lease.Holder != holder && now.Before(lease.ExpiresAt) {
+		return false, nil
+	}
+
+	err = l.writeLease(ctx, wk.ID, v, jsonLease{Holder: holder, ExpiresAt: now.Add(ttl)})
+	if err != nil {
+		return false, err
+	}
+
+	// Our workspace lock could have been force unlocked by another replica in the meantime, confirm
+	// that we are the lease holder.
+	_, lease, err = l.readLease(ctx, wk.ID)
+	if err != nil {
+		return false, err
+	}
+
+	return lease.Holder == holder, nil
+}
+
+// contended handles the leader workspace being locked by another replica while updating the lease. The
+// holder of a valid lease will maintain the leadership, and stale workspace locks (e.g: a crashed replica
+// in the middle of a lease update) will be force unlocked so the next election can proceed. A lock is
+// only considered stale when the same lock is seen on two consecutive elections, so we don't force unlock
+// the lock of a replica that is updating the lease.
+func (l *LeaderLock) contended(ctx context.Context, workspaceID, holder string, ttl time.Duration) (bool, error) {
+	v, lease, err := l.readLease(ctx, workspaceID)
+	if err != nil {
+		return false, err
+	}
+
+	now := l.now()
+	if lease.Holder == holder && now.Before(lease.ExpiresAt) {
+		l.setStaleLock("")
+		return true, nil
+	}
+
+	if !now.After(lease.ExpiresAt.Add(ttl)) {
+		l.setStaleLock("")
+		return false, nil
+	}
+
+	wk, err := l.c.ReadWorkspace(ctx, l.org, l.workspace)
+	if err != nil {
+		return false, fmt.Errorf("could not read leader workspace: %w", err)
+	}
+
+	if !wk.Locked {
+		l.setStaleLock("")
+		return false, nil
+	}
+
+	lock := lockID(wk, v)
+	if l.swapStaleLock(lock) != lock {
+		return false, nil
+	}
+
+	_, err = l.c.ForceUnlockWorkspace(ctx, workspaceID)
+	if err != nil && !errors.Is(err, tfe.ErrWorkspaceNotLocked) {
+		return false, fmt.Errorf("could not force unlock stale leader workspace lock: %w", err)
+	}
+	l.setStaleLock("")
+
+	return false, nil
+}
+
+func (l *LeaderLock) setStaleLock(lock string) {
+	_ = l.swapStaleLock(lock)
+}
+
+// swapStaleLock sets the stale lock and returns the previous one.
+func (l *LeaderLock) swapStaleLock(lock string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	prev := l.staleLock
+	l.staleLock = lock
+	return prev
+}
+
+// lockID identifies a leader workspace lock by the lock owner and the lease when it was seen.
+func lockID(wk *tfe.Workspace, v *tfe.Variable) string {
+	owner := ""
+	if wk.LockedBy != nil {
+		switch {
+		case wk.LockedBy.Run != nil:
+			owner = "run/" + wk.LockedBy.Run.ID
+		case wk.LockedBy.User != nil:
+			owner = "user/" + wk.LockedBy.User.ID
+		case wk.LockedBy.Team != nil:
+			owner = "team/" + wk.LockedBy.Team.ID
+		}
+	}
+
+	lease := ""
+	if v != nil {
+		lease = v.Value
+	}
+
+	return owner + "|" + lease
+}
+
+func (l *LeaderLock) Release(ctx context.Context, holder string) error {
+	wk, err := l.c.ReadWorkspace(ctx, l.org, l.workspace)
+	if err != nil {
+		return fmt.Errorf("could not read leader workspace: %w", err)
+	}
+
+	_, err = l.c.LockWorkspace(ctx, wk.ID, leaderLockReason)
+	if err != nil {
+		return fmt.Errorf("could not lock leader workspace: %w", err)
+	}
+	defer l.unlock(wk.ID)
+
+	v, lease, err := l.readLease(ctx, wk.ID)
+	if err != nil {
+		return err
+	}
+
+	if lease.Holder != holder {
+		return nil
+	}
+
+	return l.writeLease(ctx, wk.ID, v, jsonLease{})
+}
+
+func (l *LeaderLock) unlock(workspaceID string) {
+	// Unlock even if the parent context is done, we don't want to leave the workspace locked.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, _ = l.c.UnlockWorkspace(ctx, workspaceID)
+}
+
+func (l *LeaderLock) readLease(ctx context.Context, workspaceID string) (*tfe.Variable, jsonLease, error) {
+	vars, err := l.c.ListVariables(ctx, workspaceID, &tfe.VariableListOptions{ListOptions: tfe.ListOptions{PageSize: defaultPageSize}})
+	if err != nil {
+		return nil, jsonLease{}, fmt.Errorf("could not list leader workspace variables: %w", err)
+	}
+
+	for _, v := range vars.Items {
+		if v.Key != leaderLeaseVariableKey {
+			continue
+		}
+
+		lease := jsonLease{}
+		if v.Value != "" {
+			err := json.Unmarshal([]byte(v.Value), &lease)
+			if err != nil {
+				return nil, jsonLease{}, fmt.Errorf("could not unmarshal lease: %w", err)
+			}
+		}
+
+		return v, lease, nil
+	}
+
+	return nil, jsonLease{}, nil
+}
+
+func (l *LeaderLock) writeLease(ctx context.Context, workspaceID string, v *tfe.Variable, lease jsonLease) error {
+	data, err := json.Marshal(lease)
+	if err != nil {
+		return fmt.Errorf("could not marshal lease: %w", err)
+	}
+	value := string(data)
+
+	if v != nil {
+		_, err := l.c.UpdateVariable(ctx, workspaceID, v.ID, tfe.VariableUpdateOptions{Value: &value})
+		if err != nil {
+			return fmt.Errorf("could not update lease variable: %w", err)
+		}
+		return nil
+	}
+
+	key := leaderLeaseVariableKey
+	description := "Managed by tfe-drift leader election."
+	_, err = l.c.CreateVariable(ctx, workspaceID, tfe.VariableCreateOptions{
+		Key:         &key,
+		Value:       &value,
+		Description: &description,
+		Category:    tfe.Category(tfe.CategoryEnv),
+	})
+	if err != nil {
+		return fmt.Errorf("could not create lease variable: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package tfe_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	gotfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/tfe-drift/internal/storage/tfe"
+	"github.com/slok/tfe-drift/internal/storage/tfe/tfemock"
+)
+
+func TestLeaderLockAcquire(t *testing.T) {
+	leaseVar := func(holder string, expiresAt time.Time) *gotfe.VariableList {
+		value := fmt.Sprintf(`{"holder":%q,"expires_at":%q}`, holder, expiresAt.Format(time.RFC3339))
+		return &gotfe.VariableList{Items: []*gotfe.Variable{
+			{ID: "var-0", Key: "OTHER", Value: "something"},
+			{ID: "var-1", Key: "TFE_DRIFT_LEADER_LEASE", Value: value},
+		}}
+	}
+	lockedWk := func(userID string) *gotfe.Workspace {
+		return &gotfe.Workspace{ID: "wk-1", Locked: true, LockedBy: &gotfe.LockedByChoice{User: &gotfe.User{ID: userID}}}
+	}
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Hour)
+
+	tests := map[string]struct {
+		elections int
+		mock      func(m *tfemock.LeaderClient)
+		expLeader bool
+		expErr    bool
+	}{
+		"Having an error reading the workspace should fail.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(nil, fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+
+		"Without lease, it should create the lease and be the leader.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(&gotfe.VariableList{}, nil)
+				m.On("CreateVariable", mock.Anything, "wk-1", mock.MatchedBy(func(o gotfe.VariableCreateOptions) bool {
+					return *o.Key == "TFE_DRIFT_LEADER_LEASE"
+				})).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h1", future), nil)
+				m.On("UnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expLeader: true,
+		},
+
+		"With a valid lease of the same holder, it should renew the lease and be the leader.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h1", future), nil)
+				m.On("UpdateVariable", mock.Anything, "wk-1", "var-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h1", future), nil)
+				m.On("UnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expLeader: true,
+		},
+
+		"With a valid lease of another holder, it should not be the leader.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h2", future), nil)
+				m.On("UnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expLeader: false,
+		},
+
+		"With an expired lease of another holder, it should take the lease and be the leader.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h2", past), nil)
+				m.On("UpdateVariable", mock.Anything, "wk-1", "var-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h1", future), nil)
+				m.On("UnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expLeader: true,
+		},
+
+		"If the lease has another holder after taking it, it should not be the leader.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h2", past), nil)
+				m.On("UpdateVariable", mock.Anything, "wk-1", "var-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h2", future), nil)
+				m.On("UnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expLeader: false,
+		},
+
+		"With the workspace locked and a valid lease of the same holder, it should maintain the leadership.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, gotfe.ErrWorkspaceLocked)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h1", future), nil)
+			},
+			expLeader: true,
+		},
+
+		"With the workspace locked and a stale lease seen once, it should not force unlock the workspace and not be the leader.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Times(2).Return(lockedWk("user-1"), nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, gotfe.ErrWorkspaceLocked)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h2", past), nil)
+			},
+			expLeader: false,
+		},
+
+		"With the workspace locked and the same stale lease seen on two consecutive elections, it should force unlock the workspace and not be the leader.": {
+			elections: 2,
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Times(4).Return(lockedWk("user-1"), nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Times(2).Return(nil, gotfe.ErrWorkspaceLocked)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Times(2).Return(leaseVar("h2", past), nil)
+				m.On("ForceUnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expLeader: false,
+		},
+
+		"With the workspace locked and a stale empty lease seen on two consecutive elections, it should force unlock the workspace and not be the leader.": {
+			elections: 2,
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Times(4).Return(lockedWk("user-1"), nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Times(2).Return(nil, gotfe.ErrWorkspaceLocked)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Times(2).Return(&gotfe.VariableList{}, nil)
+				m.On("ForceUnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expLeader: false,
+		},
+
+		"With the workspace locked and different stale locks on two consecutive elections, it should not force unlock the workspace and not be the leader.": {
+			elections: 2,
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Times(2).Return(lockedWk("user-1"), nil)
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Times(2).Return(lockedWk("user-2"), nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Times(2).Return(nil, gotfe.ErrWorkspaceLocked)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Times(2).Return(leaseVar("h2", past), nil)
+			},
+			expLeader: false,
+		},
+
+		"With the workspace locked and a stale lease, if the workspace is not locked anymore, it should not force unlock the workspace and not be the leader.": {
+			elections: 2,
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Times(4).Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Times(2).Return(nil, gotfe.ErrWorkspaceLocked)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Times(2).Return(leaseVar("h2", past), nil)
+			},
+			expLeader: false,
+		},
+
+		"Having an error updating the lease should fail.": {
+			mock: func(m *tfemock.LeaderClient) {
+				m.On("ReadWorkspace", mock.Anything, "test-org", "test-wk").Once().Return(&gotfe.Workspace{ID: "wk-1"}, nil)
+				m.On("LockWorkspace", mock.Anything, "wk-1", mock.Anything).Once().Return(nil, nil)
+				m.On("ListVariables", mock.Anything, "wk-1", mock.Anything).Once().Return(leaseVar("h1", future), nil)
+				m.On("UpdateVariable", mock.Anything, "wk-1", "var-1", mock.Anything).Once().Return(nil, fmt.Errorf("something"))
+				m.On("UnlockWorkspace", mock.Anything, "wk-1").Once().Return(nil, nil)
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			m := tfemock.NewLeaderClient(t)
+			test.mock(m)
+
+			l := tfe.NewLeaderLock(m, "test-org", "test-wk")
+			var leader bool
+			var err error
+			for i := 0; i < max(test.elections, 1); i++ {
+				leader, err = l.Acquire(context.TODO(), "h1", 10*time.Minute)
+			}
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expLeader, leader)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package tfe
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// MetricsRecorder knows how to record the TFE storage metrics.
+type MetricsRecorder interface {
+	// ObserveAPIRequest observes the duration of a TFE API request by operation and HTTP status code.
+	ObserveAPIRequest(ctx context.Context, operation, statusCode string, duration time.Duration)
+}
+
+// NoopMetricsRecorder is a metrics recorder that doesn't record anything.
+var NoopMetricsRecorder MetricsRecorder = noopMetricsRecorder(0)
+
+type noopMetricsRecorder int
+
+func (noopMetricsRecorder) ObserveAPIRequest(ctx context.Context, op, code string, d time.Duration) {}
+
+const (
+	// unknownOperation is used on the API requests that are not made by the clients of this package.
+	unknownOperation = "unknown"
+	// errorStatusCode is used on the API requests that didn't get a response.
+	errorStatusCode = "error"
+)
+
+type operationCtxKey struct{}
+
+// withOperation sets the client operation on the context so the API requests can be measured by operation.
+func withOperation(ctx context.Context, operation string) context.Context {
+	return context.WithValue(ctx, operationCtxKey{}, operation)
+}
+
+func operationFromCtx(ctx context.Context) string {
+	op, ok := ctx.Value(operationCtxKey{}).(string)
+	if !ok || op == "" {
+		return unknownOperation
+	}
+
+	return op
+}
+
+// NewMetricsRoundTripper returns an HTTP round tripper that measures the TFE API requests, it should be used
+// on the HTTP client of the TFE official client, the requests will be measured with the operation of the
+// client that made them (e.g: `list_workspaces`).
+func NewMetricsRoundTripper(rt http.RoundTripper, rec MetricsRecorder) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	if rec == nil {
+		rec = NoopMetricsRecorder
+	}
+
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		start := time.Now()
+		resp, err := rt.RoundTrip(r)
+
+		statusCode := errorStatusCode
+		if err == nil {
+			statusCode = strconv.Itoa(resp.StatusCode)
+		}
+		rec.ObserveAPIRequest(r.Context(), operationFromCtx(r.Context()), statusCode, time.Since(start))
+
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (r roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return r(req) }
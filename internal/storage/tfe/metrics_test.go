@@ -0,0 +1,82 @@
+package tfe_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	gotfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/storage/tfe"
+)
+
+type testMetricsRecorder struct {
+	mu       sync.Mutex
+	requests []string
+}
+
+func (t *testMetricsRecorder) ObserveAPIRequest(ctx context.Context, operation, statusCode string, duration time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests = append(t.requests, operation+" "+statusCode)
+}
+
+func TestMetricsRoundTripper(t *testing.T) {
+	tests := map[string]struct {
+		statusCode  int
+		expRequests []string
+		expErr      bool
+	}{
+		"A successful API request should be measured with its operation and status code.": {
+			statusCode:  http.StatusOK,
+			expRequests: []string{"unknown 200", "list_workspaces 200"},
+		},
+
+		"A failed API request should be measured with its operation and status code.": {
+			statusCode:  http.StatusNotFound,
+			expRequests: []string{"unknown 200", "list_workspaces 404"},
+			expErr:      true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/vnd.api+json")
+				// The official client pings the API when created.
+				if r.URL.Path == "/api/v2/ping" {
+					w.WriteHeader(http.StatusOK)
+					return
+				}
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(`{"data":[]}`))
+			}))
+			defer srv.Close()
+
+			rec := &testMetricsRecorder{}
+			c, err := gotfe.NewClient(&gotfe.Config{
+				Address:    srv.URL,
+				Token:      "test",
+				HTTPClient: &http.Client{Transport: tfe.NewMetricsRoundTripper(nil, rec)},
+			})
+			require.NoError(err)
+
+			_, err = tfe.NewClient(c).ListWorkspaces(context.TODO(), "test", nil)
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+			assert.Equal(test.expRequests, rec.requests)
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package tfe
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/go-tfe"
+)
+
+// Pinger checks the connectivity with Terraform enterprise or cloud.
+type Pinger struct {
+	c   Client
+	org string
+}
+
+func NewPinger(c Client, tfeOrg string) Pinger {
+	return Pinger{c: c, org: tfeOrg}
+}
+
+// Ping checks that TFE is reachable and the organization workspaces can be listed, it's a cheap
+// call that only asks for a single workspace.
+func (p Pinger) Ping(ctx context.Context) error {
+	_, err := p.c.ListWorkspaces(ctx, p.org, &tfe.WorkspaceListOptions{ListOptions: tfe.ListOptions{PageSize: 1}})
+	if err != nil {
+		return fmt.Errorf("could not list workspaces: %w", err)
+	}
+
+	return nil
+}
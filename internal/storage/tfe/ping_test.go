@@ -0,0 +1,52 @@
+package tfe_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	gotfe "github.com/hashicorp/go-tfe"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/tfe-drift/internal/storage/tfe"
+	"github.com/slok/tfe-drift/internal/storage/tfe/tfemock"
+)
+
+func TestPingerPing(t *testing.T) {
+	tests := map[string]struct {
+		mock   func(mc *tfemock.Client)
+		expErr bool
+	}{
+		"Having an error listing workspaces, should fail.": {
+			mock: func(mc *tfemock.Client) {
+				mc.On("ListWorkspaces", mock.Anything, "test", mock.Anything).Once().Return(nil, fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+
+		"Listing workspaces, should succeed asking for a single workspace.": {
+			mock: func(mc *tfemock.Client) {
+				expOpts := &gotfe.WorkspaceListOptions{ListOptions: gotfe.ListOptions{PageSize: 1}}
+				mc.On("ListWorkspaces", mock.Anything, "test", expOpts).Once().Return(&gotfe.WorkspaceList{}, nil)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			mc := tfemock.NewClient(t)
+			test.mock(mc)
+
+			err := tfe.NewPinger(mc, "test").Ping(context.TODO())
+
+			if test.expErr {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ type Repository interface {
 	CreateCheckPlan(ctx context.Context, w model.Workspace, message string) (*model.Plan, error)
 	GetCheckPlan(ctx context.Context, w model.Workspace, id string) (*model.Plan, error)
 	GetLatestCheckPlan(ctx context.Context, w model.Workspace) (*model.Plan, error)
+	GetPreviousCheckPlan(ctx context.Context, w model.Workspace, planID string) (*model.Plan, error)
 }
 
 func NewRepository(c Client, tfeOrg, tfeAddress, detectorID string) (Repository, error) {
@@ -132,6 +133,7 @@ func (r repository) GetLatestCheckPlan(ctx context.Context, w model.Workspace) (
 	runs, err := r.c.ListRuns(ctx, w.ID, &tfe.RunListOptions{
 		Search:      messageID,
 		ListOptions: tfe.ListOptions{PageSize: 1},
+		Include:     []tfe.RunIncludeOpt{tfe.RunPlan},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("could not get check plan from tfe: %w", err)
@@ -154,17 +156,61 @@ func (r repository) GetLatestCheckPlan(ctx context.Context, w model.Workspace) (
 	return plan, nil
 }
 
+// GetPreviousCheckPlan returns the latest finished check plan that was created before the received one.
+func (r repository) GetPreviousCheckPlan(ctx context.Context, w model.Workspace, planID string) (*model.Plan, error) {
+	const historyPageSize = 10
+
+	messageID := fmt.Sprintf(messageIDFmt, r.detectorID)
+	runs, err := r.c.ListRuns(ctx, w.ID, &tfe.RunListOptions{
+		Search:      messageID,
+		ListOptions: tfe.ListOptions{PageSize: historyPageSize},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not get check plans from tfe: %w", err)
+	}
+
+	// Runs are returned from newest to oldest, get the first finished one after the received one.
+	found := false
+	for _, run := range runs.Items {
+		if run.ID == planID {
+			found = true
+			continue
+		}
+
+		if !found || mapTFEStatus2Model(run.Status) == model.PlanStatusWaiting {
+			continue
+		}
+
+		plan, err := mapPlanTFE2Model(run)
+		if err != nil {
+			return nil, fmt.Errorf("could not map tfe run to model: %w", err)
+		}
+		plan.URL = r.runURL(w.Name, run.ID)
+
+		return plan, nil
+	}
+
+	return nil, fmt.Errorf("previous check plan missing: %w", internalerrors.ErrNotExist)
+}
+
 func (r repository) runURL(workspaceName, runID string) string {
 	const runURLFmt = "%s/app/%s/workspaces/%s/runs/%s"
 
 	return fmt.Sprintf(runURLFmt, r.tfeAddress, r.org, workspaceName, runID)
 }
 
+func (r repository) workspaceURL(workspaceName string) string {
+	const workspaceURLFmt = "%s/app/%s/workspaces/%s"
+
+	return fmt.Sprintf(workspaceURLFmt, r.tfeAddress, r.org, workspaceName)
+}
+
 func (r repository) mapWorkspaceTFE2Model(w *tfe.Workspace) (*model.Workspace, error) {
 	return &model.Workspace{
 		Name:           w.Name,
 		ID:             w.ID,
 		Org:            r.org,
+		URL:            r.workspaceURL(w.Name),
 		OriginalObject: w,
 		Tags:           w.TagNames,
 	}, nil
@@ -180,6 +226,17 @@ func mapPlanTFE2Model(run *tfe.Run) (*model.Plan, error) {
 		duration = run.StatusTimestamps.PlannedAndFinishedAt.Sub(run.StatusTimestamps.PlanningAt)
 	}
 
+	// Plan resources are only available if the plan has been included and finished.
+	var resources *model.PlanResources
+	if run.Plan != nil && run.Plan.Status == tfe.PlanFinished {
+		resources = &model.PlanResources{
+			Additions:    run.Plan.ResourceAdditions,
+			Changes:      run.Plan.ResourceChanges,
+			Destructions: run.Plan.ResourceDestructions,
+			Imports:      run.Plan.ResourceImports,
+		}
+	}
+
 	return &model.Plan{
 		ID:              run.ID,
 		Message:         run.Message,
@@ -188,6 +245,7 @@ func mapPlanTFE2Model(run *tfe.Run) (*model.Plan, error) {
 		PlanRunDuration: duration,
 		HasChanges:      run.HasChanges,
 		Status:          status,
+		Resources:       resources,
 		OriginalObject:  run,
 	}, nil
 }
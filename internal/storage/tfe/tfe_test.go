@@ -46,9 +46,9 @@ func TestRepositoryListWorkspaces(t *testing.T) {
 				}, nil)
 			},
 			expWorkspaces: []model.Workspace{
-				{ID: "test-id-1", Name: "test-1", Org: "test", Tags: []string{"t1"}, OriginalObject: &gotfe.Workspace{ID: "test-id-1", Name: "test-1", TagNames: []string{"t1"}}},
-				{ID: "test-id-2", Name: "test-2", Org: "test", Tags: []string{"t2"}, OriginalObject: &gotfe.Workspace{ID: "test-id-2", Name: "test-2", TagNames: []string{"t2"}}},
-				{ID: "test-id-3", Name: "test-3", Org: "test", Tags: []string{"t3"}, OriginalObject: &gotfe.Workspace{ID: "test-id-3", Name: "test-3", TagNames: []string{"t3"}}},
+				{ID: "test-id-1", Name: "test-1", Org: "test", URL: "https://test-tfe-drift.dev/app/test/workspaces/test-1", Tags: []string{"t1"}, OriginalObject: &gotfe.Workspace{ID: "test-id-1", Name: "test-1", TagNames: []string{"t1"}}},
+				{ID: "test-id-2", Name: "test-2", Org: "test", URL: "https://test-tfe-drift.dev/app/test/workspaces/test-2", Tags: []string{"t2"}, OriginalObject: &gotfe.Workspace{ID: "test-id-2", Name: "test-2", TagNames: []string{"t2"}}},
+				{ID: "test-id-3", Name: "test-3", Org: "test", URL: "https://test-tfe-drift.dev/app/test/workspaces/test-3", Tags: []string{"t3"}, OriginalObject: &gotfe.Workspace{ID: "test-id-3", Name: "test-3", TagNames: []string{"t3"}}},
 			},
 		},
 
@@ -67,9 +67,9 @@ func TestRepositoryListWorkspaces(t *testing.T) {
 					Items:      []*gotfe.Workspace{{ID: "test-id-3", Name: "test-3"}}}, nil)
 			},
 			expWorkspaces: []model.Workspace{
-				{ID: "test-id-1", Name: "test-1", Org: "test", OriginalObject: &gotfe.Workspace{ID: "test-id-1", Name: "test-1"}},
-				{ID: "test-id-2", Name: "test-2", Org: "test", OriginalObject: &gotfe.Workspace{ID: "test-id-2", Name: "test-2"}},
-				{ID: "test-id-3", Name: "test-3", Org: "test", OriginalObject: &gotfe.Workspace{ID: "test-id-3", Name: "test-3"}},
+				{ID: "test-id-1", Name: "test-1", Org: "test", URL: "https://test-tfe-drift.dev/app/test/workspaces/test-1", OriginalObject: &gotfe.Workspace{ID: "test-id-1", Name: "test-1"}},
+				{ID: "test-id-2", Name: "test-2", Org: "test", URL: "https://test-tfe-drift.dev/app/test/workspaces/test-2", OriginalObject: &gotfe.Workspace{ID: "test-id-2", Name: "test-2"}},
+				{ID: "test-id-3", Name: "test-3", Org: "test", URL: "https://test-tfe-drift.dev/app/test/workspaces/test-3", OriginalObject: &gotfe.Workspace{ID: "test-id-3", Name: "test-3"}},
 			},
 		},
 	}
@@ -211,6 +211,50 @@ func TestRepositoryGetCheckPlan(t *testing.T) {
 				},
 			},
 		},
+
+		"Getting a plan with the finished plan included should map the plan resources.": {
+			mock: func(mc *tfemock.Client) {
+				mc.On("ReadRun", mock.Anything, "test").Once().Return(&gotfe.Run{
+					ID:         "test-id-1",
+					HasChanges: true,
+					Status:     gotfe.RunPlannedAndFinished,
+					CreatedAt:  t0,
+					Plan: &gotfe.Plan{
+						Status:               gotfe.PlanFinished,
+						ResourceAdditions:    1,
+						ResourceChanges:      2,
+						ResourceDestructions: 3,
+						ResourceImports:      4,
+					},
+				}, nil)
+			},
+			expPlan: &model.Plan{
+				ID:         "test-id-1",
+				HasChanges: true,
+				Status:     model.PlanStatusFinishedOK,
+				CreatedAt:  t0,
+				URL:        "https://test-tfe-drift.dev/app/test/workspaces//runs/test-id-1",
+				Resources: &model.PlanResources{
+					Additions:    1,
+					Changes:      2,
+					Destructions: 3,
+					Imports:      4,
+				},
+				OriginalObject: &gotfe.Run{
+					ID:         "test-id-1",
+					HasChanges: true,
+					Status:     gotfe.RunPlannedAndFinished,
+					CreatedAt:  t0,
+					Plan: &gotfe.Plan{
+						Status:               gotfe.PlanFinished,
+						ResourceAdditions:    1,
+						ResourceChanges:      2,
+						ResourceDestructions: 3,
+						ResourceImports:      4,
+					},
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -264,6 +308,7 @@ func TestRepositoryLatestCheckPlan(t *testing.T) {
 				expOpts := &gotfe.RunListOptions{
 					Search:      "tfe-drift/detector-id/test-id",
 					ListOptions: gotfe.ListOptions{PageSize: 1},
+					Include:     []gotfe.RunIncludeOpt{gotfe.RunPlan},
 				}
 				mc.On("ListRuns", mock.Anything, "test", expOpts).Once().Return(&gotfe.RunList{Items: []*gotfe.Run{
 					{
@@ -311,3 +356,79 @@ func TestRepositoryLatestCheckPlan(t *testing.T) {
 		})
 	}
 }
+
+func TestRepositoryPreviousCheckPlan(t *testing.T) {
+	t0 := time.Now()
+
+	tests := map[string]struct {
+		mock      func(mc *tfemock.Client)
+		workspace model.Workspace
+		planID    string
+		expPlan   *model.Plan
+		expErr    bool
+	}{
+		"Having an error while getting the plans, should fail.": {
+			workspace: model.Workspace{ID: "test"},
+			planID:    "test-id-1",
+			mock: func(mc *tfemock.Client) {
+				mc.On("ListRuns", mock.Anything, "test", mock.Anything).Once().Return(nil, fmt.Errorf("something"))
+			},
+			expErr: true,
+		},
+
+		"Not having previous runs should fail.": {
+			workspace: model.Workspace{ID: "test"},
+			planID:    "test-id-1",
+			mock: func(mc *tfemock.Client) {
+				mc.On("ListRuns", mock.Anything, "test", mock.Anything).Once().Return(&gotfe.RunList{Items: []*gotfe.Run{
+					{ID: "test-id-1", Status: gotfe.RunPlannedAndFinished},
+				}}, nil)
+			},
+			expErr: true,
+		},
+
+		"Getting the previous plan should skip the newer and the waiting ones and map the model.": {
+			workspace: model.Workspace{ID: "test", Name: "wk"},
+			planID:    "test-id-2",
+			mock: func(mc *tfemock.Client) {
+				expOpts := &gotfe.RunListOptions{
+					Search:      "tfe-drift/detector-id/test-id",
+					ListOptions: gotfe.ListOptions{PageSize: 10},
+				}
+				mc.On("ListRuns", mock.Anything, "test", expOpts).Once().Return(&gotfe.RunList{Items: []*gotfe.Run{
+					{ID: "test-id-1", Status: gotfe.RunPlannedAndFinished},
+					{ID: "test-id-2", Status: gotfe.RunPlannedAndFinished},
+					{ID: "test-id-3", Status: gotfe.RunPlanning},
+					{ID: "test-id-4", Status: gotfe.RunErrored, CreatedAt: t0},
+					{ID: "test-id-5", Status: gotfe.RunPlannedAndFinished},
+				}}, nil)
+			},
+			expPlan: &model.Plan{
+				ID:             "test-id-4",
+				Status:         model.PlanStatusFinishedNotOK,
+				CreatedAt:      t0,
+				URL:            "https://test-tfe-drift.dev/app/test/workspaces/wk/runs/test-id-4",
+				OriginalObject: &gotfe.Run{ID: "test-id-4", Status: gotfe.RunErrored, CreatedAt: t0},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// Mocks.
+			mc := tfemock.NewClient(t)
+			test.mock(mc)
+
+			r, _ := tfe.NewRepository(mc, "test", "https://test-tfe-drift.dev", "test-id")
+			gotPlan, err := r.GetPreviousCheckPlan(context.TODO(), test.workspace, test.planID)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expPlan, gotPlan)
+			}
+		})
+	}
+}
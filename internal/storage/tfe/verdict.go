@@ -0,0 +1,72 @@
+package tfe
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+// CheckPlanHistoryGetter knows how to get the previous check plans of a workspace.
+type CheckPlanHistoryGetter interface {
+	GetPreviousCheckPlan(ctx context.Context, w model.Workspace, planID string) (*model.Plan, error)
+}
+
+// NewVerdictRepository returns a verdict repository that gets the previous verdicts from the TFE drift detection
+// plans history. TFE doesn't have a place to store the notification timestamps, so these are stored in memory.
+func NewVerdictRepository(g CheckPlanHistoryGetter) *VerdictRepository {
+	return &VerdictRepository{
+		g:        g,
+		verdicts: map[string]model.Verdict{},
+	}
+}
+
+type VerdictRepository struct {
+	g        CheckPlanHistoryGetter
+	verdicts map[string]model.Verdict
+	mu       sync.Mutex
+}
+
+func (v *VerdictRepository) GetPreviousVerdict(ctx context.Context, w model.Workspace) (*model.Verdict, error) {
+	if w.LastDriftPlan == nil {
+		return nil, fmt.Errorf("workspace without drift detection plan: %w", internalerrors.ErrNotExist)
+	}
+
+	v.mu.Lock()
+	stored, ok := v.verdicts[w.ID]
+	v.mu.Unlock()
+
+	// Already processed verdict.
+	if ok && stored.PlanID == w.LastDriftPlan.ID {
+		return &stored, nil
+	}
+
+	plan, err := v.g.GetPreviousCheckPlan(ctx, w, w.LastDriftPlan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	verdict := &model.Verdict{
+		State:     model.Workspace{LastDriftPlan: plan}.DriftState(),
+		PlanID:    plan.ID,
+		CreatedAt: plan.CreatedAt,
+	}
+
+	// Use our notification tracking if we processed the previous verdict.
+	if ok && stored.PlanID == plan.ID {
+		verdict.NotifiedAt = stored.NotifiedAt
+	}
+
+	return verdict, nil
+}
+
+func (v *VerdictRepository) StoreVerdict(ctx context.Context, w model.Workspace, verdict model.Verdict) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.verdicts[w.ID] = verdict
+
+	return nil
+}
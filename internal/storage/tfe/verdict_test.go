@@ -0,0 +1,63 @@
+package tfe_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/storage/tfe"
+)
+
+type checkPlanHistoryGetterFunc func(ctx context.Context, w model.Workspace, planID string) (*model.Plan, error)
+
+func (f checkPlanHistoryGetterFunc) GetPreviousCheckPlan(ctx context.Context, w model.Workspace, planID string) (*model.Plan, error) {
+	return f(ctx, w, planID)
+}
+
+func TestVerdictRepository(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	t0, _ := time.Parse(time.RFC3339, "2023-01-10T10:00:00Z")
+	history := map[string]*model.Plan{
+		"run-2": {ID: "run-1", Status: model.PlanStatusFinishedOK, HasChanges: true, CreatedAt: t0},
+		"run-3": {ID: "run-2", Status: model.PlanStatusFinishedOK, CreatedAt: t0.Add(time.Hour)},
+	}
+	r := tfe.NewVerdictRepository(checkPlanHistoryGetterFunc(func(ctx context.Context, w model.Workspace, planID string) (*model.Plan, error) {
+		p, ok := history[planID]
+		if !ok {
+			return nil, internalerrors.ErrNotExist
+		}
+		return p, nil
+	}))
+
+	// Without plan.
+	_, err := r.GetPreviousVerdict(context.TODO(), model.Workspace{ID: "ws-1"})
+	assert.True(errors.Is(err, internalerrors.ErrNotExist))
+
+	// From TFE history.
+	wk := model.Workspace{ID: "ws-1", LastDriftPlan: &model.Plan{ID: "run-2"}}
+	gotV, err := r.GetPreviousVerdict(context.TODO(), wk)
+	require.NoError(err)
+	assert.Equal(model.Verdict{State: model.DriftStateDrift, PlanID: "run-1", CreatedAt: t0}, *gotV)
+
+	// Already processed verdicts should be returned from memory.
+	v := model.Verdict{State: model.DriftStateOK, PlanID: "run-2", NotifiedAt: t0.Add(2 * time.Hour)}
+	err = r.StoreVerdict(context.TODO(), wk, v)
+	require.NoError(err)
+	gotV, err = r.GetPreviousVerdict(context.TODO(), wk)
+	require.NoError(err)
+	assert.Equal(v, *gotV)
+
+	// Next plans should use TFE history with the tracked notification.
+	wk.LastDriftPlan = &model.Plan{ID: "run-3"}
+	gotV, err = r.GetPreviousVerdict(context.TODO(), wk)
+	require.NoError(err)
+	assert.Equal(model.Verdict{State: model.DriftStateOK, PlanID: "run-2", CreatedAt: t0.Add(time.Hour), NotifiedAt: t0.Add(2 * time.Hour)}, *gotV)
+}
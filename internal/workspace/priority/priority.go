@@ -0,0 +1,162 @@
+// Package priority computes the drift detection priority of the workspaces, so the important ones
+// are drift detected before the rest.
+package priority
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+// ScorerConfig is the configuration of the priority Scorer.
+type ScorerConfig struct {
+	// WeightRules are the rules with the weight added to the workspaces they select, the
+	// weights of all the matched rules are added.
+	WeightRules []selector.Rule
+	// DriftWeight is the weight added to the workspaces whose latest drift detection plan had drift.
+	DriftWeight float64
+	// ErrorWeight is the weight added to the workspaces whose latest drift detection plan failed.
+	ErrorWeight float64
+	// AgeWeight is the weight added per hour since the latest drift detection plan.
+	AgeWeight float64
+	// MaxAge is the max age used to compute the age weight, the workspaces without drift detection
+	// plans will use it.
+	MaxAge  time.Duration
+	TimeNow func() time.Time
+}
+
+func (c *ScorerConfig) defaults() error {
+	for _, r := range c.WeightRules {
+		if _, err := strconv.ParseFloat(r.Value, 64); err != nil {
+			return fmt.Errorf("invalid weight %q on %q rule", r.Value, r)
+		}
+	}
+
+	if c.MaxAge == 0 {
+		c.MaxAge = 7 * 24 * time.Hour
+	}
+
+	if c.MaxAge < 0 {
+		return fmt.Errorf("max age can't be negative")
+	}
+
+	if c.TimeNow == nil {
+		c.TimeNow = time.Now
+	}
+
+	return nil
+}
+
+// Score is the priority of a workspace with the breakdown of how it has been computed.
+type Score struct {
+	// Value is the priority, the greater, the higher priority.
+	Value float64
+	// Rules are the weight rules that matched the workspace.
+	Rules       []string
+	RulesWeight float64
+	// Verdict is the drift state of the latest drift detection plan.
+	Verdict       model.DriftState
+	VerdictWeight float64
+	// Age is the time since the latest drift detection plan (capped to the max age).
+	Age       time.Duration
+	AgeWeight float64
+}
+
+func (s Score) String() string {
+	rules := "none"
+	if len(s.Rules) > 0 {
+		rules = strings.Join(s.Rules, ",")
+	}
+
+	return fmt.Sprintf("%s (rules %s: %s, verdict %s: %s, age %s: %s)",
+		FormatWeight(s.Value),
+		rules, FormatWeight(s.RulesWeight),
+		s.Verdict, FormatWeight(s.VerdictWeight),
+		s.Age, FormatWeight(s.AgeWeight))
+}
+
+// FormatWeight formats a priority weight rounded to two decimals.
+func FormatWeight(w float64) string {
+	return strconv.FormatFloat(math.Round(w*100)/100, 'f', -1, 64)
+}
+
+type weightRule struct {
+	rule   selector.Rule
+	weight float64
+}
+
+// Scorer computes the priority of the workspaces based on the weight rules, the latest drift
+// detection plan verdict and its age.
+type Scorer struct {
+	rules       []weightRule
+	driftWeight float64
+	errorWeight float64
+	ageWeight   float64
+	maxAge      time.Duration
+	timeNow     func() time.Time
+}
+
+// NewScorer returns a new priority Scorer.
+func NewScorer(config ScorerConfig) (*Scorer, error) {
+	err := config.defaults()
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	rules := make([]weightRule, 0, len(config.WeightRules))
+	for _, r := range config.WeightRules {
+		// Already validated.
+		w, _ := strconv.ParseFloat(r.Value, 64)
+		rules = append(rules, weightRule{rule: r, weight: w})
+	}
+
+	return &Scorer{
+		rules:       rules,
+		driftWeight: config.DriftWeight,
+		errorWeight: config.ErrorWeight,
+		ageWeight:   config.AgeWeight,
+		maxAge:      config.MaxAge,
+		timeNow:     config.TimeNow,
+	}, nil
+}
+
+// Score returns the priority of the workspace.
+func (s *Scorer) Score(wk model.Workspace) Score {
+	score := Score{Rules: []string{}, Verdict: wk.DriftState()}
+
+	for _, r := range s.rules {
+		if !r.rule.Selector.Match(wk) {
+			continue
+		}
+		score.Rules = append(score.Rules, r.rule.String())
+		score.RulesWeight += r.weight
+	}
+
+	switch score.Verdict {
+	case model.DriftStateDrift:
+		score.VerdictWeight = s.driftWeight
+	case model.DriftStateError:
+		score.VerdictWeight = s.errorWeight
+	}
+
+	score.Age = s.maxAge
+	if wk.LastDriftPlan != nil {
+		age := s.timeNow().Sub(wk.LastDriftPlan.CreatedAt).Truncate(time.Second)
+		if age < 0 {
+			age = 0
+		}
+		if age < s.maxAge {
+			score.Age = age
+		}
+	}
+	score.AgeWeight = score.Age.Hours() * s.ageWeight
+
+	score.Value = score.RulesWeight + score.VerdictWeight + score.AgeWeight
+
+	return score
+}
@@ -0,0 +1,141 @@
+package priority_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/priority"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+func TestScorerScore(t *testing.T) {
+	t0 := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+	rules := func(ss ...string) []selector.Rule {
+		rs, err := selector.ParseRules(ss)
+		require.NoError(t, err)
+		return rs
+	}
+
+	tests := map[string]struct {
+		config    priority.ScorerConfig
+		workspace model.Workspace
+		expScore  priority.Score
+		expString string
+		expErr    bool
+	}{
+		"Invalid weight rules should fail.": {
+			config: priority.ScorerConfig{WeightRules: rules("tag:critical=high")},
+			expErr: true,
+		},
+
+		"Negative max age should fail.": {
+			config: priority.ScorerConfig{MaxAge: -1 * time.Hour},
+			expErr: true,
+		},
+
+		"Workspaces without drift detection plans should use the max age.": {
+			config:    priority.ScorerConfig{AgeWeight: 1, MaxAge: 10 * time.Hour},
+			workspace: model.Workspace{Name: "wk1"},
+			expScore: priority.Score{
+				Value:     10,
+				Rules:     []string{},
+				Verdict:   model.DriftStateUnknown,
+				Age:       10 * time.Hour,
+				AgeWeight: 10,
+			},
+			expString: "10 (rules none: 0, verdict unknown: 0, age 10h0m0s: 10)",
+		},
+
+		"The age should be capped to the max age.": {
+			config: priority.ScorerConfig{AgeWeight: 0.5, MaxAge: 10 * time.Hour},
+			workspace: model.Workspace{Name: "wk1", LastDriftPlan: &model.Plan{
+				CreatedAt: t0.Add(-20 * time.Hour),
+				Status:    model.PlanStatusFinishedOK,
+			}},
+			expScore: priority.Score{
+				Value:     5,
+				Rules:     []string{},
+				Verdict:   model.DriftStateOK,
+				Age:       10 * time.Hour,
+				AgeWeight: 5,
+			},
+			expString: "5 (rules none: 0, verdict ok: 0, age 10h0m0s: 5)",
+		},
+
+		"All the matched rules weights should be added.": {
+			config: priority.ScorerConfig{
+				WeightRules: rules("tag:critical=100", "name:^prod-=50", "name:sandbox=-25.5"),
+				AgeWeight:   1,
+			},
+			workspace: model.Workspace{Name: "prod-db", Tags: []string{"critical"}, LastDriftPlan: &model.Plan{
+				CreatedAt: t0.Add(-2 * time.Hour),
+				Status:    model.PlanStatusFinishedOK,
+			}},
+			expScore: priority.Score{
+				Value:       152,
+				Rules:       []string{"tag:critical=100", "name:^prod-=50"},
+				RulesWeight: 150,
+				Verdict:     model.DriftStateOK,
+				Age:         2 * time.Hour,
+				AgeWeight:   2,
+			},
+			expString: "152 (rules tag:critical=100,name:^prod-=50: 150, verdict ok: 0, age 2h0m0s: 2)",
+		},
+
+		"Drift verdicts should add the drift weight.": {
+			config: priority.ScorerConfig{DriftWeight: 20, ErrorWeight: 10},
+			workspace: model.Workspace{Name: "wk1", LastDriftPlan: &model.Plan{
+				CreatedAt:  t0.Add(-1 * time.Hour),
+				Status:     model.PlanStatusFinishedOK,
+				HasChanges: true,
+			}},
+			expScore: priority.Score{
+				Value:         20,
+				Rules:         []string{},
+				Verdict:       model.DriftStateDrift,
+				VerdictWeight: 20,
+				Age:           1 * time.Hour,
+			},
+			expString: "20 (rules none: 0, verdict drift: 20, age 1h0m0s: 0)",
+		},
+
+		"Error verdicts should add the error weight.": {
+			config: priority.ScorerConfig{DriftWeight: 20, ErrorWeight: 10},
+			workspace: model.Workspace{Name: "wk1", LastDriftPlan: &model.Plan{
+				CreatedAt: t0.Add(-1 * time.Hour),
+				Status:    model.PlanStatusFinishedNotOK,
+			}},
+			expScore: priority.Score{
+				Value:         10,
+				Rules:         []string{},
+				Verdict:       model.DriftStateError,
+				VerdictWeight: 10,
+				Age:           1 * time.Hour,
+			},
+			expString: "10 (rules none: 0, verdict drift_plan_error: 10, age 1h0m0s: 0)",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			test.config.TimeNow = func() time.Time { return t0 }
+			s, err := priority.NewScorer(test.config)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			gotScore := s.Score(test.workspace)
+			assert.Equal(test.expScore, gotScore)
+			assert.Equal(test.expString, gotScore.String())
+		})
+	}
+}
@@ -44,6 +44,7 @@ func NewHydrateLatestDetectionPlanProcessor(ctx context.Context, logger log.Logg
 				// TODO(slok): Add strict as an option so we can fail or not based on this option.
 				// Don't stop all the  process for other workspaces because of one workspace error.
 				logger.WithValues(log.Kv{"workspace": result.wk.Name}).Errorf("could not get latest drift detection plan for workspaces %q: %w", result.wk.Name, result.err)
+				result.wk.ProcessErrors = append(result.wk.ProcessErrors, model.ProcessError{Kind: model.ProcessErrorKindAPI, Err: result.err})
 			}
 
 			result.wk.LastDriftPlan = result.plan
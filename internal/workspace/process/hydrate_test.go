@@ -50,7 +50,7 @@ func TestHydrateLatestDetectionPlanProcessor(t *testing.T) {
 			workspaces: []model.Workspace{{ID: "wk1"}, {ID: "wk2"}, {ID: "wk3"}},
 			expWorkspaces: []model.Workspace{
 				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1"}},
-				{ID: "wk2"},
+				{ID: "wk2", ProcessErrors: []model.ProcessError{{Kind: model.ProcessErrorKindAPI, Err: fmt.Errorf("something")}}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3"}},
 			},
 		},
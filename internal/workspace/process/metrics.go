@@ -0,0 +1,170 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+// RunStats are the stats of a drift detection run execution.
+type RunStats struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	// PlansCreated are the drift detection plans created on the run.
+	PlansCreated int
+	// PlanCreateErrors are the workspaces that could not create a drift detection plan.
+	PlanCreateErrors int
+	// PlanWaitErrors are the workspaces that the drift detection plan didn't finish in time.
+	PlanWaitErrors int
+	// DriftPlanErrors are the workspaces that the drift detection plan failed.
+	DriftPlanErrors int
+	// Failed is true when the run processing failed (the exit code decisions, e.g drift detected, are not failures).
+	Failed bool
+}
+
+type RunMetricsExporter interface {
+	ExportRunMetrics(ctx context.Context, wks []model.Workspace, stats RunStats) error
+}
+
+//go:generate mockery --case underscore --output processmock --outpkg processmock --name RunMetricsExporter
+
+// RunStatsRecorder is a metrics recorder that counts the drift detection plans of a run where they are
+// created and waited, so they can be used on the run stats.
+type RunStatsRecorder struct {
+	mu               sync.Mutex
+	plansCreated     int
+	planCreateErrors int
+	planWaitTimeouts int
+}
+
+// NewRunStatsRecorder returns a new RunStatsRecorder.
+func NewRunStatsRecorder() *RunStatsRecorder { return &RunStatsRecorder{} }
+
+func (r *RunStatsRecorder) AddDriftDetectionPlansCreated(_ context.Context, n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.plansCreated += n
+}
+
+func (r *RunStatsRecorder) AddDriftDetectionPlanCreateErrors(_ context.Context, n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.planCreateErrors += n
+}
+
+func (r *RunStatsRecorder) AddDriftDetectionPlanWaitTimeouts(_ context.Context, n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.planWaitTimeouts += n
+}
+
+func (r *RunStatsRecorder) AddFilteredWorkspaces(context.Context, string, int) {}
+
+// NewRunMetricsProcessor will wrap the drift detection run processor and export the metrics of the run after it,
+// even if it fails, so the run duration and errors are always exported. The metrics use the workspaces of the
+// processed snapshot (if nil, the ones returned by the run processor) and the plans counted by the run stats
+// recorder (that needs to be used by the drift detection plan processors), on dry run mode, as no plans are created,
+// the plan creation stats will be ignored.
+func NewRunMetricsProcessor(logger log.Logger, e RunMetricsExporter, rec *RunStatsRecorder, processed *WorkspaceSnapshot, startedAt time.Time, dryRun bool, p Processor) Processor {
+	logger = logger.WithValues(log.Kv{"workspace-processor": "RunMetrics"})
+
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) (resWks []model.Workspace, resErr error) {
+		defer func() {
+			exportWks := resWks
+			if processed != nil {
+				exportWks = processed.Workspaces()
+			}
+
+			var exitErr *internalerrors.ExitError
+			failed := resErr != nil && !errors.As(resErr, &exitErr)
+
+			err := e.ExportRunMetrics(ctx, exportWks, newRunStats(rec, exportWks, startedAt, dryRun, failed))
+			if err != nil {
+				// TODO(slok): Add strict as an option so we can fail or not based on this option.
+				// Don't stop all the process because of a metrics error.
+				logger.Errorf("Could not export run metrics: %s", err)
+				return
+			}
+
+			logger.Infof("Run metrics exported")
+		}()
+
+		return p.Process(ctx, wks)
+	})
+}
+
+func newRunStats(rec *RunStatsRecorder, wks []model.Workspace, startedAt time.Time, dryRun, failed bool) RunStats {
+	rec.mu.Lock()
+	stats := RunStats{
+		StartedAt:      startedAt,
+		Duration:       time.Since(startedAt),
+		PlanWaitErrors: rec.planWaitTimeouts,
+		Failed:         failed,
+	}
+	if !dryRun {
+		stats.PlansCreated = rec.plansCreated
+		stats.PlanCreateErrors = rec.planCreateErrors
+	}
+	rec.mu.Unlock()
+
+	for _, wk := range wks {
+		// The workspaces that could not create a plan have the previous drift detection plan.
+		if !dryRun && wk.HasProcessError(model.ProcessErrorKindAPI) {
+			continue
+		}
+
+		if wk.DriftState() == model.DriftStateError {
+			stats.DriftPlanErrors++
+		}
+	}
+
+	return stats
+}
+
+// MetricsRecorder knows how to record the workspace processors metrics.
+type MetricsRecorder interface {
+	// AddDriftDetectionPlansCreated adds the drift detection plans created.
+	AddDriftDetectionPlansCreated(ctx context.Context, n int)
+	// AddDriftDetectionPlanCreateErrors adds the drift detection plans that could not be created.
+	AddDriftDetectionPlanCreateErrors(ctx context.Context, n int)
+	// AddDriftDetectionPlanWaitTimeouts adds the drift detection plans that didn't finish in time.
+	AddDriftDetectionPlanWaitTimeouts(ctx context.Context, n int)
+	// AddFilteredWorkspaces adds the workspaces filtered by a processor.
+	AddFilteredWorkspaces(ctx context.Context, processor string, n int)
+}
+
+// NoopMetricsRecorder is a metrics recorder that doesn't record anything.
+var NoopMetricsRecorder MetricsRecorder = noopMetricsRecorder(0)
+
+type noopMetricsRecorder int
+
+func (noopMetricsRecorder) AddDriftDetectionPlansCreated(ctx context.Context, n int)           {}
+func (noopMetricsRecorder) AddDriftDetectionPlanCreateErrors(ctx context.Context, n int)       {}
+func (noopMetricsRecorder) AddDriftDetectionPlanWaitTimeouts(ctx context.Context, n int)       {}
+func (noopMetricsRecorder) AddFilteredWorkspaces(ctx context.Context, processor string, n int) {}
+
+// NewMeasuredProcessor records the workspaces filtered by the processor (the ones that are not returned)
+// using the processor name.
+func NewMeasuredProcessor(rec MetricsRecorder, name string, p Processor) Processor {
+	if rec == nil {
+		rec = NoopMetricsRecorder
+	}
+
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		newWks, err := p.Process(ctx, wks)
+		if err != nil {
+			return nil, err
+		}
+
+		if filtered := len(wks) - len(newWks); filtered > 0 {
+			rec.AddFilteredWorkspaces(ctx, name, filtered)
+		}
+
+		return newWks, nil
+	})
+}
@@ -0,0 +1,197 @@
+package process_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/process/processmock"
+)
+
+type testMetricsRecorder struct {
+	created      int
+	createErrors int
+	waitTimeouts int
+	filtered     map[string]int
+}
+
+func (t *testMetricsRecorder) AddDriftDetectionPlansCreated(ctx context.Context, n int) {
+	t.created += n
+}
+
+func (t *testMetricsRecorder) AddDriftDetectionPlanCreateErrors(ctx context.Context, n int) {
+	t.createErrors += n
+}
+
+func (t *testMetricsRecorder) AddDriftDetectionPlanWaitTimeouts(ctx context.Context, n int) {
+	t.waitTimeouts += n
+}
+
+func (t *testMetricsRecorder) AddFilteredWorkspaces(ctx context.Context, processor string, n int) {
+	if t.filtered == nil {
+		t.filtered = map[string]int{}
+	}
+	t.filtered[processor] += n
+}
+
+func TestRunMetricsProcessor(t *testing.T) {
+	t0 := time.Now().Add(-10 * time.Minute)
+	apiErr := []model.ProcessError{{Kind: model.ProcessErrorKindAPI, Err: fmt.Errorf("something")}}
+
+	wks := []model.Workspace{
+		{ID: "wk1", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(time.Minute), Status: model.PlanStatusFinishedOK}},
+		{ID: "wk2", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(time.Minute), Status: model.PlanStatusFinishedOK, HasChanges: true}},
+		{ID: "wk3", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(time.Minute), Status: model.PlanStatusFinishedNotOK}},
+		{ID: "wk4", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(time.Minute), Status: model.PlanStatusWaiting}},
+		{ID: "wk5", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(-time.Hour), Status: model.PlanStatusFinishedNotOK}, ProcessErrors: apiErr},
+		{ID: "wk6", ProcessErrors: apiErr},
+	}
+
+	// The stats are counted where the plans are created and waited, not from the plans timestamps.
+	record := func(rec *process.RunStatsRecorder) {
+		rec.AddDriftDetectionPlansCreated(context.TODO(), 4)
+		rec.AddDriftDetectionPlanCreateErrors(context.TODO(), 2)
+		rec.AddDriftDetectionPlanWaitTimeouts(context.TODO(), 1)
+	}
+
+	tests := map[string]struct {
+		mock       func(me *processmock.RunMetricsExporter)
+		dryRun     bool
+		processErr error
+		expErr     bool
+	}{
+		"Having workspaces should export the run stats.": {
+			mock: func(me *processmock.RunMetricsExporter) {
+				exp := func(s process.RunStats) bool {
+					return s.StartedAt.Equal(t0) && s.Duration >= 10*time.Minute && !s.Failed &&
+						s.PlansCreated == 4 && s.PlanCreateErrors == 2 && s.PlanWaitErrors == 1 && s.DriftPlanErrors == 1
+				}
+				me.On("ExportRunMetrics", mock.Anything, wks, mock.MatchedBy(exp)).Once().Return(nil)
+			},
+		},
+
+		"Having workspaces on dry run should not count the plan creations.": {
+			dryRun: true,
+			mock: func(me *processmock.RunMetricsExporter) {
+				exp := func(s process.RunStats) bool {
+					return s.PlansCreated == 0 && s.PlanCreateErrors == 0 && s.PlanWaitErrors == 1 && s.DriftPlanErrors == 2
+				}
+				me.On("ExportRunMetrics", mock.Anything, wks, mock.MatchedBy(exp)).Once().Return(nil)
+			},
+		},
+
+		"Having an error while exporting should not stop the process.": {
+			mock: func(me *processmock.RunMetricsExporter) {
+				me.On("ExportRunMetrics", mock.Anything, mock.Anything, mock.Anything).Once().Return(fmt.Errorf("something"))
+			},
+		},
+
+		"Having an error on the run processing should export the run stats as failed.": {
+			processErr: fmt.Errorf("something"),
+			mock: func(me *processmock.RunMetricsExporter) {
+				exp := func(s process.RunStats) bool {
+					return s.Failed && s.Duration >= 10*time.Minute && s.PlansCreated == 4 && s.PlanCreateErrors == 2
+				}
+				me.On("ExportRunMetrics", mock.Anything, wks, mock.MatchedBy(exp)).Once().Return(nil)
+			},
+			expErr: true,
+		},
+
+		"Having an exit code decision on the run processing should not export the run stats as failed.": {
+			processErr: &internalerrors.ExitError{Code: 1, Err: internalerrors.ErrDriftDetected},
+			mock: func(me *processmock.RunMetricsExporter) {
+				exp := func(s process.RunStats) bool { return !s.Failed }
+				me.On("ExportRunMetrics", mock.Anything, wks, mock.MatchedBy(exp)).Once().Return(nil)
+			},
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			me := processmock.NewRunMetricsExporter(t)
+			test.mock(me)
+
+			rec := process.NewRunStatsRecorder()
+			record(rec)
+
+			// The exported workspaces are the ones of the processed snapshot, even if the run fails afterwards.
+			processed := process.NewWorkspaceSnapshot()
+			run := process.NewProcessorChain([]process.Processor{
+				processed,
+				process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+					if test.processErr != nil {
+						return nil, test.processErr
+					}
+					return wks, nil
+				}),
+			})
+
+			p := process.NewRunMetricsProcessor(log.Noop, me, rec, processed, t0, test.dryRun, run)
+			gotWks, err := p.Process(context.TODO(), wks)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(wks, gotWks)
+			}
+		})
+	}
+}
+
+func TestMeasuredProcessor(t *testing.T) {
+	wks := []model.Workspace{{ID: "wk1"}, {ID: "wk2"}, {ID: "wk3"}}
+
+	tests := map[string]struct {
+		processor   process.Processor
+		expWks      []model.Workspace
+		expFiltered map[string]int
+		expErr      bool
+	}{
+		"A processor that doesn't filter workspaces should not record filtered workspaces.": {
+			processor: process.NoopProcessor,
+			expWks:    wks,
+		},
+
+		"A processor that filters workspaces should record the filtered workspaces.": {
+			processor: process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+				return wks[:1], nil
+			}),
+			expWks:      wks[:1],
+			expFiltered: map[string]int{"test": 2},
+		},
+
+		"A processor that fails should fail.": {
+			processor: process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+				return nil, fmt.Errorf("something")
+			}),
+			expErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			rec := &testMetricsRecorder{}
+			p := process.NewMeasuredProcessor(rec, "test", test.processor)
+			gotWks, err := p.Process(context.TODO(), wks)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expWks, gotWks)
+				assert.Equal(test.expFiltered, rec.filtered)
+			}
+		})
+	}
+}
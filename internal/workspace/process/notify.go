@@ -0,0 +1,39 @@
+package process
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+type WorkspaceNotifier interface {
+	Notify(ctx context.Context, wks []model.Workspace) error
+}
+
+//go:generate mockery --case underscore --output processmock --outpkg processmock --name WorkspaceNotifier
+
+// NewNotifyProcessor will send the received workspaces to the notifier.
+//
+// The notification errors don't stop the process unless strict is set, in that case the error will be returned
+// (e.g: track what workspaces have been notified).
+func NewNotifyProcessor(logger log.Logger, name string, n WorkspaceNotifier, strict bool) Processor {
+	logger = logger.WithValues(log.Kv{"workspace-processor": "Notify", "notifier": name})
+
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		logger.Infof("Notifying %d workspaces", len(wks))
+
+		err := n.Notify(ctx, wks)
+		if err != nil {
+			if strict {
+				return nil, fmt.Errorf("could not notify workspaces with %s: %w", name, err)
+			}
+
+			// Don't stop all the process because of a notification error.
+			logger.Errorf("Could not notify workspaces: %s", err)
+		}
+
+		return wks, nil
+	})
+}
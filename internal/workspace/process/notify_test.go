@@ -0,0 +1,67 @@
+package process_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/process/processmock"
+)
+
+func TestNotifyProcessor(t *testing.T) {
+	tests := map[string]struct {
+		mock          func(mn *processmock.WorkspaceNotifier)
+		strict        bool
+		workspaces    []model.Workspace
+		expWorkspaces []model.Workspace
+		expErr        bool
+	}{
+		"Having workspaces should notify them.": {
+			mock: func(mn *processmock.WorkspaceNotifier) {
+				mn.On("Notify", mock.Anything, []model.Workspace{{ID: "wk1"}, {ID: "wk2"}}).Once().Return(nil)
+			},
+			workspaces:    []model.Workspace{{ID: "wk1"}, {ID: "wk2"}},
+			expWorkspaces: []model.Workspace{{ID: "wk1"}, {ID: "wk2"}},
+		},
+
+		"Having an error while notifying should not stop the process.": {
+			mock: func(mn *processmock.WorkspaceNotifier) {
+				mn.On("Notify", mock.Anything, mock.Anything).Once().Return(fmt.Errorf("something"))
+			},
+			workspaces:    []model.Workspace{{ID: "wk1"}, {ID: "wk2"}},
+			expWorkspaces: []model.Workspace{{ID: "wk1"}, {ID: "wk2"}},
+		},
+
+		"Having an error while notifying in strict mode should fail.": {
+			mock: func(mn *processmock.WorkspaceNotifier) {
+				mn.On("Notify", mock.Anything, mock.Anything).Once().Return(fmt.Errorf("something"))
+			},
+			strict:     true,
+			workspaces: []model.Workspace{{ID: "wk1"}, {ID: "wk2"}},
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			mn := processmock.NewWorkspaceNotifier(t)
+			test.mock(mn)
+
+			p := process.NewNotifyProcessor(log.Noop, "test", mn, test.strict)
+			gotWks, err := p.Process(context.TODO(), test.workspaces)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.expWorkspaces, gotWks)
+			}
+		})
+	}
+}
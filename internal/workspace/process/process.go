@@ -3,6 +3,7 @@ package process
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/slok/tfe-drift/internal/model"
 )
@@ -41,3 +42,30 @@ func (noopProcessor) Process(ctx context.Context, wks []model.Workspace) ([]mode
 
 // NoopProcessor doesn't do anything.
 const NoopProcessor = noopProcessor(false)
+
+// ReloadableProcessor is a processor that delegates on a processor that can be replaced at any
+// time (e.g: configuration reloads), the running processes finish with the previous processor.
+type ReloadableProcessor struct {
+	mu sync.RWMutex
+	p  Processor
+}
+
+// NewReloadableProcessor returns a new reloadable processor starting with p.
+func NewReloadableProcessor(p Processor) *ReloadableProcessor {
+	return &ReloadableProcessor{p: p}
+}
+
+// Set replaces the processor used by the next processes.
+func (r *ReloadableProcessor) Set(p Processor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.p = p
+}
+
+func (r *ReloadableProcessor) Process(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+	r.mu.RLock()
+	p := r.p
+	r.mu.RUnlock()
+
+	return p.Process(ctx, wks)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/slok/tfe-drift/internal/model"
 	"github.com/slok/tfe-drift/internal/workspace/process"
@@ -135,3 +136,25 @@ func TestProcessorChain(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadableProcessor(t *testing.T) {
+	assert := assert.New(t)
+	require := require.New(t)
+
+	appendID := func(id string) process.Processor {
+		return process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+			return append(wks, model.Workspace{ID: id}), nil
+		})
+	}
+
+	p := process.NewReloadableProcessor(appendID("test1"))
+	gotWks, err := p.Process(context.TODO(), nil)
+	require.NoError(err)
+	assert.Equal([]model.Workspace{{ID: "test1"}}, gotWks)
+
+	// Once reloaded, the new processor should be used.
+	p.Set(appendID("test2"))
+	gotWks, err = p.Process(context.TODO(), nil)
+	require.NoError(err)
+	assert.Equal([]model.Workspace{{ID: "test2"}}, gotWks)
+}
@@ -1,131 +1,203 @@
 package process
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
+	"github.com/slok/tfe-drift/internal/exitpolicy"
 	"github.com/slok/tfe-drift/internal/internalerrors"
 	"github.com/slok/tfe-drift/internal/log"
 	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+	"github.com/slok/tfe-drift/internal/result"
 )
 
-func NewDriftDetectionPlansResultProcessor(logger log.Logger, noErrorDriftPlans bool) Processor {
+// ExitDecider is a processor that decides the exit code of the run using the exit policy, the decision is stored
+// so it can be used afterwards by the result outputs and the DriftDetectionPlansResult processor. The snapshot is
+// used to know the selected workspaces, if nil, the received workspaces will be used.
+type ExitDecider struct {
+	policy   *exitpolicy.Policy
+	all      *WorkspaceSnapshot
+	decision *exitpolicy.Decision
+	mu       sync.Mutex
+}
+
+// NewExitDecider returns a new ExitDecider.
+func NewExitDecider(policy *exitpolicy.Policy, all *WorkspaceSnapshot) *ExitDecider {
+	return &ExitDecider{policy: policy, all: all}
+}
+
+func (e *ExitDecider) Process(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+	selected := wks
+	if e.all != nil {
+		selected = e.all.Workspaces()
+	}
+
+	d := e.policy.Decide(selected, wks)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.decision = &d
+
+	return wks, nil
+}
+
+// Decision returns the exit code decision, nil if not decided yet.
+func (e *ExitDecider) Decision() *exitpolicy.Decision {
+	if e == nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.decision
+}
+
+var exitReasonErrors = map[exitpolicy.Reason]error{
+	exitpolicy.ReasonNoWorkspaces:   internalerrors.ErrNoWorkspacesSelected,
+	exitpolicy.ReasonDrift:          internalerrors.ErrDriftDetected,
+	exitpolicy.ReasonDriftPlanError: internalerrors.ErrDriftDetectionPlanFailed,
+	exitpolicy.ReasonWaitTimeout:    internalerrors.ErrWaitTimeout,
+	exitpolicy.ReasonAPIError:       internalerrors.ErrAPI,
+}
+
+// NewDriftDetectionPlansResultProcessor logs the drift detection results with the workspace severity and returns
+// an exit error based on the exit decision, the ExitDecider must be executed before this processor.
+func NewDriftDetectionPlansResultProcessor(logger log.Logger, d *ExitDecider) Processor {
 	logger = logger.WithValues(log.Kv{"workspace-processor": "DriftDetectionPlansResult"})
 
 	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
-		hasChanges := false
-		hasErrors := false
 		for _, wk := range wks {
 			var driftPlan model.Plan
 			if wk.LastDriftPlan != nil {
 				driftPlan = *wk.LastDriftPlan
 			}
 
+			severity, _ := d.policy.Severity(wk)
 			logger := logger.WithValues(log.Kv{
 				"workspace": wk.Name,
 				"run-id":    driftPlan.ID,
 				"run-url":   driftPlan.URL,
+				"severity":  severity,
 			})
 
 			switch {
 			case driftPlan.HasChanges:
-				hasChanges = true
 				logger.Warningf("Drift detected")
 			case driftPlan.Status == model.PlanStatusFinishedNotOK:
-				hasErrors = true
 				logger.Warningf("Drift detection plan failed")
 			}
 		}
 
-		switch {
-		case noErrorDriftPlans:
+		decision := d.Decision()
+		if decision == nil {
+			return nil, fmt.Errorf("exit code has not been decided")
+		}
+
+		if decision.Code == exitpolicy.CodeOK {
 			return wks, nil
-		case hasChanges:
-			return nil, internalerrors.ErrDriftDetected
-		case hasErrors:
-			return nil, internalerrors.ErrDriftDetectionPlanFailed
+		}
+
+		logger.WithValues(log.Kv{
+			"exit-code":   decision.Code,
+			"exit-reason": decision.Reason,
+			"exit-rule":   decision.Rule,
+		}).Debugf("Exit code decided")
+
+		err := exitReasonErrors[decision.Reason]
+		if len(decision.Workspaces) > 0 {
+			err = fmt.Errorf("%w on %d workspaces (%s rule)", err, len(decision.Workspaces), decision.Rule)
+		}
+
+		return nil, &internalerrors.ExitError{Code: decision.Code, Err: err}
+	})
+}
+
+// NewDetailedJSONResultProcessor will write the JSON result of the processed workspaces using the received result
+// schema version. The snapshot is used to know all the workspaces that could be processed (e.g: to get the skipped
+// ones), if nil, only the received workspaces will be used. The exit decider is optional and used to add the exit
+// code decision to the result.
+func NewDetailedJSONResultProcessor(out io.Writer, pretty bool, schemaVersion int, all *WorkspaceSnapshot, exit *ExitDecider) Processor {
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		allWks := wks
+		if all != nil {
+			allWks = all.Workspaces()
+		}
+
+		root, err := result.New(schemaVersion, wks, allWks, time.Now(), exit.Decision())
+		if err != nil {
+			return nil, fmt.Errorf("could not create result: %w", err)
+		}
+
+		data, err := marshallJSON(root, pretty)
+		if err != nil {
+			return nil, fmt.Errorf("the result could not be marshaled in JSON: %w", err)
+		}
+
+		_, err = out.Write(data)
+		if err != nil {
+			return nil, fmt.Errorf("result could not be written in the output: %w", err)
 		}
 
 		return wks, nil
 	})
 }
 
-func NewDetailedJSONResultProcessor(out io.Writer, pretty bool) Processor {
-	type jsonResultWorkspace struct {
-		Name                    string   `json:"name"`
-		ID                      string   `json:"id"`
-		Tags                    []string `json:"tags"`
-		DriftDetectionRunID     string   `json:"drift_detection_run_id"`
-		DriftDetectionRunURL    string   `json:"drift_detection_run_url"`
-		Drift                   bool     `json:"drift"`
-		DriftDetectionPlanError bool     `json:"drift_detection_plan_error"`
-		OK                      bool     `json:"ok"`
-		RunDuration             string   `json:"run_duration"`
-	}
+// WorkspaceSnapshot is a processor that stores the workspaces at a specific point of the processor chain,
+// this can be used afterwards to know what workspaces have been skipped by the processors.
+type WorkspaceSnapshot struct {
+	wks []model.Workspace
+	mu  sync.Mutex
+}
 
-	type jsonResult struct {
-		Workspaces              map[string]jsonResultWorkspace `json:"workspaces"`
-		Drift                   bool                           `json:"drift"`
-		DriftDetectionPlanError bool                           `json:"drift_detection_plan_error"`
-		OK                      bool                           `json:"ok"`
-		CreatedAt               time.Time                      `json:"created_at"`
-	}
+// NewWorkspaceSnapshot returns a new WorkspaceSnapshot.
+func NewWorkspaceSnapshot() *WorkspaceSnapshot {
+	return &WorkspaceSnapshot{}
+}
 
-	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
-		drift := false
-		driftError := false
-		workspaces := map[string]jsonResultWorkspace{}
-		for _, wk := range wks {
-			var driftPlan model.Plan
-			if wk.LastDriftPlan != nil {
-				driftPlan = *wk.LastDriftPlan
-			}
+func (s *WorkspaceSnapshot) Process(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-			hasDrift := driftPlan.HasChanges
-			hasDriftDetectionError := driftPlan.Status == model.PlanStatusFinishedNotOK
-
-			jrwk := jsonResultWorkspace{
-				Name:                    wk.Name,
-				ID:                      wk.ID,
-				Tags:                    wk.Tags,
-				DriftDetectionRunID:     driftPlan.ID,
-				DriftDetectionRunURL:    driftPlan.URL,
-				Drift:                   hasDrift,
-				DriftDetectionPlanError: hasDriftDetectionError,
-				OK:                      !hasDrift && !hasDriftDetectionError,
-				RunDuration:             driftPlan.PlanRunDuration.String(),
-			}
+	s.wks = append([]model.Workspace{}, wks...)
 
-			if hasDrift {
-				drift = true
-				jrwk.Drift = true
-			}
+	return wks, nil
+}
 
-			if hasDriftDetectionError {
-				driftError = true
-				jrwk.DriftDetectionPlanError = true
-			}
+// Workspaces returns the snapshot workspaces.
+func (s *WorkspaceSnapshot) Workspaces() []model.Workspace {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-			workspaces[wk.Name] = jrwk
-		}
+	return s.wks
+}
 
-		root := jsonResult{
-			Workspaces:              workspaces,
-			Drift:                   drift,
-			DriftDetectionPlanError: driftError,
-			OK:                      !drift && !driftError,
-			CreatedAt:               time.Now().UTC(),
+// NewReportResultProcessor will render a report of the processed workspaces with the renderer. The snapshot is used
+// to know all the workspaces that could be processed (e.g: to get the skipped ones), if nil, only the received
+// workspaces will be used.
+func NewReportResultProcessor(out io.Writer, renderer report.Renderer, all *WorkspaceSnapshot) Processor {
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		allWks := wks
+		if all != nil {
+			allWks = all.Workspaces()
 		}
 
-		data, err := marshallJSON(root, pretty)
+		r := report.New(wks, allWks, time.Now())
+
+		// Render first so we write the output all at once.
+		var b bytes.Buffer
+		err := renderer.Render(&b, r)
 		if err != nil {
-			return nil, fmt.Errorf("the result could not be marshaled in JSON: %w", err)
+			return nil, fmt.Errorf("the result could not be rendered: %w", err)
 		}
 
-		_, err = out.Write(data)
+		_, err = out.Write(b.Bytes())
 		if err != nil {
 			return nil, fmt.Errorf("result could not be written in the output: %w", err)
 		}
@@ -3,26 +3,43 @@ package process_test
 import (
 	"bytes"
 	"context"
+	"fmt"
+	"io"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/slok/tfe-drift/internal/exitpolicy"
+	"github.com/slok/tfe-drift/internal/internalerrors"
 	"github.com/slok/tfe-drift/internal/log"
 	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/report"
+	"github.com/slok/tfe-drift/internal/result"
 	"github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
 )
 
 func TestDriftDetectionPlansResultProcessor(t *testing.T) {
 	tests := map[string]struct {
 		noErrorOnDrift bool
+		severityRules  []string
+		snapshot       []model.Workspace
 		workspaces     []model.Workspace
-		expErr         bool
+		expErr         error
+		expCode        int
 	}{
-		"Not having workspaces shouldn't error.": {
+		"Not having workspaces should fail with the no workspaces selected code.": {
+			workspaces: []model.Workspace{},
+			expErr:     internalerrors.ErrNoWorkspacesSelected,
+			expCode:    6,
+		},
+
+		"Having selected workspaces without processed workspaces shouldn't error.": {
+			snapshot:   []model.Workspace{{ID: "wk1"}},
 			workspaces: []model.Workspace{},
-			expErr:     false,
 		},
 
 		"Having workspaces without changes should not fail.": {
@@ -31,7 +48,6 @@ func TestDriftDetectionPlansResultProcessor(t *testing.T) {
 				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2", HasChanges: false}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3", HasChanges: false}},
 			},
-			expErr: false,
 		},
 
 		"Having a workspace with changes should fail.": {
@@ -40,7 +56,8 @@ func TestDriftDetectionPlansResultProcessor(t *testing.T) {
 				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2", HasChanges: true}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3", HasChanges: false}},
 			},
-			expErr: true,
+			expErr:  internalerrors.ErrDriftDetected,
+			expCode: 2,
 		},
 
 		"Having a workspace with plan errors should fail.": {
@@ -49,7 +66,8 @@ func TestDriftDetectionPlansResultProcessor(t *testing.T) {
 				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2", Status: model.PlanStatusFinishedNotOK}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3", Status: model.PlanStatusFinishedOK}},
 			},
-			expErr: true,
+			expErr:  internalerrors.ErrDriftDetectionPlanFailed,
+			expCode: 3,
 		},
 
 		"Having a workspace with changes but with no error on drift option, should not fail.": {
@@ -59,19 +77,64 @@ func TestDriftDetectionPlansResultProcessor(t *testing.T) {
 				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2", HasChanges: true}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3", Status: model.PlanStatusFinishedNotOK}},
 			},
-			expErr: false,
+		},
+
+		"Having a workspace with changes and warning severity, should not fail.": {
+			severityRules: []string{"tag:sandbox=warning"},
+			workspaces: []model.Workspace{
+				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1", HasChanges: false}},
+				{ID: "wk2", Tags: []string{"sandbox"}, LastDriftPlan: &model.Plan{ID: "p2", HasChanges: true}},
+			},
+		},
+
+		"Having a workspace with wait timeouts should fail.": {
+			workspaces: []model.Workspace{
+				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1", HasChanges: false}},
+				{ID: "wk2", ProcessErrors: []model.ProcessError{{Kind: model.ProcessErrorKindWaitTimeout}}},
+			},
+			expErr:  internalerrors.ErrWaitTimeout,
+			expCode: 5,
+		},
+
+		"Having a workspace with API errors should fail.": {
+			workspaces: []model.Workspace{
+				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1", HasChanges: false}},
+				{ID: "wk2", ProcessErrors: []model.ProcessError{{Kind: model.ProcessErrorKindAPI}}},
+			},
+			expErr:  internalerrors.ErrAPI,
+			expCode: 4,
 		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
+			require := require.New(t)
 
-			p := process.NewDriftDetectionPlansResultProcessor(log.Noop, test.noErrorOnDrift)
-			_, err := p.Process(context.TODO(), test.workspaces)
+			rules, err := selector.ParseRules(test.severityRules)
+			require.NoError(err)
+			policy, err := exitpolicy.NewPolicy(exitpolicy.PolicyConfig{
+				SeverityRules:         rules,
+				DisableDriftPlanCodes: test.noErrorOnDrift,
+			})
+			require.NoError(err)
 
-			if test.expErr {
-				assert.Error(err)
+			var snapshot *process.WorkspaceSnapshot
+			if test.snapshot != nil {
+				snapshot = process.NewWorkspaceSnapshot()
+				_, _ = snapshot.Process(context.TODO(), test.snapshot)
+			}
+
+			d := process.NewExitDecider(policy, snapshot)
+			p := process.NewProcessorChain([]process.Processor{d, process.NewDriftDetectionPlansResultProcessor(log.Noop, d)})
+			_, err = p.Process(context.TODO(), test.workspaces)
+
+			if test.expErr != nil {
+				var exitErr *internalerrors.ExitError
+				if assert.ErrorAs(err, &exitErr) {
+					assert.ErrorIs(err, test.expErr)
+					assert.Equal(test.expCode, exitErr.Code)
+				}
 			} else {
 				assert.NoError(err)
 			}
@@ -81,13 +144,24 @@ func TestDriftDetectionPlansResultProcessor(t *testing.T) {
 
 func TestDetailedJSONResultProcessor(t *testing.T) {
 	tests := map[string]struct {
+		schemaVersion  int
+		snapshot       []model.Workspace
+		exitDecision   bool
 		workspaces     []model.Workspace
 		expResultRegex *regexp.Regexp
 		expErr         bool
 	}{
+		"Having an unknown schema version should fail.": {
+			schemaVersion: 99,
+			workspaces:    []model.Workspace{},
+			expErr:        true,
+		},
+
 		"Not having workspaces shouldn't error.": {
-			workspaces: []model.Workspace{},
+			schemaVersion: result.VersionV1,
+			workspaces:    []model.Workspace{},
 			expResultRegex: regexp.MustCompile(`{
+	"schema_version": 1,
 	"workspaces": {},
 	"drift": false,
 	"drift_detection_plan_error": false,
@@ -97,12 +171,14 @@ func TestDetailedJSONResultProcessor(t *testing.T) {
 		},
 
 		"Having workspaces should return the result.": {
+			schemaVersion: result.VersionV1,
 			workspaces: []model.Workspace{
 				{ID: "wk1", Name: "wk1", Tags: []string{"t1"}, LastDriftPlan: &model.Plan{ID: "p1", HasChanges: false, PlanRunDuration: 1 * time.Second}},
 				{ID: "wk2", Name: "wk2", Tags: []string{"t2"}, LastDriftPlan: &model.Plan{ID: "p2", HasChanges: true, PlanRunDuration: 17 * time.Second}},
 				{ID: "wk3", Name: "wk3", Tags: []string{"t3"}, LastDriftPlan: &model.Plan{ID: "p3", Status: model.PlanStatusFinishedNotOK, PlanRunDuration: 5 * time.Second}},
 			},
 			expResultRegex: regexp.MustCompile(`{
+	"schema_version": 1,
 	"workspaces": {
 		"wk1": {
 			"name": "wk1",
@@ -148,6 +224,66 @@ func TestDetailedJSONResultProcessor(t *testing.T) {
 	"drift_detection_plan_error": true,
 	"ok": false,
 	"created_at": ".*"
+}`),
+		},
+
+		"Having workspaces with schema version 2 should return the v2 result with the skipped workspaces and the exit decision.": {
+			schemaVersion: result.VersionV2,
+			snapshot:      []model.Workspace{{ID: "wk1", Name: "wk1"}, {ID: "wk2", Name: "wk2"}},
+			exitDecision:  true,
+			workspaces: []model.Workspace{
+				{ID: "wk1", Name: "wk1", Tags: []string{"t1"}, LastDriftPlan: &model.Plan{ID: "p1", HasChanges: true, Status: model.PlanStatusFinishedOK, PlanRunDuration: 1 * time.Second}},
+			},
+			expResultRegex: regexp.MustCompile(`{
+	"schema_version": 2,
+	"workspaces": \[
+		{
+			"name": "wk1",
+			"id": "wk1",
+			"organization": "",
+			"tags": \[
+				"t1"
+			\],
+			"status": "drift",
+			"drift_detection_run": {
+				"id": "p1",
+				"url": "",
+				"created_at": "0001-01-01T00:00:00Z",
+				"duration_seconds": 1,
+				"resources": null
+			}
+		}
+	\],
+	"skipped": \[
+		{
+			"name": "wk2",
+			"id": "wk2",
+			"organization": "",
+			"tags": \[\],
+			"status": "skipped",
+			"drift_detection_run": null
+		}
+	\],
+	"summary": {
+		"total": 2,
+		"ok": 0,
+		"drift": 1,
+		"drift_detection_plan_error": 0,
+		"unknown": 0,
+		"skipped": 1
+	},
+	"drift": true,
+	"drift_detection_plan_error": false,
+	"ok": false,
+	"exit": {
+		"code": 2,
+		"reason": "drift",
+		"rule": "default",
+		"workspaces": \[
+			"wk1"
+		\]
+	},
+	"created_at": ".*"
 }`),
 		},
 	}
@@ -156,8 +292,22 @@ func TestDetailedJSONResultProcessor(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			assert := assert.New(t)
 
+			var snapshot *process.WorkspaceSnapshot
+			if test.snapshot != nil {
+				snapshot = process.NewWorkspaceSnapshot()
+				_, _ = snapshot.Process(context.TODO(), test.snapshot)
+			}
+
+			var exitDecider *process.ExitDecider
+			if test.exitDecision {
+				policy, err := exitpolicy.NewPolicy(exitpolicy.PolicyConfig{})
+				require.NoError(t, err)
+				exitDecider = process.NewExitDecider(policy, snapshot)
+				_, _ = exitDecider.Process(context.TODO(), test.workspaces)
+			}
+
 			var b bytes.Buffer
-			p := process.NewDetailedJSONResultProcessor(&b, true)
+			p := process.NewDetailedJSONResultProcessor(&b, true, test.schemaVersion, snapshot, exitDecider)
 			_, err := p.Process(context.TODO(), test.workspaces)
 
 			if test.expErr {
@@ -168,3 +318,62 @@ func TestDetailedJSONResultProcessor(t *testing.T) {
 		})
 	}
 }
+
+func TestReportResultProcessor(t *testing.T) {
+	tests := map[string]struct {
+		snapshot   []model.Workspace
+		workspaces []model.Workspace
+		renderErr  error
+		expResult  string
+		expErr     bool
+	}{
+		"Not having snapshot should use the processed workspaces.": {
+			workspaces: []model.Workspace{{ID: "wk1", Name: "wk1"}, {ID: "wk2", Name: "wk2"}},
+			expResult:  "processed: 2, skipped: 0",
+		},
+
+		"Having a snapshot should report the skipped workspaces.": {
+			snapshot:   []model.Workspace{{ID: "wk1", Name: "wk1"}, {ID: "wk2", Name: "wk2"}, {ID: "wk3", Name: "wk3"}},
+			workspaces: []model.Workspace{{ID: "wk1", Name: "wk1"}},
+			expResult:  "processed: 1, skipped: 2",
+		},
+
+		"Having an error while rendering should fail without writing.": {
+			workspaces: []model.Workspace{{ID: "wk1", Name: "wk1"}},
+			renderErr:  fmt.Errorf("something"),
+			expErr:     true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			var snapshot *process.WorkspaceSnapshot
+			if test.snapshot != nil {
+				snapshot = process.NewWorkspaceSnapshot()
+				_, _ = snapshot.Process(context.TODO(), test.snapshot)
+			}
+
+			renderer := report.RendererFunc(func(w io.Writer, r report.Report) error {
+				if test.renderErr != nil {
+					_, _ = w.Write([]byte("partial"))
+					return test.renderErr
+				}
+				_, err := fmt.Fprintf(w, "processed: %d, skipped: %d", len(r.Workspaces), len(r.Skipped))
+				return err
+			})
+
+			var b bytes.Buffer
+			p := process.NewReportResultProcessor(&b, renderer, snapshot)
+			_, err := p.Process(context.TODO(), test.workspaces)
+
+			if test.expErr {
+				assert.Error(err)
+				assert.Empty(b.String())
+			} else if assert.NoError(err) {
+				assert.Equal(test.expResult, b.String())
+			}
+		})
+	}
+}
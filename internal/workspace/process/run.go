@@ -13,12 +13,16 @@ type WorkspaceCheckPlanCreator interface {
 
 //go:generate mockery --case underscore --output processmock --outpkg processmock --name WorkspaceCheckPlanCreator
 
-func NewDriftDetectionPlanProcessor(logger log.Logger, c WorkspaceCheckPlanCreator, planMessage string) Processor {
+func NewDriftDetectionPlanProcessor(logger log.Logger, rec MetricsRecorder, c WorkspaceCheckPlanCreator, planMessage string) Processor {
 	logger = logger.WithValues(log.Kv{"workspace-processor": "DriftDetectionPlan"})
+	if rec == nil {
+		rec = NoopMetricsRecorder
+	}
 
 	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
 		newWks := []model.Workspace{}
 		createdPlans := 0
+		createErrors := 0
 		for _, wk := range wks {
 			logger := logger.WithValues(log.Kv{"workspace": wk.Name})
 
@@ -27,6 +31,8 @@ func NewDriftDetectionPlanProcessor(logger log.Logger, c WorkspaceCheckPlanCreat
 				// TODO(slok): Add strict as an option so we can fail or not based on this option.
 				// Don't stop all the  process for other workspaces because of one workspace error.
 				logger.Errorf("Could not create drift detection plan: %s", err)
+				createErrors++
+				wk.ProcessErrors = append(wk.ProcessErrors, model.ProcessError{Kind: model.ProcessErrorKindAPI, Err: err})
 			} else {
 				createdPlans++
 				wk.LastDriftPlan = plan
@@ -37,6 +43,8 @@ func NewDriftDetectionPlanProcessor(logger log.Logger, c WorkspaceCheckPlanCreat
 		}
 
 		logger.Infof("%d drift detection plans created", createdPlans)
+		rec.AddDriftDetectionPlansCreated(ctx, createdPlans)
+		rec.AddDriftDetectionPlanCreateErrors(ctx, createErrors)
 
 		return newWks, nil
 	})
@@ -19,6 +19,8 @@ func TestDriftDetectionPlanProcessor(t *testing.T) {
 		mock          func(mc *processmock.WorkspaceCheckPlanCreator)
 		workspaces    []model.Workspace
 		expWorkspaces []model.Workspace
+		expCreated    int
+		expErrors     int
 		expErr        bool
 	}{
 		"Not having workspaces shouldn't create any plan.": {
@@ -39,6 +41,7 @@ func TestDriftDetectionPlanProcessor(t *testing.T) {
 				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2"}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3"}},
 			},
+			expCreated: 3,
 		},
 
 		"Having an error while create drift detection plans should not stop the process.": {
@@ -50,9 +53,11 @@ func TestDriftDetectionPlanProcessor(t *testing.T) {
 			workspaces: []model.Workspace{{ID: "wk1"}, {ID: "wk2"}, {ID: "wk3"}},
 			expWorkspaces: []model.Workspace{
 				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1"}},
-				{ID: "wk2"},
+				{ID: "wk2", ProcessErrors: []model.ProcessError{{Kind: model.ProcessErrorKindAPI, Err: fmt.Errorf("something")}}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3"}},
 			},
+			expCreated: 2,
+			expErrors:  1,
 		},
 	}
 
@@ -62,13 +67,16 @@ func TestDriftDetectionPlanProcessor(t *testing.T) {
 			mc := processmock.NewWorkspaceCheckPlanCreator(t)
 			test.mock(mc)
 
-			p := process.NewDriftDetectionPlanProcessor(log.Noop, mc, "test")
+			rec := &testMetricsRecorder{}
+			p := process.NewDriftDetectionPlanProcessor(log.Noop, rec, mc, "test")
 			gotWks, err := p.Process(context.TODO(), test.workspaces)
 
 			if test.expErr {
 				assert.Error(err)
 			} else if assert.NoError(err) {
 				assert.Equal(test.expWorkspaces, gotWks)
+				assert.Equal(test.expCreated, rec.created)
+				assert.Equal(test.expErrors, rec.createErrors)
 			}
 		})
 	}
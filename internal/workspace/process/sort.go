@@ -2,11 +2,17 @@ package process
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"math/rand"
 	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/slok/tfe-drift/internal/log"
 	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/priority"
 )
 
 // NewSortByOldestDetectionPlanProcessor will sort the received workspaces by its latest drift detection plan.
@@ -18,18 +24,109 @@ func NewSortByOldestDetectionPlanProcessor(logger log.Logger) Processor {
 		logger.Infof("Sorting Workspaces by oldest drift detection")
 
 		sort.SliceStable(wks, func(i, j int) bool {
-			// If not last drift plan, treat as the oldest possible TS.
-			var ti, tj time.Time
-			if wks[i].LastDriftPlan != nil {
-				ti = wks[i].LastDriftPlan.CreatedAt
-			}
-			if wks[j].LastDriftPlan != nil {
-				tj = wks[j].LastDriftPlan.CreatedAt
-			}
+			return lastDriftPlanCreatedAt(wks[i]).Before(lastDriftPlanCreatedAt(wks[j]))
+		})
+
+		return wks, nil
+	})
+}
+
+// lastDriftPlanCreatedAt returns the creation time of the latest drift detection plan, if the workspace
+// doesn't have one, it will be treated as the oldest possible TS.
+func lastDriftPlanCreatedAt(wk model.Workspace) time.Time {
+	if wk.LastDriftPlan == nil {
+		return time.Time{}
+	}
+
+	return wk.LastDriftPlan.CreatedAt
+}
 
-			return ti.Before(tj)
+// NewSortByNameProcessor will sort the received workspaces alphabetically by their name.
+func NewSortByNameProcessor(logger log.Logger) Processor {
+	logger = logger.WithValues(log.Kv{"workspace-processor": "NewSortByName"})
+
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		logger.Infof("Sorting Workspaces by name")
+
+		sort.SliceStable(wks, func(i, j int) bool { return wks[i].Name < wks[j].Name })
+
+		return wks, nil
+	})
+}
+
+// NewSortRandomProcessor will shuffle the received workspaces.
+func NewSortRandomProcessor(logger log.Logger) Processor {
+	logger = logger.WithValues(log.Kv{"workspace-processor": "NewSortRandom"})
+
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		logger.Infof("Sorting Workspaces randomly")
+
+		rand.Shuffle(len(wks), func(i, j int) { wks[i], wks[j] = wks[j], wks[i] })
+
+		return wks, nil
+	})
+}
+
+// NewSortByPriorityProcessor will sort the received workspaces by their priority, setting the first ones the
+// ones with the highest priority, on the same priority the oldest detection plan ones go first.
+//
+// If an explain writer is set, the sorted workspaces priority breakdown will be written on every process.
+func NewSortByPriorityProcessor(logger log.Logger, scorer *priority.Scorer, explain io.Writer) Processor {
+	logger = logger.WithValues(log.Kv{"workspace-processor": "NewSortByPriority"})
+
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		logger.Infof("Sorting Workspaces by priority")
+
+		scored := make([]scoredWorkspace, 0, len(wks))
+		for _, wk := range wks {
+			s := scorer.Score(wk)
+			scored = append(scored, scoredWorkspace{wk: wk, score: s})
+			logger.WithValues(log.Kv{"workspace": wk.Name, "priority": s.Value}).Debugf("Workspace priority: %s", s)
+		}
+
+		sort.SliceStable(scored, func(i, j int) bool {
+			si, sj := scored[i].score.Value, scored[j].score.Value
+			if si != sj {
+				return si > sj
+			}
+			return lastDriftPlanCreatedAt(scored[i].wk).Before(lastDriftPlanCreatedAt(scored[j].wk))
 		})
 
+		for i, s := range scored {
+			wks[i] = s.wk
+		}
+
+		if explain != nil {
+			err := writePriorityExplain(explain, scored)
+			if err != nil {
+				return nil, fmt.Errorf("could not write priority explain: %w", err)
+			}
+		}
+
 		return wks, nil
 	})
 }
+
+type scoredWorkspace struct {
+	wk    model.Workspace
+	score priority.Score
+}
+
+func writePriorityExplain(w io.Writer, scored []scoredWorkspace) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POSITION\tWORKSPACE\tPRIORITY\tRULES\tRULES WEIGHT\tVERDICT\tVERDICT WEIGHT\tAGE\tAGE WEIGHT")
+	for i, sw := range scored {
+		s := sw.score
+		rules := "-"
+		if len(s.Rules) > 0 {
+			rules = strings.Join(s.Rules, ",")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i+1, sw.wk.Name,
+			priority.FormatWeight(s.Value),
+			rules, priority.FormatWeight(s.RulesWeight),
+			s.Verdict, priority.FormatWeight(s.VerdictWeight),
+			s.Age, priority.FormatWeight(s.AgeWeight))
+	}
+
+	return tw.Flush()
+}
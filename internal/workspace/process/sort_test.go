@@ -1,15 +1,19 @@
 package process_test
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/slok/tfe-drift/internal/log"
 	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/priority"
 	"github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
 )
 
 func TestSortByOldestDetectionPlanProcessor(t *testing.T) {
@@ -59,3 +63,111 @@ func TestSortByOldestDetectionPlanProcessor(t *testing.T) {
 		})
 	}
 }
+
+func TestSortByNameProcessor(t *testing.T) {
+	tests := map[string]struct {
+		workspaces    []model.Workspace
+		expWorkspaces []model.Workspace
+	}{
+		"Having no workspaces should not fail": {
+			workspaces:    []model.Workspace{},
+			expWorkspaces: []model.Workspace{},
+		},
+		"Having workspaces should sort them by name.": {
+			workspaces:    []model.Workspace{{Name: "wk-b"}, {Name: "wk-c"}, {Name: "wk-a"}},
+			expWorkspaces: []model.Workspace{{Name: "wk-a"}, {Name: "wk-b"}, {Name: "wk-c"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			p := process.NewSortByNameProcessor(log.Noop)
+			gotWks, err := p.Process(context.TODO(), test.workspaces)
+			if assert.NoError(err) {
+				assert.Equal(test.expWorkspaces, gotWks)
+			}
+		})
+	}
+}
+
+func TestSortRandomProcessor(t *testing.T) {
+	assert := assert.New(t)
+
+	wks := []model.Workspace{{Name: "wk-a"}, {Name: "wk-b"}, {Name: "wk-c"}, {Name: "wk-d"}}
+	expWks := append([]model.Workspace{}, wks...)
+
+	p := process.NewSortRandomProcessor(log.Noop)
+	gotWks, err := p.Process(context.TODO(), wks)
+	if assert.NoError(err) {
+		assert.ElementsMatch(expWks, gotWks)
+	}
+}
+
+func TestSortByPriorityProcessor(t *testing.T) {
+	t0 := time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		weightRules   []string
+		workspaces    []model.Workspace
+		expWorkspaces []string
+		expExplain    string
+	}{
+		"Having no workspaces should not fail": {
+			workspaces:    []model.Workspace{},
+			expWorkspaces: []string{},
+			expExplain:    "POSITION  WORKSPACE  PRIORITY  RULES  RULES WEIGHT  VERDICT  VERDICT WEIGHT  AGE  AGE WEIGHT\n",
+		},
+		"Having workspaces should sort them by priority, and by oldest detection plan on the same priority.": {
+			weightRules: []string{"tag:critical=100", "name:sandbox=-50"},
+			workspaces: []model.Workspace{
+				{Name: "sandbox-1", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(-10 * time.Hour), Status: model.PlanStatusFinishedNotOK}},
+				{Name: "wk-1", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(-1 * time.Hour), Status: model.PlanStatusFinishedOK}},
+				{Name: "wk-2", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(-2 * time.Hour), Status: model.PlanStatusFinishedOK, HasChanges: true}},
+				{Name: "wk-3", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(-3 * time.Hour), Status: model.PlanStatusFinishedOK}},
+				{Name: "critical-1", Tags: []string{"critical"}, LastDriftPlan: &model.Plan{CreatedAt: t0.Add(-1 * time.Hour), Status: model.PlanStatusFinishedOK}},
+				{Name: "wk-4", LastDriftPlan: &model.Plan{CreatedAt: t0.Add(-2 * time.Hour), Status: model.PlanStatusFinishedOK}},
+			},
+			expWorkspaces: []string{"critical-1", "wk-2", "wk-3", "wk-4", "wk-1", "sandbox-1"},
+			expExplain: `POSITION  WORKSPACE   PRIORITY  RULES             RULES WEIGHT  VERDICT           VERDICT WEIGHT  AGE      AGE WEIGHT
+1         critical-1  101       tag:critical=100  100           ok                0               1h0m0s   1
+2         wk-2        12        -                 0             drift             10              2h0m0s   2
+3         wk-3        3         -                 0             ok                0               3h0m0s   3
+4         wk-4        2         -                 0             ok                0               2h0m0s   2
+5         wk-1        1         -                 0             ok                0               1h0m0s   1
+6         sandbox-1   -35       name:sandbox=-50  -50           drift_plan_error  5               10h0m0s  10
+`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rules, err := selector.ParseRules(test.weightRules)
+			require.NoError(err)
+			scorer, err := priority.NewScorer(priority.ScorerConfig{
+				WeightRules: rules,
+				DriftWeight: 10,
+				ErrorWeight: 5,
+				AgeWeight:   1,
+				TimeNow:     func() time.Time { return t0 },
+			})
+			require.NoError(err)
+
+			var explain bytes.Buffer
+			p := process.NewSortByPriorityProcessor(log.Noop, scorer, &explain)
+			gotWks, err := p.Process(context.TODO(), test.workspaces)
+			require.NoError(err)
+
+			gotNames := []string{}
+			for _, wk := range gotWks {
+				gotNames = append(gotNames, wk.Name)
+			}
+			assert.Equal(test.expWorkspaces, gotNames)
+			assert.Equal(test.expExplain, explain.String())
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package process
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+type WorkspaceVerdictRepository interface {
+	GetPreviousVerdict(ctx context.Context, w model.Workspace) (*model.Verdict, error)
+	StoreVerdict(ctx context.Context, w model.Workspace, v model.Verdict) error
+}
+
+//go:generate mockery --case underscore --output processmock --outpkg processmock --name WorkspaceVerdictRepository
+
+// NewTransitionNotifyProcessor will track the drift state transitions of the workspaces (e.g ok -> drift, drift -> ok,
+// ok -> drift_plan_error...) and only pass to the notifiers processor the workspaces that had a transition,
+// or the ones that maintain a not ok state for more than the reminder interval (0 disables reminders).
+//
+// Each notifier processor is called independently, if any of them fails, the verdicts of the notified workspaces
+// will not be stored, so they are notified again on the next run.
+//
+// The processor will return all the received workspaces, not only the notified ones.
+func NewTransitionNotifyProcessor(logger log.Logger, repo WorkspaceVerdictRepository, reminderInterval time.Duration, notifiers []Processor) Processor {
+	logger = logger.WithValues(log.Kv{"workspace-processor": "TransitionNotify"})
+
+	return ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+		logger.Infof("Tracking workspaces drift state transitions")
+
+		now := time.Now()
+		notifyWks := []model.Workspace{}
+		verdicts := map[string]model.Verdict{}
+		for _, wk := range wks {
+			state := wk.DriftState()
+			if state == model.DriftStateUnknown || state == model.DriftStateWaiting {
+				continue
+			}
+
+			logger := logger.WithValues(log.Kv{"workspace": wk.Name, "run-id": wk.LastDriftPlan.ID})
+
+			prev, err := repo.GetPreviousVerdict(ctx, wk)
+			if err != nil && !errors.Is(err, internalerrors.ErrNotExist) {
+				// Don't notify on unknown previous states, could end in notifications spam.
+				logger.Errorf("Could not get previous verdict: %s", err)
+				continue
+			}
+
+			verdict := model.Verdict{
+				State:     state,
+				PlanID:    wk.LastDriftPlan.ID,
+				CreatedAt: wk.LastDriftPlan.CreatedAt,
+			}
+
+			switch {
+			// First verdict, notify only if something is wrong.
+			case prev == nil:
+				if state != model.DriftStateOK {
+					logger.Infof("Drift state transition: %s -> %s", model.DriftStateUnknown, state)
+					verdict.NotifiedAt = now
+				}
+
+			case prev.State != state:
+				logger.Infof("Drift state transition: %s -> %s", prev.State, state)
+				verdict.NotifiedAt = now
+
+			default:
+				verdict.NotifiedAt = prev.NotifiedAt
+
+				lastNotified := prev.NotifiedAt
+				if lastNotified.IsZero() {
+					lastNotified = prev.CreatedAt
+				}
+
+				if reminderInterval > 0 && state != model.DriftStateOK && now.Sub(lastNotified) >= reminderInterval {
+					logger.Infof("Drift state reminder: %s since %s", state, lastNotified.Format(time.RFC3339))
+					verdict.NotifiedAt = now
+				} else {
+					logger.Debugf("Ignoring workspace, drift state %s without changes", state)
+				}
+			}
+
+			if verdict.NotifiedAt.Equal(now) {
+				notifyWks = append(notifyWks, wk)
+			}
+			verdicts[wk.ID] = verdict
+		}
+
+		notifyFailed := false
+		if len(notifyWks) > 0 {
+			for _, n := range notifiers {
+				_, err := n.Process(ctx, notifyWks)
+				if err != nil {
+					// Don't stop the other notifiers because of a notification error.
+					logger.Errorf("Could not notify workspaces: %s", err)
+					notifyFailed = true
+				}
+			}
+		}
+
+		// Store the verdicts once notified.
+		for _, wk := range wks {
+			v, ok := verdicts[wk.ID]
+			if !ok {
+				continue
+			}
+
+			if notifyFailed && v.NotifiedAt.Equal(now) {
+				logger.WithValues(log.Kv{"workspace": wk.Name}).Warningf("Verdict not stored, workspace notification failed")
+				continue
+			}
+
+			err := repo.StoreVerdict(ctx, wk, v)
+			if err != nil {
+				logger.WithValues(log.Kv{"workspace": wk.Name}).Errorf("Could not store verdict: %s", err)
+			}
+		}
+
+		if notifyFailed {
+			logger.Warningf("%d workspaces notification failed", len(notifyWks))
+		} else {
+			logger.Infof("%d workspaces notified", len(notifyWks))
+		}
+
+		return wks, nil
+	})
+}
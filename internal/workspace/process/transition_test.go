@@ -0,0 +1,136 @@
+package process_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/slok/tfe-drift/internal/internalerrors"
+	"github.com/slok/tfe-drift/internal/log"
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/process"
+	"github.com/slok/tfe-drift/internal/workspace/process/processmock"
+)
+
+func TestTransitionNotifyProcessor(t *testing.T) {
+	t0 := time.Now()
+
+	driftWk := func(id string) model.Workspace {
+		return model.Workspace{ID: id, LastDriftPlan: &model.Plan{ID: "p-" + id, Status: model.PlanStatusFinishedOK, HasChanges: true, CreatedAt: t0}}
+	}
+	okWk := func(id string) model.Workspace {
+		return model.Workspace{ID: id, LastDriftPlan: &model.Plan{ID: "p-" + id, Status: model.PlanStatusFinishedOK, CreatedAt: t0}}
+	}
+	notified := func(notified bool) interface{} {
+		return mock.MatchedBy(func(v model.Verdict) bool { return v.NotifiedAt.After(t0) == notified })
+	}
+
+	tests := map[string]struct {
+		mock             func(mr *processmock.WorkspaceVerdictRepository)
+		reminderInterval time.Duration
+		notifyErr        error
+		workspaces       []model.Workspace
+		expNotified      []string
+		expErr           bool
+	}{
+		"Workspaces without previous verdicts should only notify the not ok ones.": {
+			mock: func(mr *processmock.WorkspaceVerdictRepository) {
+				mr.On("GetPreviousVerdict", mock.Anything, mock.Anything).Return(nil, internalerrors.ErrNotExist)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk1"), notified(true)).Once().Return(nil)
+				mr.On("StoreVerdict", mock.Anything, okWk("wk2"), notified(false)).Once().Return(nil)
+			},
+			workspaces:  []model.Workspace{driftWk("wk1"), okWk("wk2"), {ID: "wk3"}},
+			expNotified: []string{"wk1"},
+		},
+
+		"Workspaces with state transitions should be notified.": {
+			mock: func(mr *processmock.WorkspaceVerdictRepository) {
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk1")).Once().Return(&model.Verdict{State: model.DriftStateOK}, nil)
+				mr.On("GetPreviousVerdict", mock.Anything, okWk("wk2")).Once().Return(&model.Verdict{State: model.DriftStateDrift}, nil)
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk3")).Once().Return(&model.Verdict{State: model.DriftStateDrift, NotifiedAt: t0}, nil)
+				mr.On("GetPreviousVerdict", mock.Anything, okWk("wk4")).Once().Return(&model.Verdict{State: model.DriftStateOK}, nil)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk1"), notified(true)).Once().Return(nil)
+				mr.On("StoreVerdict", mock.Anything, okWk("wk2"), notified(true)).Once().Return(nil)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk3"), notified(false)).Once().Return(nil)
+				mr.On("StoreVerdict", mock.Anything, okWk("wk4"), notified(false)).Once().Return(nil)
+			},
+			workspaces:  []model.Workspace{driftWk("wk1"), okWk("wk2"), driftWk("wk3"), okWk("wk4")},
+			expNotified: []string{"wk1", "wk2"},
+		},
+
+		"Workspaces without transitions should be notified after the reminder interval.": {
+			reminderInterval: time.Hour,
+			mock: func(mr *processmock.WorkspaceVerdictRepository) {
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk1")).Once().Return(&model.Verdict{State: model.DriftStateDrift, NotifiedAt: t0.Add(-2 * time.Hour)}, nil)
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk2")).Once().Return(&model.Verdict{State: model.DriftStateDrift, NotifiedAt: t0.Add(-10 * time.Minute)}, nil)
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk3")).Once().Return(&model.Verdict{State: model.DriftStateDrift, CreatedAt: t0.Add(-3 * time.Hour)}, nil)
+				mr.On("GetPreviousVerdict", mock.Anything, okWk("wk4")).Once().Return(&model.Verdict{State: model.DriftStateOK, NotifiedAt: t0.Add(-2 * time.Hour)}, nil)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk1"), notified(true)).Once().Return(nil)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk2"), notified(false)).Once().Return(nil)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk3"), notified(true)).Once().Return(nil)
+				mr.On("StoreVerdict", mock.Anything, okWk("wk4"), notified(false)).Once().Return(nil)
+			},
+			workspaces:  []model.Workspace{driftWk("wk1"), driftWk("wk2"), driftWk("wk3"), okWk("wk4")},
+			expNotified: []string{"wk1", "wk3"},
+		},
+
+		"Errors getting the previous verdict should ignore the workspace.": {
+			mock: func(mr *processmock.WorkspaceVerdictRepository) {
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk1")).Once().Return(nil, fmt.Errorf("something"))
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk2")).Once().Return(nil, internalerrors.ErrNotExist)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk2"), notified(true)).Once().Return(nil)
+			},
+			workspaces:  []model.Workspace{driftWk("wk1"), driftWk("wk2")},
+			expNotified: []string{"wk2"},
+		},
+
+		"Errors notifying should not store the verdicts of the notified workspaces.": {
+			mock: func(mr *processmock.WorkspaceVerdictRepository) {
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk1")).Once().Return(&model.Verdict{State: model.DriftStateOK}, nil)
+				mr.On("GetPreviousVerdict", mock.Anything, driftWk("wk2")).Once().Return(&model.Verdict{State: model.DriftStateDrift, NotifiedAt: t0}, nil)
+				mr.On("StoreVerdict", mock.Anything, driftWk("wk2"), notified(false)).Once().Return(nil)
+			},
+			notifyErr:   fmt.Errorf("something"),
+			workspaces:  []model.Workspace{driftWk("wk1"), driftWk("wk2")},
+			expNotified: []string{"wk1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			mr := processmock.NewWorkspaceVerdictRepository(t)
+			test.mock(mr)
+
+			// The first notifier fails, the second one should be notified anyway.
+			gotNotified := []string{}
+			notifier := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+				for _, wk := range wks {
+					gotNotified = append(gotNotified, wk.ID)
+				}
+				return wks, nil
+			})
+			failingNotifier := process.ProcessorFunc(func(ctx context.Context, wks []model.Workspace) ([]model.Workspace, error) {
+				if test.notifyErr != nil {
+					return nil, test.notifyErr
+				}
+				return wks, nil
+			})
+			notifiers := []process.Processor{failingNotifier, notifier}
+
+			p := process.NewTransitionNotifyProcessor(log.Noop, mr, test.reminderInterval, notifiers)
+			gotWks, err := p.Process(context.TODO(), test.workspaces)
+
+			if test.expErr {
+				assert.Error(err)
+			} else if assert.NoError(err) {
+				assert.Equal(test.workspaces, gotWks)
+				assert.Equal(test.expNotified, gotNotified)
+			}
+		})
+	}
+}
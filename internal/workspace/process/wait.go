@@ -2,6 +2,7 @@ package process
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,8 +16,11 @@ type WorkspaceCheckPlanGetter interface {
 
 //go:generate mockery --case underscore --output processmock --outpkg processmock --name WorkspaceCheckPlanGetter
 
-func NewDriftDetectionPlanWaitProcessor(logger log.Logger, g WorkspaceCheckPlanGetter, pollingDuration, timeoutDuration time.Duration) Processor {
+func NewDriftDetectionPlanWaitProcessor(logger log.Logger, rec MetricsRecorder, g WorkspaceCheckPlanGetter, pollingDuration, timeoutDuration time.Duration) Processor {
 	logger = logger.WithValues(log.Kv{"workspace-processor": "DriftDetectionPlanWait"})
+	if rec == nil {
+		rec = NoopMetricsRecorder
+	}
 
 	// waitResult will be used to send results over a channel.
 	type waitResult struct {
@@ -46,8 +50,13 @@ func NewDriftDetectionPlanWaitProcessor(logger log.Logger, g WorkspaceCheckPlanG
 				logger.Infof("Waiting for drift detection plan to finish...")
 
 				plan, err := waitForPlan(ctx, g, wk, planID, pollingDuration, timeoutDuration)
-				if err == nil {
+				switch {
+				case err == nil:
 					wk.LastDriftPlan = plan
+				case errors.Is(err, context.DeadlineExceeded):
+					wk.ProcessErrors = append(wk.ProcessErrors, model.ProcessError{Kind: model.ProcessErrorKindWaitTimeout, Err: err})
+				default:
+					wk.ProcessErrors = append(wk.ProcessErrors, model.ProcessError{Kind: model.ProcessErrorKindAPI, Err: err})
 				}
 				c <- waitResult{wk: wk, err: err}
 			}()
@@ -56,6 +65,7 @@ func NewDriftDetectionPlanWaitProcessor(logger log.Logger, g WorkspaceCheckPlanG
 		// Wait for all workspace drift detection plan waiters to finish.
 		// We index the workspaces to maintain the order with the new result.
 		indexedWks := map[string]model.Workspace{}
+		timeouts := 0
 		for i := 0; i < len(wks); i++ {
 			res := <-c
 			logger := logger.WithValues(log.Kv{"workspace": res.wk.Name})
@@ -64,6 +74,9 @@ func NewDriftDetectionPlanWaitProcessor(logger log.Logger, g WorkspaceCheckPlanG
 			case res.wk.LastDriftPlan == nil:
 				logger.Debugf("No drift plan to check, ignoring...")
 			case res.err != nil:
+				if errors.Is(res.err, context.DeadlineExceeded) {
+					timeouts++
+				}
 				// TODO(slok): Add strict as an option so we can fail or not based on this option.
 				// Don't stop all the  process for other workspaces because of one workspace error.
 				logger.WithValues(log.Kv{"run-id": res.wk.LastDriftPlan.ID}).Errorf("Error while waiting for drift detection plan: %s", res.err)
@@ -74,6 +87,8 @@ func NewDriftDetectionPlanWaitProcessor(logger log.Logger, g WorkspaceCheckPlanG
 			indexedWks[res.wk.ID] = res.wk
 		}
 
+		rec.AddDriftDetectionPlanWaitTimeouts(ctx, timeouts)
+
 		// Create again our workspaces list in the same order but with the new data.
 		newWks := []model.Workspace{}
 		for _, wk := range wks {
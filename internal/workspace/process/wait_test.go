@@ -18,8 +18,10 @@ import (
 func TestDriftDetectionPlanWaitProcessor(t *testing.T) {
 	tests := map[string]struct {
 		mock          func(mg *processmock.WorkspaceCheckPlanGetter)
+		timeout       time.Duration
 		workspaces    []model.Workspace
 		expWorkspaces []model.Workspace
+		expTimeouts   int
 		expErr        bool
 	}{
 		"Not having workspaces shouldn't wait.": {
@@ -80,10 +82,31 @@ func TestDriftDetectionPlanWaitProcessor(t *testing.T) {
 			},
 			expWorkspaces: []model.Workspace{
 				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1", Status: model.PlanStatusFinishedOK}},
-				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2"}},
+				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2"}, ProcessErrors: []model.ProcessError{
+					{Kind: model.ProcessErrorKindAPI, Err: fmt.Errorf("could not get check plan %q: %w", "p2", fmt.Errorf("something"))},
+				}},
 				{ID: "wk3", LastDriftPlan: &model.Plan{ID: "p3", Status: model.PlanStatusFinishedOK}},
 			},
 		},
+
+		"Having plans that don't finish in time, should mark them as wait timeouts and not fail.": {
+			mock: func(mg *processmock.WorkspaceCheckPlanGetter) {
+				mg.On("GetCheckPlan", mock.Anything, mock.Anything, "p1").Once().Return(&model.Plan{ID: "p1", Status: model.PlanStatusFinishedOK}, nil)
+				mg.On("GetCheckPlan", mock.Anything, mock.Anything, "p2").Return(&model.Plan{ID: "p2", Status: model.PlanStatusWaiting}, nil)
+			},
+			timeout: 20 * time.Millisecond,
+			workspaces: []model.Workspace{
+				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1"}},
+				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2"}},
+			},
+			expWorkspaces: []model.Workspace{
+				{ID: "wk1", LastDriftPlan: &model.Plan{ID: "p1", Status: model.PlanStatusFinishedOK}},
+				{ID: "wk2", LastDriftPlan: &model.Plan{ID: "p2"}, ProcessErrors: []model.ProcessError{
+					{Kind: model.ProcessErrorKindWaitTimeout, Err: fmt.Errorf("context cancellation: %w", context.DeadlineExceeded)},
+				}},
+			},
+			expTimeouts: 1,
+		},
 	}
 
 	for name, test := range tests {
@@ -92,13 +115,20 @@ func TestDriftDetectionPlanWaitProcessor(t *testing.T) {
 			mg := processmock.NewWorkspaceCheckPlanGetter(t)
 			test.mock(mg)
 
-			p := process.NewDriftDetectionPlanWaitProcessor(log.Noop, mg, 1*time.Millisecond, 1*time.Hour)
+			timeout := test.timeout
+			if timeout == 0 {
+				timeout = 1 * time.Hour
+			}
+
+			rec := &testMetricsRecorder{}
+			p := process.NewDriftDetectionPlanWaitProcessor(log.Noop, rec, mg, 1*time.Millisecond, timeout)
 			gotWks, err := p.Process(context.Background(), test.workspaces)
 
 			if test.expErr {
 				assert.Error(err)
 			} else if assert.NoError(err) {
 				assert.Equal(test.expWorkspaces, gotWks)
+				assert.Equal(test.expTimeouts, rec.waitTimeouts)
 			}
 			mg.AssertExpectations(t) // Check the calls are exactly what we expect.
 		})
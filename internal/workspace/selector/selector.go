@@ -0,0 +1,135 @@
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/slok/tfe-drift/internal/model"
+)
+
+const (
+	tagPrefix  = "tag:"
+	namePrefix = "name:"
+	matchAll   = "*"
+)
+
+// Selector knows how to select workspaces by tag or name regex.
+//
+// The textual representation is:
+//   - `tag:<tag>`: Matches the workspaces that have the tag.
+//   - `name:<regex>`: Matches the workspaces whose name matches the regex.
+//   - `*`: Matches all the workspaces.
+type Selector struct {
+	raw    string
+	tag    string
+	nameRx *regexp.Regexp
+}
+
+// Parse parses a selector from its textual representation.
+func Parse(s string) (Selector, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == matchAll:
+		return Selector{raw: s}, nil
+	case strings.HasPrefix(s, tagPrefix):
+		tag := strings.TrimPrefix(s, tagPrefix)
+		if tag == "" {
+			return Selector{}, fmt.Errorf("tag selector requires a tag")
+		}
+		return Selector{raw: s, tag: tag}, nil
+	case strings.HasPrefix(s, namePrefix):
+		rx, err := regexp.Compile(strings.TrimPrefix(s, namePrefix))
+		if err != nil {
+			return Selector{}, fmt.Errorf("invalid name regex: %w", err)
+		}
+		return Selector{raw: s, nameRx: rx}, nil
+	}
+
+	return Selector{}, fmt.Errorf("invalid selector %q, must be `tag:<tag>`, `name:<regex>` or `*`", s)
+}
+
+// Match returns true if the workspace is selected by the selector.
+func (s Selector) Match(wk model.Workspace) bool {
+	switch {
+	case s.tag != "":
+		for _, t := range wk.Tags {
+			if t == s.tag {
+				return true
+			}
+		}
+		return false
+	case s.nameRx != nil:
+		return s.nameRx.MatchString(wk.Name)
+	}
+
+	return true
+}
+
+func (s Selector) String() string {
+	if s.raw == "" {
+		return matchAll
+	}
+	return s.raw
+}
+
+// Rule is a selector with an associated value.
+type Rule struct {
+	Selector Selector
+	Value    string
+}
+
+func (r Rule) String() string { return r.Selector.String() + "=" + r.Value }
+
+// ParseRule parses a rule with the `<selector>=<value>` format, the value is split
+// on the last `=`. If there isn't a selector, the rule will match all the workspaces.
+func ParseRule(s string) (Rule, error) {
+	i := strings.LastIndex(s, "=")
+	if i < 0 {
+		return Rule{Selector: Selector{raw: matchAll}, Value: strings.TrimSpace(s)}, nil
+	}
+
+	sel, err := Parse(s[:i])
+	if err != nil {
+		return Rule{}, err
+	}
+
+	return Rule{Selector: sel, Value: strings.TrimSpace(s[i+1:])}, nil
+}
+
+// ParseRules parses multiple rules.
+func ParseRules(ss []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(ss))
+	for _, s := range ss {
+		r, err := ParseRule(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule %q: %w", s, err)
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+// FirstMatch returns the first rule that matches the workspace.
+func FirstMatch(rules []Rule, wk model.Workspace) (Rule, bool) {
+	for _, r := range rules {
+		if r.Selector.Match(wk) {
+			return r, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// AllMatches returns all the rules that match the workspace.
+func AllMatches(rules []Rule, wk model.Workspace) []Rule {
+	res := []Rule{}
+	for _, r := range rules {
+		if r.Selector.Match(wk) {
+			res = append(res, r)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,154 @@
+package selector_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/slok/tfe-drift/internal/model"
+	"github.com/slok/tfe-drift/internal/workspace/selector"
+)
+
+func TestSelector(t *testing.T) {
+	tests := map[string]struct {
+		selector  string
+		workspace model.Workspace
+		expMatch  bool
+		expErr    bool
+	}{
+		"An invalid selector should fail.": {
+			selector: "something",
+			expErr:   true,
+		},
+
+		"An empty tag selector should fail.": {
+			selector: "tag:",
+			expErr:   true,
+		},
+
+		"An invalid name regex should fail.": {
+			selector: "name:[",
+			expErr:   true,
+		},
+
+		"A match all selector should match any workspace.": {
+			selector:  "*",
+			workspace: model.Workspace{Name: "wk1"},
+			expMatch:  true,
+		},
+
+		"A tag selector should match workspaces with the tag.": {
+			selector:  "tag:prod",
+			workspace: model.Workspace{Name: "wk1", Tags: []string{"team-a", "prod"}},
+			expMatch:  true,
+		},
+
+		"A tag selector should not match workspaces without the tag.": {
+			selector:  "tag:prod",
+			workspace: model.Workspace{Name: "wk1", Tags: []string{"team-a"}},
+			expMatch:  false,
+		},
+
+		"A name selector should match workspaces with the name.": {
+			selector:  "name:^prod-",
+			workspace: model.Workspace{Name: "prod-wk1"},
+			expMatch:  true,
+		},
+
+		"A name selector should not match workspaces without the name.": {
+			selector:  "name:^prod-",
+			workspace: model.Workspace{Name: "sandbox-wk1"},
+			expMatch:  false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			s, err := selector.Parse(test.selector)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+
+			if assert.NoError(err) {
+				assert.Equal(test.expMatch, s.Match(test.workspace))
+			}
+		})
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := map[string]struct {
+		rules          []string
+		workspace      model.Workspace
+		expFirstValue  string
+		expFirstMatch  bool
+		expAllValues   []string
+		expRuleStrings []string
+		expErr         bool
+	}{
+		"Invalid rules should fail.": {
+			rules:  []string{"something=value"},
+			expErr: true,
+		},
+
+		"Rules without selector should match all.": {
+			rules:          []string{"org/repo"},
+			workspace:      model.Workspace{Name: "wk1"},
+			expFirstValue:  "org/repo",
+			expFirstMatch:  true,
+			expAllValues:   []string{"org/repo"},
+			expRuleStrings: []string{"*=org/repo"},
+		},
+
+		"Multiple rules should return the first match and all the matches.": {
+			rules:          []string{"tag:t1=v1", "name:^wk=v2", "tag:t3=v3", "*=v4"},
+			workspace:      model.Workspace{Name: "wk1", Tags: []string{"t3"}},
+			expFirstValue:  "v2",
+			expFirstMatch:  true,
+			expAllValues:   []string{"v2", "v3", "v4"},
+			expRuleStrings: []string{"tag:t1=v1", "name:^wk=v2", "tag:t3=v3", "*=v4"},
+		},
+
+		"Not matching rules should return no match.": {
+			rules:          []string{"tag:t1=v1"},
+			workspace:      model.Workspace{Name: "wk1"},
+			expFirstMatch:  false,
+			expAllValues:   []string{},
+			expRuleStrings: []string{"tag:t1=v1"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert := assert.New(t)
+			require := require.New(t)
+
+			rules, err := selector.ParseRules(test.rules)
+			if test.expErr {
+				assert.Error(err)
+				return
+			}
+			require.NoError(err)
+
+			r, ok := selector.FirstMatch(rules, test.workspace)
+			assert.Equal(test.expFirstMatch, ok)
+			assert.Equal(test.expFirstValue, r.Value)
+
+			gotValues := []string{}
+			for _, r := range selector.AllMatches(rules, test.workspace) {
+				gotValues = append(gotValues, r.Value)
+			}
+			assert.Equal(test.expAllValues, gotValues)
+
+			gotRuleStrings := []string{}
+			for _, r := range rules {
+				gotRuleStrings = append(gotRuleStrings, r.String())
+			}
+			assert.Equal(test.expRuleStrings, gotRuleStrings)
+		})
+	}
+}